@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+	"github.com/malinatrash/golang-initializr/project_templates"
+	"github.com/malinatrash/golang-initializr/templates"
+)
+
+// Дизайнер сущностей работает на HTMX: сервер хранит только шаблоны, все
+// состояние формы приходит с каждым запросом и уезжает в ссылку на страницу
+
+// handleDesigner применяет операцию над схемой и перерисовывает дизайнер
+func handleDesigner(c echo.Context) error {
+	values, err := c.FormParams()
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Bad request")
+	}
+
+	form := parseProjectForm(values)
+	if values.Get("op") == "import-sql" {
+		// Ошибка импорта показывается в превью, сущности остаются прежними
+		imported, err := readSQLSchema(c)
+		switch {
+		case err != nil:
+			form.Error = err.Error()
+		case imported == nil:
+			form.Error = "SQL schema: no file uploaded"
+		default:
+			form.Entities = imported
+		}
+		return renderDesigner(c, form, templates.EntityDesigner)
+	}
+
+	entity, _ := strconv.Atoi(values.Get("entity"))
+	field, _ := strconv.Atoi(values.Get("field"))
+	form.Entities = applyDesignerOp(form.Entities, values.Get("op"), entity, field)
+
+	return renderDesigner(c, form, templates.EntityDesigner)
+}
+
+// handlePreview обновляет только превью, чтобы не сбивать фокус в редакторе
+func handlePreview(c echo.Context) error {
+	values, err := c.FormParams()
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Bad request")
+	}
+
+	return renderDesigner(c, parseProjectForm(values), templates.EntityPreview)
+}
+
+func renderDesigner(c echo.Context, form templates.ProjectForm, component func(templates.ProjectForm) templ.Component) error {
+	if form.Error == "" {
+		fillPreview(&form)
+	}
+
+	// Адресная строка всегда содержит ссылку на текущую конфигурацию
+	c.Response().Header().Set("HX-Replace-Url", form.ShareURL())
+	return component(form).Render(c.Request().Context(), c.Response().Writer)
+}
+
+func fillPreview(form *templates.ProjectForm) {
+	previews, err := project_templates.PreviewEntities(form.Entities)
+	if err != nil {
+		form.Error = err.Error()
+		return
+	}
+	form.Previews = previews
+}
+
+// projectFormFromQuery восстанавливает форму из ссылки, полученной от дизайнера
+func projectFormFromQuery(query url.Values) templates.ProjectForm {
+	form := templates.DefaultProjectForm()
+	if len(query) == 0 {
+		return form
+	}
+
+	form.Name = query.Get("name")
+	form.Dependencies = query["dependencies"]
+
+	var entities project_templates.Entities
+	// Испорченная ссылка не должна ломать страницу, остаются сущности по умолчанию
+	if err := entities.UnmarshalParam(query.Get("entities")); err == nil && len(entities) > 0 {
+		form.Entities = entities
+	}
+	return form
+}
+
+func parseProjectForm(values url.Values) templates.ProjectForm {
+	return templates.ProjectForm{
+		Name:         values.Get("name"),
+		Dependencies: values["dependencies"],
+		Entities:     parseEntityForm(values),
+	}
+}
+
+// parseEntityForm собирает схему из полей дизайнера. Индексы идут подряд
+// с нуля, поэтому перебор заканчивается на первом отсутствующем имени
+func parseEntityForm(values url.Values) project_templates.Entities {
+	var entities project_templates.Entities
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("entities.%d.", i)
+		if _, ok := values[prefix+"name"]; !ok {
+			break
+		}
+
+		entity := project_templates.Entity{
+			Name:         strings.TrimSpace(values.Get(prefix + "name")),
+			TableName:    values.Get(prefix + "table"),
+			IDColumnName: values.Get(prefix + "id_column"),
+			NoTimestamps: values.Get(prefix+"no_timestamps") != "",
+		}
+		for j := 0; ; j++ {
+			prefix := fmt.Sprintf("entities.%d.fields.%d.", i, j)
+			if _, ok := values[prefix+"name"]; !ok {
+				break
+			}
+
+			field := project_templates.Field{
+				Name:     strings.TrimSpace(values.Get(prefix + "name")),
+				Type:     values.Get(prefix + "type"),
+				Required: values.Get(prefix+"required") != "",
+				Unique:   values.Get(prefix+"unique") != "",
+				Indexed:  values.Get(prefix+"indexed") != "",
+				Ref:      values.Get(prefix + "ref"),
+
+				ColumnName: values.Get(prefix + "column"),
+				ColumnType: values.Get(prefix + "column_type"),
+				Null:       values.Get(prefix+"null") != "",
+			}
+			for _, v := range strings.Split(values.Get(prefix+"values"), ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					field.Values = append(field.Values, v)
+				}
+			}
+			entity.Fields = append(entity.Fields, field)
+		}
+		entities = append(entities, entity)
+	}
+	return entities
+}
+
+// applyDesignerOp добавляет или удаляет сущность или поле. Индексы приходят
+// от кнопок дизайнера, неизвестные значения просто игнорируются
+func applyDesignerOp(entities project_templates.Entities, op string, entity, field int) project_templates.Entities {
+	switch op {
+	case "add-entity":
+		entities = append(entities, project_templates.Entity{
+			Name:   fmt.Sprintf("Entity%d", len(entities)+1),
+			Fields: []project_templates.Field{{Name: "name", Type: project_templates.FieldString, Required: true}},
+		})
+	case "remove-entity":
+		if entity >= 0 && entity < len(entities) {
+			entities = append(entities[:entity:entity], entities[entity+1:]...)
+		}
+	case "add-field":
+		if entity >= 0 && entity < len(entities) {
+			fields := entities[entity].Fields
+			entities[entity].Fields = append(fields, project_templates.Field{
+				Name: fmt.Sprintf("field_%d", len(fields)+1),
+				Type: project_templates.FieldString,
+			})
+		}
+	case "remove-field":
+		if entity >= 0 && entity < len(entities) {
+			fields := entities[entity].Fields
+			if field >= 0 && field < len(fields) {
+				entities[entity].Fields = append(fields[:field:field], fields[field+1:]...)
+			}
+		}
+	}
+
+	// Relation поле без ссылки или со ссылкой на переименованную сущность
+	// указывает на первую сущность, как и выбранный браузером вариант в списке
+	names := make(map[string]bool, len(entities))
+	for _, e := range entities {
+		names[e.Name] = true
+	}
+	for i := range entities {
+		for j := range entities[i].Fields {
+			f := &entities[i].Fields[j]
+			if f.Type == project_templates.FieldRelation && !names[f.Ref] && len(entities) > 0 {
+				f.Ref = entities[0].Name
+			}
+		}
+	}
+	return entities
+}
@@ -3,7 +3,10 @@ package main
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"strings"
 
@@ -13,12 +16,17 @@ import (
 	"github.com/malinatrash/golang-initializr/templates"
 )
 
+// Максимальный размер загружаемой OpenAPI спецификации, SQL схемы или .proto файла
+const maxSpecSize = 1 << 20
+
 // Хранилище сгенерированных проектов
 var projectFiles = make(map[string]map[string]string)
 
 type ProjectRequest struct {
 	Name         string   `json:"name" form:"name"`
 	Dependencies []string `json:"dependencies" form:"dependencies"`
+	// Entities приходят JSON строкой из дизайнера сущностей
+	Entities project_templates.Entities `json:"entities" form:"entities"`
 }
 
 func main() {
@@ -35,13 +43,18 @@ func main() {
 	e.GET("/", handleIndex)
 	e.POST("/generate", handleGenerate)
 	e.GET("/download", handleDownload)
+	e.POST("/entities/designer", handleDesigner)
+	e.POST("/entities/preview", handlePreview)
 
 	// Start server
 	e.Logger.Fatal(e.Start(":8081"))
 }
 
 func handleIndex(c echo.Context) error {
-	return templates.Index().Render(c.Request().Context(), c.Response().Writer)
+	// Ссылка из дизайнера сущностей открывает форму в сохраненном состоянии
+	form := projectFormFromQuery(c.QueryParams())
+	fillPreview(&form)
+	return templates.Index(form).Render(c.Request().Context(), c.Response().Writer)
 }
 
 func handleGenerate(c echo.Context) error {
@@ -50,13 +63,44 @@ func handleGenerate(c echo.Context) error {
 		return c.String(http.StatusBadRequest, "Bad request")
 	}
 
+	// Форма дизайнера присылает сущности полями, API клиенты - JSON строкой
+	if len(req.Entities) == 0 {
+		if values, err := c.FormParams(); err == nil {
+			req.Entities = parseEntityForm(values)
+		}
+	}
+
+	// Загруженная SQL схема заменяет сущности из формы
+	imported, err := readSQLSchema(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if imported != nil {
+		req.Entities = imported
+	}
+
 	// Validate project name
 	if req.Name == "" {
 		return c.String(http.StatusBadRequest, "Project name is required")
 	}
 
+	// Parse the uploaded OpenAPI document for spec-first generation
+	spec, err := readOpenAPISpec(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Uploaded .proto files replace the entity gRPC services
+	protoSpec, err := readProtoFiles(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
 	// Generate project in memory
-	files := generateProject(req.Name, req.Dependencies)
+	files, err := generateProject(req.Name, req.Dependencies, req.Entities, spec, protoSpec)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
 
 	// Create a zip archive with project files
 	buf := new(bytes.Buffer)
@@ -91,6 +135,89 @@ func handleGenerate(c echo.Context) error {
 	return nil
 }
 
+// readOpenAPISpec возвращает nil, если спецификация не была загружена
+func readOpenAPISpec(c echo.Context) (*project_templates.OpenAPISpec, error) {
+	data, err := readFormFile(c, "openapi")
+	if err != nil {
+		return nil, fmt.Errorf("OpenAPI document: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return project_templates.ParseOpenAPI(data)
+}
+
+// readSQLSchema возвращает nil, если SQL схема не была загружена
+func readSQLSchema(c echo.Context) (project_templates.Entities, error) {
+	data, err := readFormFile(c, "schema")
+	if err != nil {
+		return nil, fmt.Errorf("SQL schema: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return project_templates.ParseSQLSchema(data)
+}
+
+// readProtoFiles возвращает nil, если .proto файлы не были загружены.
+// Файлы импортируют друг друга по именам, под которыми их загрузили
+func readProtoFiles(c echo.Context) (*project_templates.ProtoSpec, error) {
+	form, err := c.MultipartForm()
+	if errors.Is(err, http.ErrNotMultipart) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(".proto files: %w", err)
+	}
+
+	headers := form.File["proto"]
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	sources := make(map[string]string, len(headers))
+	for _, header := range headers {
+		if _, ok := sources[header.Filename]; ok {
+			return nil, fmt.Errorf(".proto files: %s is uploaded twice", header.Filename)
+		}
+		data, err := readUpload(header)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", header.Filename, err)
+		}
+		sources[header.Filename] = string(data)
+	}
+	return project_templates.ParseProto(sources)
+}
+
+// readFormFile читает загруженный файл целиком, nil - файла в запросе нет
+func readFormFile(c echo.Context, name string) ([]byte, error) {
+	file, err := c.FormFile(name)
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return readUpload(file)
+}
+
+func readUpload(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxSpecSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSpecSize {
+		return nil, errors.New("file is too large")
+	}
+	return data, nil
+}
+
 func handleDownload(c echo.Context) error {
 	// Получаем последний сгенерированный проект
 	session := c.QueryParam("session")
@@ -141,18 +268,29 @@ func handleDownload(c echo.Context) error {
 }
 
 // generateProject генерирует структуру проекта на основе выбранных зависимостей
-func generateProject(name string, dependencies []string) map[string]string {
+func generateProject(name string, dependencies []string, entities project_templates.Entities, spec *project_templates.OpenAPISpec, proto *project_templates.ProtoSpec) (map[string]string, error) {
 	// Создаем конфигурацию проекта
 	config := &project_templates.ProjectConfig{
 		Name:         name,
 		Dependencies: dependencies,
+		OpenAPI:      spec,
+		Proto:        proto,
+		Entities:     entities,
+	}
+
+	// Проверяем совместимость выбранных зависимостей
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	// Генерируем файлы проекта
-	files := config.GenerateProject()
+	files, err := config.GenerateProject()
+	if err != nil {
+		return nil, err
+	}
 
 	fmt.Printf("Generating project %s with dependencies: %s\n", name, strings.Join(dependencies, ", "))
 	fmt.Printf("Generated %d files\n", len(files))
 
-	return files
+	return files, nil
 }
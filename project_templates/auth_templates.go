@@ -0,0 +1,1119 @@
+package project_templates
+
+// Шаблоны для JWT аутентификации: Echo middleware, gRPC interceptors,
+// кеш JWKS и локальный заменитель identity provider для тестов и разработки
+
+const configAuthTemplate = `package config
+
+import "time"
+
+
+// AuthConfig configures JWT validation: HS256 tokens are checked with the
+// shared secret, RS256 tokens with the keys published at JWKSURL.
+// At least one of them has to be set
+type AuthConfig struct {
+	HS256Secret string
+	JWKSURL     string
+	// JWKSCacheTTL is how long fetched keys are used before the set is fetched again
+	JWKSCacheTTL time.Duration
+	// Issuer and Audience are checked against iss and aud when set
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew between the issuer and this service
+	Leeway time.Duration
+}
+
+
+func NewAuthConfig() AuthConfig {
+	return AuthConfig{
+		HS256Secret:  getEnv("JWT_HS256_SECRET", ""),
+		JWKSURL:      getEnv("JWT_JWKS_URL", ""),
+		JWKSCacheTTL: time.Duration(getEnvAsInt("JWT_JWKS_CACHE_TTL_MS", 300000)) * time.Millisecond,
+		Issuer:       getEnv("JWT_ISSUER", ""),
+		Audience:     getEnv("JWT_AUDIENCE", ""),
+		Leeway:       time.Duration(getEnvAsInt("JWT_LEEWAY_MS", 30000)) * time.Millisecond,
+	}
+}`
+
+const principalDomainTemplate = `package domain
+
+import "context"
+
+// Principal is the authenticated caller: the subject of the access token
+// and the roles it was granted
+type Principal struct {
+	Subject string
+	Roles   []string
+}
+
+type principalKey struct{}
+
+// WithPrincipal stores the caller in the request context
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller of the request; ok is false
+// when the call did not go through authentication
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+`
+
+const authModuleTemplate = `package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/domain"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(NewAuthenticator),
+)
+
+
+// Claims are the registered JWT claims and the roles of the subject
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string ` + "`json:\"roles,omitempty\"`" + `
+}
+
+
+// placeholderSecret is the secret of the local authdev example
+const placeholderSecret = "change-me"
+
+
+// Authenticator validates bearer tokens and turns them into a domain.Principal.
+// Middleware and interceptors for the transports are in echo.go{{if .HasDependency "grpc"}} and grpc.go{{end}}
+type Authenticator struct {
+	parser *jwt.Parser
+	secret []byte
+	jwks   *JWKS
+}
+
+
+func NewAuthenticator(cfg *config.Config, logger *zap.Logger) (*Authenticator, error) {
+	if cfg.Auth.HS256Secret == "" && cfg.Auth.JWKSURL == "" {
+		return nil, errors.New("auth: set JWT_HS256_SECRET or JWT_JWKS_URL")
+	}
+	// The placeholder is public, anyone could sign tokens with it
+	if cfg.Auth.HS256Secret == placeholderSecret {
+		return nil, errors.New("auth: JWT_HS256_SECRET is the " + placeholderSecret + " placeholder, set a real secret")
+	}
+
+	a := &Authenticator{}
+	var methods []string
+	if cfg.Auth.HS256Secret != "" {
+		a.secret = []byte(cfg.Auth.HS256Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.Auth.JWKSURL != "" {
+		a.jwks = NewJWKS(cfg.Auth.JWKSURL, cfg.Auth.JWKSCacheTTL, logger)
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	opts := []jwt.ParserOption{
+		// Only the configured algorithms are accepted, so "none" or an RS256
+		// public key used as an HMAC secret never get to the key lookup
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Auth.Leeway),
+	}
+	if cfg.Auth.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Auth.Issuer))
+	}
+	if cfg.Auth.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Auth.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+
+// Authenticate validates a raw token. Every failure is domain.ErrUnauthorized;
+// the message is safe to show to clients
+func (a *Authenticator) Authenticate(ctx context.Context, raw string) (domain.Principal, error) {
+	var claims Claims
+	_, err := a.parser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (any, error) {
+		return a.key(ctx, token)
+	})
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return domain.Principal{}, domain.Errorf(domain.ErrUnauthorized, "token is expired")
+	case err != nil:
+		return domain.Principal{}, domain.Errorf(domain.ErrUnauthorized, "invalid token")
+	case claims.Subject == "":
+		return domain.Principal{}, domain.Errorf(domain.ErrUnauthorized, "token has no subject")
+	}
+
+	return domain.Principal{Subject: claims.Subject, Roles: claims.Roles}, nil
+}
+
+
+// authenticateHeader validates the value of an Authorization header
+func (a *Authenticator) authenticateHeader(ctx context.Context, header string) (domain.Principal, error) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return domain.Principal{}, domain.Errorf(domain.ErrUnauthorized, "missing bearer token")
+	}
+	return a.Authenticate(ctx, strings.TrimSpace(token))
+}
+
+
+func (a *Authenticator) key(ctx context.Context, token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		return a.jwks.Key(ctx, kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
+`
+
+const authJWKSTemplate = `package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+
+// defaultMinRefresh limits fetches caused by unknown key ids, so tokens with
+// made up kids cannot turn every request into a call to the identity provider
+const defaultMinRefresh = 30 * time.Second
+
+
+// fetchTimeout bounds a fetch of the key set
+const fetchTimeout = 10 * time.Second
+
+
+// JWKS caches the RSA keys published at a JSON Web Key Set URL. The set is
+// fetched again when the TTL runs out or a token names an unknown kid, which
+// is how key rotation at the issuer shows up. A fetch runs in its own
+// goroutine without holding the lock: requests keep using the cached keys,
+// only a request with an unknown kid waits for the fetch to finish
+type JWKS struct {
+	url        string
+	ttl        time.Duration
+	minRefresh time.Duration
+	client     *http.Client
+	logger     *zap.Logger
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refreshing is closed when the running fetch is done, nil without one
+	refreshing chan struct{}
+}
+
+
+func NewJWKS(url string, ttl time.Duration, logger *zap.Logger) *JWKS {
+	return &JWKS{
+		url:        url,
+		ttl:        ttl,
+		minRefresh: defaultMinRefresh,
+		client:     &http.Client{},
+		logger:     logger,
+	}
+}
+
+
+// Key returns the public key with the given kid. A token without kid is
+// accepted when the set holds a single key
+func (j *JWKS) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	key, found := j.lookup(kid)
+	stale := time.Since(j.fetchedAt) > j.ttl
+	refreshed := j.refreshing
+	if (stale || !found) && time.Since(j.attemptedAt) > j.minRefresh {
+		refreshed = j.refresh()
+	}
+	j.mu.Unlock()
+
+	if !found && refreshed != nil {
+		// A cancelled request stops waiting, the fetch goes on for the others
+		select {
+		case <-refreshed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		j.mu.Lock()
+		key, found = j.lookup(kid)
+		j.mu.Unlock()
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+
+// refresh starts a fetch unless one is running and returns the channel that
+// is closed when it is done. The caller holds the lock
+func (j *JWKS) refresh() chan struct{} {
+	if j.refreshing != nil {
+		return j.refreshing
+	}
+
+	j.attemptedAt = time.Now()
+	done := make(chan struct{})
+	j.refreshing = done
+
+	go func() {
+		defer close(done)
+
+		// The fetch is shared, so it does not depend on any request context
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		keys, err := j.fetch(ctx)
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.refreshing = nil
+		if err != nil {
+			// Cached keys keep working while the identity provider is unavailable
+			j.logger.Warn("Failed to fetch JWKS", zap.String("url", j.url), zap.Error(err))
+			return
+		}
+		j.keys = keys
+		j.fetchedAt = time.Now()
+	}()
+	return done
+}
+
+
+func (j *JWKS) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+
+// jsonWebKey holds the RFC 7517 members of an RSA signing key
+type jsonWebKey struct {
+	Kty string ` + "`json:\"kty\"`" + `
+	Kid string ` + "`json:\"kid\"`" + `
+	Use string ` + "`json:\"use\"`" + `
+	N   string ` + "`json:\"n\"`" + `
+	E   string ` + "`json:\"e\"`" + `
+}
+
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey ` + "`json:\"keys\"`" + `
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode key set: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			j.logger.Warn("Skipping invalid JWKS key", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+
+func (k jsonWebKey) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decode modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decode exponent: %w", err)
+	}
+	if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid modulus or exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+`
+
+const authEchoTemplate = `package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"{{.Name}}/internal/domain"
+)
+
+
+// Middleware answers requests without a valid bearer token with 401 and puts
+// the principal into the request context for handlers and use cases
+func (a *Authenticator) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			principal, err := a.authenticateHeader(ctx, c.Request().Header.Get(echo.HeaderAuthorization))
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+			}
+
+			c.SetRequest(c.Request().WithContext(domain.WithPrincipal(ctx, principal)))
+			return next(c)
+		}
+	}
+}
+
+
+// TokenFromQuery moves the access_token query parameter (RFC 6750, section
+// 2.3) into the Authorization header for paths with one of the prefixes.
+// Browser WebSocket and EventSource cannot set headers, so streams take the
+// token from the URL. Register it with Echo.Pre: it then runs before the
+// request log, which records the URL without the token
+func TokenFromQuery(prefixes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			query := req.URL.Query()
+			token := query.Get("access_token")
+			if token == "" || !hasAnyPrefix(req.URL.Path, prefixes) {
+				return next(c)
+			}
+
+			if req.Header.Get(echo.HeaderAuthorization) == "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			}
+			query.Del("access_token")
+			req.URL.RawQuery = query.Encode()
+			req.RequestURI = req.URL.RequestURI()
+			return next(c)
+		}
+	}
+}
+
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+`
+
+const authGRPCTemplate = `package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"{{.Name}}/internal/domain"
+)
+
+
+// publicMethods are served without a token: health probes and tooling
+// do not carry credentials
+var publicMethods = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+
+func isPublic(fullMethod string) bool {
+	for _, prefix := range publicMethods {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+
+// UnaryServerInterceptor rejects calls without a valid token in the
+// authorization metadata with Unauthenticated
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := a.authenticateContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := a.authenticateContext(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+
+func (a *Authenticator) authenticateContext(ctx context.Context) (context.Context, error) {
+	var header string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		header = values[0]
+	}
+
+	principal, err := a.authenticateHeader(ctx, header)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return domain.WithPrincipal(ctx, principal), nil
+}
+
+
+// principalStream replaces the stream context with one carrying the principal
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+`
+
+const authTestServerTemplate = `// Package authtest stands in for an identity provider in tests and local
+// development: it publishes a JWKS document and mints tokens signed with
+// the matching private key
+package authtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+
+// JWKSPath is where the issuer handler publishes its keys
+const JWKSPath = "/.well-known/jwks.json"
+
+
+// claims mirror auth.Claims: registered claims and the roles of the subject
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string ` + "`json:\"roles,omitempty\"`" + `
+}
+
+
+// Issuer signs RS256 tokens. Name and Audience go into the iss and aud claims
+type Issuer struct {
+	Name     string
+	Audience string
+
+	mu      sync.RWMutex
+	kid     string
+	key     *rsa.PrivateKey
+	rotated int
+}
+
+
+func NewIssuer(name, audience string) (*Issuer, error) {
+	issuer := &Issuer{Name: name, Audience: audience}
+	if err := issuer.Rotate(); err != nil {
+		return nil, err
+	}
+	return issuer, nil
+}
+
+
+// Rotate replaces the signing key. Only the new key is published afterwards,
+// tokens signed with the old one stop validating once verifiers refetch the set
+func (i *Issuer) Rotate() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rotated++
+	i.kid = fmt.Sprintf("key-%d", i.rotated)
+	i.key = key
+	return nil
+}
+
+
+// Token mints a token for subject with the given roles, valid for ttl
+func (i *Issuer) Token(subject string, roles []string, ttl time.Duration) (string, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, newClaims(i.Name, i.Audience, subject, roles, ttl))
+	token.Header["kid"] = i.kid
+	return token.SignedString(i.key)
+}
+
+
+// JWKS returns the public key set in RFC 7517 form
+func (i *Issuer) JWKS() map[string]any {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	key := map[string]string{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": i.kid,
+		"n":   base64.RawURLEncoding.EncodeToString(i.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.E)).Bytes()),
+	}
+	return map[string]any{"keys": []map[string]string{key}}
+}
+
+
+// Handler serves the key set at JWKSPath and mints tokens at
+// GET /token?sub=alice&role=admin&ttl=1h
+func (i *Issuer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+JWKSPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(i.JWKS())
+	})
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.URL.Query().Get("sub")
+		if subject == "" {
+			subject = "dev"
+		}
+		ttl := time.Hour
+		if value := r.URL.Query().Get("ttl"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				http.Error(w, "invalid ttl", http.StatusBadRequest)
+				return
+			}
+			ttl = parsed
+		}
+
+		token, err := i.Token(subject, r.URL.Query()["role"], ttl)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, token)
+	})
+	return mux
+}
+
+
+// NewServer starts a stand-in identity provider for the issuer. The JWKS URL
+// to configure is server.URL + JWKSPath; close the server when done
+func NewServer(issuer *Issuer) *httptest.Server {
+	return httptest.NewServer(issuer.Handler())
+}
+
+
+// HS256Token mints a token signed with a shared secret
+func HS256Token(secret, issuer, audience, subject string, roles []string, ttl time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(issuer, audience, subject, roles, ttl))
+	return token.SignedString([]byte(secret))
+}
+
+
+func newClaims(issuer, audience, subject string, roles []string, ttl time.Duration) claims {
+	now := time.Now()
+	c := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Roles: roles,
+	}
+	if audience != "" {
+		c.Audience = jwt.ClaimStrings{audience}
+	}
+	return c
+}
+
+
+// BearerHeader formats a token as an Authorization header value
+func BearerHeader(token string) string {
+	return "Bearer " + strings.TrimSpace(token)
+}
+`
+
+const authTestTemplate = `package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+{{- if .HasDependency "grpc"}}
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+{{- end}}
+
+	"{{.Name}}/internal/auth/authtest"
+	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/domain"
+)
+
+
+const (
+	testSecret   = "test-secret"
+	testIssuer   = "https://issuer.test"
+	testAudience = "{{.GetProjectName}}"
+)
+
+
+// newTestAuthenticator accepts HS256 tokens signed with testSecret and RS256
+// tokens of the issuer, whose keys are served by a stand-in JWKS server.
+// fetches counts requests for the key set
+func newTestAuthenticator(t *testing.T, issuer *authtest.Issuer) (*Authenticator, *atomic.Int32) {
+	t.Helper()
+
+	var fetches atomic.Int32
+	handler := issuer.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == authtest.JWKSPath {
+			fetches.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{Auth: config.AuthConfig{
+		HS256Secret:  testSecret,
+		JWKSURL:      server.URL + authtest.JWKSPath,
+		JWKSCacheTTL: time.Hour,
+		Issuer:       testIssuer,
+		Audience:     testAudience,
+	}}
+	authenticator, err := NewAuthenticator(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	return authenticator, &fetches
+}
+
+
+func newTestIssuer(t *testing.T) *authtest.Issuer {
+	t.Helper()
+	issuer, err := authtest.NewIssuer(testIssuer, testAudience)
+	if err != nil {
+		t.Fatalf("NewIssuer: %v", err)
+	}
+	return issuer
+}
+
+
+func rs256(t *testing.T, issuer *authtest.Issuer, subject string, roles []string, ttl time.Duration) string {
+	t.Helper()
+	token, err := issuer.Token(subject, roles, ttl)
+	if err != nil {
+		t.Fatalf("mint token: %v", err)
+	}
+	return token
+}
+
+
+func hs256(t *testing.T, secret, iss, aud, subject string) string {
+	t.Helper()
+	token, err := authtest.HS256Token(secret, iss, aud, subject, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("mint token: %v", err)
+	}
+	return token
+}
+
+
+func TestNewAuthenticatorRequiresSecret(t *testing.T) {
+	for _, secret := range []string{"", placeholderSecret} {
+		cfg := &config.Config{Auth: config.AuthConfig{HS256Secret: secret}}
+		if _, err := NewAuthenticator(cfg, zap.NewNop()); err == nil {
+			t.Errorf("expected JWT_HS256_SECRET=%q to be rejected", secret)
+		}
+	}
+}
+
+
+func TestAuthenticate(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authenticator, _ := newTestAuthenticator(t, issuer)
+
+	stranger, err := authtest.NewIssuer(testIssuer, testAudience)
+	if err != nil {
+		t.Fatalf("NewIssuer: %v", err)
+	}
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("mint token: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		subject string
+	}{
+		{"rs256 from jwks", rs256(t, issuer, "alice", []string{"admin"}, time.Hour), "alice"},
+		{"hs256 with shared secret", hs256(t, testSecret, testIssuer, testAudience, "bob"), "bob"},
+		{"expired", rs256(t, issuer, "alice", nil, -time.Hour), ""},
+		{"wrong secret", hs256(t, "other-secret", testIssuer, testAudience, "bob"), ""},
+		{"wrong issuer", hs256(t, testSecret, "https://other.test", testAudience, "bob"), ""},
+		{"wrong audience", hs256(t, testSecret, testIssuer, "other", "bob"), ""},
+		{"no subject", hs256(t, testSecret, testIssuer, testAudience, ""), ""},
+		{"signed with another key", rs256(t, stranger, "alice", nil, time.Hour), ""},
+		{"alg none", none, ""},
+		{"garbage", "not-a-token", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), tt.token)
+			if tt.subject == "" {
+				if !errors.Is(err, domain.ErrUnauthorized) {
+					t.Fatalf("expected ErrUnauthorized, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if principal.Subject != tt.subject {
+				t.Errorf("expected subject %q, got %q", tt.subject, principal.Subject)
+			}
+		})
+	}
+}
+
+
+func TestAuthenticateRoles(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authenticator, _ := newTestAuthenticator(t, issuer)
+
+	principal, err := authenticator.Authenticate(context.Background(), rs256(t, issuer, "alice", []string{"admin", "viewer"}, time.Hour))
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if len(principal.Roles) != 2 || principal.Roles[0] != "admin" || principal.Roles[1] != "viewer" {
+		t.Errorf("unexpected roles %v", principal.Roles)
+	}
+}
+
+
+func TestJWKSIsCached(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authenticator, fetches := newTestAuthenticator(t, issuer)
+
+	token := rs256(t, issuer, "alice", nil, time.Hour)
+	for i := 0; i < 3; i++ {
+		if _, err := authenticator.Authenticate(context.Background(), token); err != nil {
+			t.Fatalf("Authenticate: %v", err)
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("expected one JWKS fetch, got %d", got)
+	}
+}
+
+
+func TestJWKSKeyRotation(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authenticator, fetches := newTestAuthenticator(t, issuer)
+	authenticator.jwks.minRefresh = 0
+
+	if _, err := authenticator.Authenticate(context.Background(), rs256(t, issuer, "alice", nil, time.Hour)); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	if err := issuer.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// The new kid is unknown to the cache, so the set is fetched again
+	if _, err := authenticator.Authenticate(context.Background(), rs256(t, issuer, "alice", nil, time.Hour)); err != nil {
+		t.Fatalf("Authenticate after rotation: %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("expected two JWKS fetches, got %d", got)
+	}
+}
+
+
+func TestJWKSRefreshDoesNotBlockRequests(t *testing.T) {
+	issuer := newTestIssuer(t)
+	handler := issuer.Handler()
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every fetch after the first hangs until the test is done
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	defer close(release)
+
+	jwks := NewJWKS(server.URL+authtest.JWKSPath, time.Hour, zap.NewNop())
+	if _, err := jwks.Key(context.Background(), ""); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+
+	// The stale set keeps being served while it is fetched again
+	jwks.ttl, jwks.minRefresh = 0, 0
+	if _, err := jwks.Key(context.Background(), ""); err != nil {
+		t.Fatalf("Key with a stale set: %v", err)
+	}
+
+	// An unknown kid waits for the fetch, until its own context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := jwks.Key(ctx, "rotated"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled request to stop waiting, got %v", err)
+	}
+}
+
+
+func TestMiddleware(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authenticator, _ := newTestAuthenticator(t, issuer)
+
+	server := echo.New()
+	server.GET("/", func(c echo.Context) error {
+		principal, ok := domain.PrincipalFromContext(c.Request().Context())
+		if !ok {
+			return c.NoContent(http.StatusInternalServerError)
+		}
+		return c.String(http.StatusOK, principal.Subject)
+	}, authenticator.Middleware())
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"valid token", authtest.BearerHeader(rs256(t, issuer, "alice", nil, time.Hour)), http.StatusOK},
+		{"no header", "", http.StatusUnauthorized},
+		{"basic auth", "Basic YWxpY2U6c2VjcmV0", http.StatusUnauthorized},
+		{"invalid token", "Bearer not-a-token", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && rec.Body.String() != "alice" {
+				t.Errorf("expected principal alice, got %q", rec.Body.String())
+			}
+			if tt.status == http.StatusUnauthorized && rec.Header().Get(echo.HeaderWWWAuthenticate) != "Bearer" {
+				t.Errorf("expected WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+{{- if .HasDependency "grpc"}}
+
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authenticator, _ := newTestAuthenticator(t, issuer)
+	interceptor := authenticator.UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		principal, _ := domain.PrincipalFromContext(ctx)
+		return principal.Subject, nil
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		code   codes.Code
+	}{
+		{"valid token", "/api.v1.Service/Get", authtest.BearerHeader(rs256(t, issuer, "alice", nil, time.Hour)), codes.OK},
+		{"no token", "/api.v1.Service/Get", "", codes.Unauthenticated},
+		{"expired token", "/api.v1.Service/Get", authtest.BearerHeader(rs256(t, issuer, "alice", nil, -time.Hour)), codes.Unauthenticated},
+		{"health is public", "/grpc.health.v1.Health/Check", "", codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.header))
+			}
+
+			resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("expected %s, got %s (%v)", tt.code, code, err)
+			}
+			if tt.code == codes.OK && tt.header != "" && resp != "alice" {
+				t.Errorf("expected principal alice, got %v", resp)
+			}
+		})
+	}
+}
+{{- end}}
+`
+
+const authDevCommandTemplate = `// authdev is a stand-in identity provider for local development. It serves
+// a JWKS document and mints RS256 tokens that the service accepts:
+//
+//	go run ./cmd/authdev -addr :8090
+//	JWT_JWKS_URL=http://localhost:8090/.well-known/jwks.json go run main.go
+//	curl -H "Authorization: Bearer $(curl -s 'localhost:8090/token?sub=alice&role=admin')" localhost:8080/api/...
+//
+// With -hs256 it prints a single token signed with JWT_HS256_SECRET and exits.
+// Keys live in memory: tokens stop validating once authdev is restarted
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"{{.Name}}/internal/auth/authtest"
+	"{{.Name}}/internal/config"
+)
+
+
+func main() {
+	addr := flag.String("addr", ":8090", "address of the JWKS and token endpoints")
+	hs256 := flag.Bool("hs256", false, "print an HS256 token signed with JWT_HS256_SECRET and exit")
+	subject := flag.String("sub", "dev", "subject of the -hs256 token")
+	roles := flag.String("roles", "", "comma separated roles of the -hs256 token")
+	ttl := flag.Duration("ttl", time.Hour, "lifetime of the -hs256 token")
+	flag.Parse()
+
+	// iss and aud have to match what the service checks
+	cfg := config.NewAuthConfig()
+
+	if *hs256 {
+		if cfg.HS256Secret == "" {
+			log.Fatal("JWT_HS256_SECRET is not set")
+		}
+		var roleList []string
+		if *roles != "" {
+			roleList = strings.Split(*roles, ",")
+		}
+		token, err := authtest.HS256Token(cfg.HS256Secret, cfg.Issuer, cfg.Audience, *subject, roleList, *ttl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(token)
+		return
+	}
+
+	issuer, err := authtest.NewIssuer(cfg.Issuer, cfg.Audience)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Serving %s and /token?sub=alice&role=admin on %s", authtest.JWKSPath, *addr)
+	log.Fatal(http.ListenAndServe(*addr, issuer.Handler()))
+}
+`
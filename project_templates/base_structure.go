@@ -2,6 +2,7 @@ package project_templates
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"text/template"
 )
@@ -9,6 +10,19 @@ import (
 type ProjectConfig struct {
 	Name         string
 	Dependencies []string
+	// OpenAPI is set in spec-first mode, when the HTTP API is generated
+	// from an uploaded OpenAPI document
+	OpenAPI *OpenAPISpec
+	// Proto is set in gRPC-first mode, when the gRPC API is generated
+	// from uploaded .proto files instead of the entity services
+	Proto *ProtoSpec
+	// Entities - сущности, для которых генерируется CRUD стек.
+	// Пустой список заменяется на DefaultEntities
+	Entities Entities
+
+	// renderErr - первая ошибка выполнения шаблона или компиляции .proto,
+	// ее возвращает GenerateProject
+	renderErr error
 }
 
 func (p *ProjectConfig) GetProjectName() string {
@@ -25,48 +39,277 @@ func (p *ProjectConfig) HasDependency(name string) bool {
 	return false
 }
 
+// httpFrameworks maps HTTP dependencies to the framework they generate.
+// They are mutually exclusive; "http" is kept as the Echo id for compatibility.
+var httpFrameworks = []struct {
+	Dependency string
+	Framework  string
+}{
+	{"http", "echo"},
+	{"chi", "chi"},
+	{"gin", "gin"},
+	{"fiber", "fiber"},
+	{"servemux", "servemux"},
+}
+
+// modulePath is a conservative check for Go module paths: the name ends up
+// in go.mod, import statements and the go_package option of .proto files
+var modulePath = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._~-]*(/[A-Za-z0-9._~-]+)*$`)
+
+func (p *ProjectConfig) Validate() error {
+	if !modulePath.MatchString(p.Name) {
+		return fmt.Errorf("invalid project name %q: expected a Go module path like github.com/username/project", p.Name)
+	}
+
+	var selected []string
+	for _, f := range httpFrameworks {
+		if p.HasDependency(f.Dependency) {
+			selected = append(selected, f.Dependency)
+		}
+	}
+	if len(selected) > 1 {
+		return fmt.Errorf("only one HTTP framework can be selected, got: %s", strings.Join(selected, ", "))
+	}
+	if len(selected) == 0 && !p.HasDependency("grpc") {
+		return fmt.Errorf("a project without an HTTP API requires gRPC")
+	}
+	if p.HasDependency("graphql") && len(selected) == 0 {
+		return fmt.Errorf("the GraphQL API is served by the HTTP server and requires an HTTP framework")
+	}
+	if p.OpenAPI != nil && !p.HasDependency("http") {
+		return fmt.Errorf("spec-first generation requires the Echo HTTP framework")
+	}
+	if p.HasDependency("grpc-gateway") {
+		if !p.HasDependency("grpc") || !p.HasDependency("http") {
+			return fmt.Errorf("grpc-gateway requires gRPC and the Echo HTTP framework")
+		}
+		if p.OpenAPI != nil {
+			return fmt.Errorf("grpc-gateway cannot be combined with spec-first generation")
+		}
+	}
+	if p.Proto != nil {
+		if !p.HasDependency("grpc") {
+			return fmt.Errorf("imported .proto files require the gRPC dependency")
+		}
+		if p.HasDependency("grpc-gateway") {
+			return fmt.Errorf("grpc-gateway cannot be combined with imported .proto files")
+		}
+	}
+	if p.HasDependency("realtime") && p.HTTPFramework() != "echo" {
+		return fmt.Errorf("realtime endpoints are served by the Echo HTTP framework")
+	}
+	if p.HasDependency("auth") && p.HTTPFramework() != "echo" {
+		return fmt.Errorf("auth middleware is generated for the Echo HTTP framework")
+	}
+	if p.HasDependency("rbac") && !p.HasDependency("auth") {
+		return fmt.Errorf("rbac takes the caller's roles from the access token and requires auth")
+	}
+	if p.HasDependency("outbox") && !(p.HasDependency("postgres") && p.HasDependency("kafka")) {
+		return fmt.Errorf("outbox requires postgres and kafka")
+	}
+	if err := p.Entities.Validate(); err != nil {
+		return err
+	}
+	if p.Proto != nil {
+		entities := p.Entities
+		if len(entities) == 0 {
+			entities = DefaultEntities()
+		}
+		if err := p.Proto.conflicts(entities); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ProjectConfig) HasHTTP() bool {
+	for _, f := range httpFrameworks {
+		if p.HasDependency(f.Dependency) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPFramework returns the selected HTTP framework, or "" when the project
+// has no HTTP API and is served over gRPC only
+func (p *ProjectConfig) HTTPFramework() string {
+	for _, f := range httpFrameworks {
+		if p.HasDependency(f.Dependency) {
+			return f.Framework
+		}
+	}
+	return ""
+}
+
+// HasGateway reports whether the REST API is served by grpc-gateway
+// from the gRPC services instead of hand-written Echo handlers
+func (p *ProjectConfig) HasGateway() bool {
+	return p.HasDependency("grpc-gateway") && p.HasDependency("grpc") && p.HasDependency("http") && p.OpenAPI == nil && p.Proto == nil
+}
+
+// HasGoogleAPIs reports whether .proto files import google/api, whose Go
+// code and buf dependency come from googleapis
+func (p *ProjectConfig) HasGoogleAPIs() bool {
+	return p.HasGateway() || (p.Proto != nil && p.Proto.GoogleAPIs)
+}
+
+func (p *ProjectConfig) HasMessaging() bool {
+	return p.HasDependency("kafka") || p.HasDependency("nats") || p.HasDependency("rabbitmq")
+}
+
+// HasOutbox reports whether entity events are written to a Postgres outbox
+// table and relayed to Kafka instead of being published directly
+func (p *ProjectConfig) HasOutbox() bool {
+	return p.HasDependency("outbox") && p.HasDependency("postgres") && p.HasDependency("kafka")
+}
+
+// HasRepositoryCache reports whether the postgres repositories are wrapped
+// in a Redis cache-aside decorator
+func (p *ProjectConfig) HasRepositoryCache() bool {
+	return p.HasDependency("redis") && p.HasDependency("postgres")
+}
+
+func (p *ProjectConfig) render(name, text string) string {
+	return p.execute(name, text, p)
+}
+
+// execute рендерит шаблон с данными data. Ошибка выполнения запоминается:
+// иначе пользователь получил бы обрезанный файл и узнал об этом только при сборке
+func (p *ProjectConfig) execute(name, text string, data any) string {
+	tmpl := template.Must(template.New(name).Parse(text))
+	var content strings.Builder
+	if err := tmpl.Execute(&content, data); err != nil && p.renderErr == nil {
+		p.renderErr = fmt.Errorf("render %s: %w", name, err)
+	}
+	return content.String()
+}
+
+// entityScope - данные шаблонов одной сущности: .Name и остальные методы
+// проекта доступны как обычно, сама сущность - в .Entity
+type entityScope struct {
+	*ProjectConfig
+	Entity Entity
+}
+
+func (p *ProjectConfig) renderEntity(name, text string, entity Entity) string {
+	return p.execute(name, text, entityScope{ProjectConfig: p, Entity: entity})
+}
+
+// protoScope - данные шаблонов одного загруженного .proto файла или сервиса
+type protoScope struct {
+	*ProjectConfig
+	File    ProtoFile
+	Service ProtoService
+}
+
+func (p *ProjectConfig) renderProto(name, text string, scope protoScope) string {
+	scope.ProjectConfig = p
+	return p.execute(name, text, scope)
+}
+
+// prepareEntities подставляет схему по умолчанию и связывает поля с сущностями
+func (p *ProjectConfig) prepareEntities() {
+	if len(p.Entities) == 0 {
+		p.Entities = DefaultEntities()
+	}
+	p.Entities = p.Entities.link()
+}
+
+// migrationName - имя файла миграции golang-migrate
+func migrationName(version int, name, direction string) string {
+	return fmt.Sprintf("migrations/%06d_%s.%s.sql", version, name, direction)
+}
+
 func (p *ProjectConfig) BaseFiles() map[string]string {
 	files := make(map[string]string)
 
-	readmeTmpl := template.Must(template.New("readme").Parse(readmeTemplate))
-	var readmeContent strings.Builder
-	readmeTmpl.Execute(&readmeContent, p)
-	files["README.md"] = readmeContent.String()
+	files["README.md"] = p.render("readme", readmeTemplate)
 
-	goModTmpl := template.Must(template.New("gomod").Parse(goModTemplate))
-	var goModContent strings.Builder
-	goModTmpl.Execute(&goModContent, p)
-	files["go.mod"] = goModContent.String()
+	files["go.mod"] = p.render("gomod", goModTemplate)
 
-	dockerfileTmpl := template.Must(template.New("dockerfile").Parse(dockerfileTemplate))
-	var dockerfileContent strings.Builder
-	dockerfileTmpl.Execute(&dockerfileContent, p)
-	files["Dockerfile"] = dockerfileContent.String()
+	files["Dockerfile"] = p.render("dockerfile", dockerfileTemplate)
 
 	files[".gitignore"] = gitignoreTemplate
 
-	projectName := p.GetProjectName()
-	files["internal/app/app.go"] = fmt.Sprintf(appTemplate, projectName, projectName, projectName, projectName, projectName)
+	files["main.go"] = p.render("main", mainTemplate)
+
+	files["internal/app/app.go"] = p.render("app", appTemplate)
 
-	files["internal/config/config.go"] = configMainTemplate
+	files["internal/config/config.go"] = p.render("config", configMainTemplate)
 	files["internal/config/app.go"] = configAppTemplate
 	files["internal/config/server.go"] = configServerTemplate
+	files["internal/config/health.go"] = configHealthTemplate
 	files["internal/config/utils.go"] = configUtilsTemplate
 
-	files["internal/domain/user.go"] = userDomainTemplate
+	files["internal/domain/events.go"] = eventsDomainTemplate
+	files["internal/domain/errors.go"] = p.render("domainerrors", domainErrorsTemplate)
+	files["internal/domain/list.go"] = listDomainTemplate
+	files["internal/domain/validation.go"] = validationDomainTemplate
+	files["internal/usecase/usecase.go"] = p.render("usecase", usecaseModuleTemplate)
+	files["internal/usecase/validation.go"] = p.render("usecasevalidation", validationUsecaseTemplate)
+	files["internal/repository/repository.go"] = p.render("repository", repositoryModuleTemplate)
+
+	for _, entity := range p.Entities {
+		files["internal/domain/"+entity.Snake()+".go"] = p.renderEntity("domain", entityDomainTemplate, entity)
+		files["internal/usecase/"+entity.Snake()+"_usecase.go"] = p.renderEntity("entityusecase", entityUsecaseTemplate, entity)
+		if !p.HasDependency("postgres") {
+			files["internal/repository/"+entity.Snake()+"_repository.go"] = p.renderEntity("entityrepository", entityRepositoryTemplate, entity)
+		}
+	}
+	if !p.HasDependency("postgres") {
+		files["internal/repository/"+p.Entities[0].Snake()+"_repository_test.go"] = p.renderEntity("entityrepositorytest", entityRepositoryTestTemplate, p.Entities[0])
+	}
 
-	files["internal/usecase/user_usecase.go"] = userUsecaseTemplate
+	switch p.HTTPFramework() {
+	case "":
+		// Без HTTP API сервис доступен только по gRPC
+	case "chi":
+		files["internal/delivery/http/handler.go"] = p.render("handler", chiHandlerTemplate)
+		files["internal/bootstrap/http.go"] = p.render("bootstraphttp", bootstrapChiTemplate)
+	case "gin":
+		files["internal/delivery/http/handler.go"] = p.render("handler", ginHandlerTemplate)
+		files["internal/bootstrap/http.go"] = p.render("bootstraphttp", bootstrapGinTemplate)
+	case "fiber":
+		files["internal/delivery/http/handler.go"] = p.render("handler", fiberHandlerTemplate)
+		files["internal/bootstrap/http.go"] = p.render("bootstraphttp", bootstrapFiberTemplate)
+	case "servemux":
+		files["internal/delivery/http/handler.go"] = p.render("handler", serveMuxHandlerTemplate)
+		files["internal/bootstrap/http.go"] = p.render("bootstraphttp", bootstrapServeMuxTemplate)
+	case "echo":
+		files["internal/delivery/http/handler.go"] = p.render("handler", httpHandlerTemplate)
+		files["internal/delivery/http/docs.go"] = p.render("docs", echoDocsTemplate)
+		files["internal/bootstrap/http.go"] = p.render("bootstraphttp", bootstrapHttpTemplate)
+		files["api/api.go"] = openapiEmbedTemplate
+	}
 
-	files["internal/repository/user_repository.go"] = userRepositoryTemplate
+	if p.OpenAPI != nil {
+		files["api/openapi.yaml"] = string(p.OpenAPI.Document)
+	} else if p.HasHTTP() {
+		files["api/openapi.yaml"] = p.render("openapi", openapiSpecTemplate)
+	}
 
-	files["internal/delivery/http/handler.go"] = httpHandlerTemplate
+	files["internal/bootstrap/fx.go"] = p.render("fx", bootstrapFxTemplate)
 
-	files["internal/bootstrap/fx.go"] = bootstrapFxTemplate
-	files["internal/bootstrap/logger.go"] = bootstrapLoggerTemplate
-	files["internal/bootstrap/http.go"] = bootstrapHttpTemplate
+	files["internal/health/health.go"] = p.render("health", healthModuleTemplate)
+	files["internal/health/handler.go"] = healthHandlerTemplate
+	files["internal/health/health_test.go"] = healthTestTemplate
+	files["internal/bootstrap/logger.go"] = p.render("bootstraplogger", bootstrapLoggerTemplate)
+	if p.HasHTTP() {
+		files["internal/health/routes.go"] = p.render("healthroutes", healthRoutesTemplate)
+		files["internal/bootstrap/timeout.go"] = p.render("bootstraptimeout", bootstrapTimeoutTemplate)
+	}
 
-	files[".env.example"] = exampleEnvTemplate
-	files["Makefile"] = makefileTemplate
+	files["internal/messaging/messaging.go"] = p.render("messaging", messagingTemplate)
+	files["internal/messaging/messaging_test.go"] = p.render("messagingtest", messagingTestTemplate)
+	files["internal/messaging/messagingtest/recorder.go"] = p.render("messagingrecorder", messagingRecorderTemplate)
+	if !p.HasMessaging() {
+		files["internal/messaging/memory/memory.go"] = p.render("memory", memoryEventPublisherTemplate)
+		files["internal/messaging/memory/memory_test.go"] = p.render("memorytest", memoryEventPublisherTestTemplate)
+	}
+
+	files[".env.example"] = p.render("env", exampleEnvTemplate)
+	files["Makefile"] = p.render("makefile", makefileTemplate)
 
 	return files
 }
@@ -74,63 +317,289 @@ func (p *ProjectConfig) BaseFiles() map[string]string {
 func (p *ProjectConfig) AdditionalFiles() map[string]string {
 	files := make(map[string]string)
 
-	if p.HasDependency("http") {
+	switch {
+	case p.OpenAPI != nil:
 		files["internal/delivery/http/server.go"] = httpServerTemplate
-		files["internal/delivery/http/user_handler.go"] = httpUserHandlerTemplate
+		files["internal/delivery/http/api_handler.go"] = p.render("apihandler", openapiHandlerTemplate)
+		files["internal/delivery/http/openapi/types.gen.go"] = p.render("openapitypes", openapiTypesTemplate)
+		files["internal/delivery/http/openapi/server.gen.go"] = p.render("openapiserver", openapiServerTemplate)
+	case p.HasGateway():
+		files["internal/delivery/http/gateway.go"] = p.render("gateway", gatewayTemplate)
+	case p.HasHTTP():
+		var handlerTemplate string
+		switch p.HTTPFramework() {
+		case "chi", "servemux":
+			handlerTemplate = netHTTPEntityHandlerTemplate
+		case "gin":
+			handlerTemplate = ginEntityHandlerTemplate
+		case "fiber":
+			handlerTemplate = fiberEntityHandlerTemplate
+		default:
+			handlerTemplate = echoEntityHandlerTemplate
+			files["internal/delivery/http/server.go"] = httpServerTemplate
+		}
+		for _, entity := range p.Entities {
+			files["internal/delivery/http/"+entity.Snake()+"_handler.go"] = p.renderEntity("entityhandler", handlerTemplate, entity)
+		}
+		files["internal/delivery/http/query.go"] = p.render("httpquery", httpQueryTemplate)
+		files["internal/delivery/http/errors.go"] = p.render("httperrors", httpErrorsTemplate)
 	}
 
 	if p.HasDependency("grpc") {
 
 		files["internal/config/grpc.go"] = configGRPCTemplate
 
-		files["internal/bootstrap/grpc.go"] = bootstrapGRPCTemplate
+		files["internal/bootstrap/grpc.go"] = p.render("bootstrapgrpc", bootstrapGRPCTemplate)
+		files["internal/bootstrap/grpc_health.go"] = p.render("bootstrapgrpchealth", bootstrapGRPCHealthTemplate)
+		files["internal/bootstrap/grpc_health_test.go"] = p.render("bootstrapgrpchealthtest", bootstrapGRPCHealthTestTemplate)
+
+		files["internal/delivery/grpc/interceptor/interceptor.go"] = grpcInterceptorTemplate
+		files["internal/delivery/grpc/interceptor/interceptor_test.go"] = grpcInterceptorTestTemplate
+		files["internal/delivery/grpc/errors.go"] = p.render("grpcerrors", grpcErrorsTemplate)
+
+		var (
+			stubs map[string]string
+			err   error
+		)
+		if p.Proto != nil {
+			// gRPC API описан загруженными .proto файлами вместо сервисов сущностей
+			files["internal/delivery/grpc/server.go"] = p.render("protoserver", protoServerTemplate)
+			files["internal/delivery/grpc/convert.go"] = p.render("protoconvert", protoConvertTemplate)
+
+			for _, file := range p.Proto.Files {
+				files["internal/domain/"+file.Snake+"_proto.go"] = p.renderProto("protodomain", protoDomainTemplate, protoScope{File: file})
+			}
+			for _, service := range p.Proto.Services() {
+				files["internal/usecase/"+service.Snake+"_usecase.go"] = p.renderProto("protousecase", protoUsecaseTemplate, protoScope{Service: service})
+				files["internal/delivery/grpc/"+service.Snake+".go"] = p.renderProto("protoservice", protoServiceTemplate, protoScope{Service: service})
+			}
+			for name, content := range p.Proto.Sources {
+				files["api/proto/"+name] = content
+			}
+			stubs, err = compileProtoPackage("internal/delivery/grpc", p.Proto.Sources, p.Name+"/internal/delivery/grpc")
+		} else {
+			files["internal/delivery/grpc/server.go"] = p.render("grpcserver", grpcServerTemplate)
+
+			protos := make(map[string]string, len(p.Entities))
+			for _, entity := range p.Entities {
+				files["internal/delivery/grpc/"+entity.Snake()+"_service.go"] = p.renderEntity("grpcservice", entityGRPCServiceTemplate, entity)
+
+				proto := p.renderEntity("proto", entityProtoTemplate, entity)
+				files["api/proto/"+entity.Snake()+".proto"] = proto
+				protos[entity.Snake()+".proto"] = proto
+			}
+			stubs, err = compileProto("internal/delivery/grpc", protos)
+		}
+		if err != nil && p.renderErr == nil {
+			p.renderErr = fmt.Errorf("compile proto: %w", err)
+		}
+		for name, content := range stubs {
+			files[name] = content
+		}
+		files["buf.yaml"] = p.render("bufyaml", bufYAMLTemplate)
+		files["buf.gen.yaml"] = p.render("bufgenyaml", bufGenYAMLTemplate)
+	}
+
+	if p.HasDependency("graphql") {
+
+		files["gqlgen.yml"] = p.render("gqlgenyaml", gqlgenYAMLTemplate)
+		files["internal/delivery/graphql/schema/schema.graphqls"] = p.render("graphqlschema", graphqlSchemaTemplate)
+		files["internal/delivery/graphql/resolver.go"] = p.render("graphqlresolver", graphqlResolverTemplate)
+		files["internal/delivery/graphql/schema.resolvers.go"] = p.render("graphqlschemaresolvers", graphqlSchemaResolversTemplate)
+		files["internal/delivery/graphql/routes.go"] = p.render("graphqlroutes", graphqlRoutesTemplate)
+		files["internal/delivery/graphql/loader/loader.go"] = p.render("graphqlloader", graphqlLoaderTemplate)
+	}
+
+	if p.HasDependency("otel") {
+
+		files["internal/config/otel.go"] = configOtelTemplate
+
+		files["internal/bootstrap/otel.go"] = p.render("bootstrapotel", bootstrapOtelTemplate)
+
+		files["internal/usecase/tracing.go"] = p.render("usecasetracing", usecaseTracingTemplate)
+		for _, entity := range p.Entities {
+			files["internal/usecase/"+entity.Snake()+"_tracing.go"] = p.renderEntity("entitytracing", entityTracingTemplate, entity)
+		}
+
+		if p.HasDependency("kafka") {
+			files["internal/messaging/kafka/tracing.go"] = p.render("kafkatracing", kafkaTracingTemplate)
+		}
+		if p.HasDependency("docker") {
+			files["deploy/otel-collector.yaml"] = otelCollectorConfigTemplate
+		}
+	}
+
+	if p.HasDependency("prometheus") {
+
+		files["internal/config/metrics.go"] = configMetricsTemplate
+
+		files["internal/metrics/metrics.go"] = p.render("metrics", metricsModuleTemplate)
+		if p.HasHTTP() {
+			files["internal/metrics/http.go"] = p.render("metricshttp", metricsHTTPTemplate)
+			files["internal/metrics/http_test.go"] = metricsHTTPTestTemplate
+		}
+
+		if p.HasDependency("grpc") {
+			files["internal/metrics/grpc.go"] = metricsGRPCTemplate
+		}
+		if p.HasDependency("postgres") {
+			files["internal/metrics/postgres.go"] = metricsPostgresTemplate
+		}
+		if p.HasDependency("redis") {
+			files["internal/metrics/redis.go"] = metricsRedisTemplate
+		}
+		if p.HasDependency("kafka") {
+			files["internal/metrics/kafka.go"] = p.render("metricskafka", metricsKafkaTemplate)
+		}
+		if p.HasDependency("docker") {
+			files["deploy/prometheus.yml"] = p.render("prometheusconfig", prometheusConfigTemplate)
+			files["deploy/grafana/provisioning/datasources/prometheus.yaml"] = grafanaDatasourceTemplate
+			files["deploy/grafana/provisioning/dashboards/dashboards.yaml"] = grafanaDashboardProviderTemplate
+			files["deploy/grafana/dashboards/service.json"] = p.render("grafanadashboard", grafanaDashboardTemplate)
+		}
+	}
+
+	if p.HasDependency("auth") {
 
-		files["internal/delivery/grpc/server.go"] = grpcServerTemplate
-		files["internal/delivery/grpc/user_service.go"] = grpcUserServiceTemplate
-		files["api/proto/user.proto"] = userProtoTemplate
+		files["internal/config/auth.go"] = configAuthTemplate
+
+		files["internal/domain/principal.go"] = principalDomainTemplate
+
+		files["internal/auth/auth.go"] = p.render("auth", authModuleTemplate)
+		files["internal/auth/jwks.go"] = authJWKSTemplate
+		files["internal/auth/echo.go"] = p.render("authecho", authEchoTemplate)
+		if p.HasDependency("grpc") {
+			files["internal/auth/grpc.go"] = p.render("authgrpc", authGRPCTemplate)
+		}
+		files["internal/auth/auth_test.go"] = p.render("authtest", authTestTemplate)
+		files["internal/auth/authtest/authtest.go"] = authTestServerTemplate
+		files["cmd/authdev/main.go"] = p.render("authdev", authDevCommandTemplate)
+	}
+
+	if p.HasDependency("rbac") {
+
+		files["internal/config/rbac.go"] = configRBACTemplate
+
+		files["internal/domain/authorizer.go"] = p.render("authorizer", authorizerDomainTemplate)
+
+		files["internal/rbac/policy.yaml"] = p.render("rbacpolicy", rbacPolicyTemplate)
+		files["internal/rbac/rbac.go"] = p.render("rbac", rbacModuleTemplate)
+		files["internal/rbac/policy.go"] = p.render("rbacpolicygo", rbacPolicyGoTemplate)
+		files["internal/rbac/policy_test.go"] = p.render("rbacpolicytest", rbacPolicyTestTemplate)
+	}
+
+	if p.HasDependency("realtime") {
+
+		files["internal/config/realtime.go"] = configRealtimeTemplate
+
+		files["internal/realtime/realtime.go"] = p.render("realtime", realtimeModuleTemplate)
+		files["internal/realtime/hub.go"] = p.render("realtimehub", realtimeHubTemplate)
+		files["internal/realtime/hub_test.go"] = p.renderEntity("realtimehubtest", realtimeHubTestTemplate, p.Entities[0])
+		files["internal/realtime/handler.go"] = p.render("realtimehandler", realtimeHandlerTemplate)
+		if p.HasDependency("auth") {
+			files["internal/realtime/handler_test.go"] = p.renderEntity("realtimehandlertest", realtimeHandlerTestTemplate, p.Entities[0])
+		}
 	}
 
 	if p.HasDependency("postgres") {
 
 		files["internal/config/postgres.go"] = configPostgresTemplate
 
-		files["internal/bootstrap/postgres.go"] = bootstrapPostgresTemplate
+		files["internal/bootstrap/postgres.go"] = p.render("bootstrappostgres", bootstrapPostgresTemplate)
 
-		files["internal/repository/postgres/postgres.go"] = postgresTemplate
-		files["internal/repository/postgres/user_repository.go"] = postgresUserRepositoryTemplate
+		files["internal/repository/postgres/helpers.go"] = p.render("postgreshelpers", postgresHelpersTemplate)
+		for _, entity := range p.Entities {
+			files["internal/repository/postgres/"+entity.Snake()+"_repository.go"] = p.renderEntity("postgresrepository", postgresEntityRepositoryTemplate, entity)
+		}
+	}
+
+	// Таблицы создаются в порядке зависимостей между сущностями, outbox - последним
+	migration := 0
+	if p.HasDependency("postgres") {
+		for _, entity := range p.Entities.Migrations() {
+			migration++
+			name := "create_" + entity.Table() + "_table"
+			files[migrationName(migration, name, "up")] = p.renderEntity("migrationup", entityMigrationUpTemplate, entity)
+			files[migrationName(migration, name, "down")] = p.renderEntity("migrationdown", entityMigrationDownTemplate, entity)
+		}
 	}
 
 	if p.HasDependency("redis") {
 
 		files["internal/config/redis.go"] = configRedisTemplate
 
-		files["internal/bootstrap/redis.go"] = bootstrapRedisTemplate
+		files["internal/bootstrap/redis.go"] = p.render("bootstrapredis", bootstrapRedisTemplate)
 
-		files["internal/repository/redis/redis.go"] = redisTemplate
-		files["internal/repository/redis/user_cache.go"] = redisUserCacheTemplate
+		for _, entity := range p.Entities {
+			files["internal/repository/redis/"+entity.Snake()+"_cache.go"] = p.renderEntity("rediscache", redisEntityCacheTemplate, entity)
+			if p.HasRepositoryCache() {
+				files["internal/repository/redis/"+entity.Snake()+"_repository.go"] = p.renderEntity("redisrepository", redisEntityRepositoryTemplate, entity)
+			}
+		}
+		if p.HasRepositoryCache() {
+			files["internal/repository/redis/"+p.Entities[0].Snake()+"_repository_test.go"] = p.renderEntity("redisrepositorytest", redisEntityRepositoryTestTemplate, p.Entities[0])
+		}
 	}
 
 	if p.HasDependency("kafka") {
 
 		files["internal/config/kafka.go"] = configKafkaTemplate
 
-		files["internal/bootstrap/kafka.go"] = bootstrapKafkaTemplate
+		files["internal/bootstrap/kafka.go"] = p.render("bootstrapkafka", bootstrapKafkaTemplate)
 
-		files["internal/messaging/kafka/kafka.go"] = kafkaTemplate
-		files["internal/messaging/kafka/user_events.go"] = kafkaUserEventsTemplate
+		files["internal/messaging/kafka/kafka.go"] = p.render("kafka", kafkaTemplate)
+		files["internal/messaging/kafka/events.go"] = p.render("kafkaevents", kafkaEventsTemplate)
+	}
+
+	if p.HasOutbox() {
+
+		files["internal/config/outbox.go"] = configOutboxTemplate
+
+		migration++
+		files[migrationName(migration, "create_outbox_table", "up")] = outboxMigrationUpTemplate
+		files[migrationName(migration, "create_outbox_table", "down")] = outboxMigrationDownTemplate
+
+		files["internal/repository/postgres/outbox.go"] = p.render("postgresoutbox", postgresOutboxTemplate)
+
+		files["internal/outbox/relay.go"] = p.render("outboxrelay", outboxRelayTemplate)
+		files["internal/outbox/postgres.go"] = p.render("outboxpostgres", outboxPostgresStoreTemplate)
+		files["internal/outbox/kafka.go"] = p.render("outboxkafka", outboxKafkaPublisherTemplate)
+		files["internal/outbox/relay_test.go"] = p.render("outboxrelaytest", outboxRelayTestTemplate)
+	}
+
+	if p.HasDependency("nats") {
+
+		files["internal/config/nats.go"] = configNATSTemplate
+
+		files["internal/bootstrap/nats.go"] = p.render("bootstrapnats", bootstrapNATSTemplate)
+
+		files["internal/messaging/nats/nats.go"] = p.render("nats", natsTemplate)
+		files["internal/messaging/nats/events.go"] = p.render("natsevents", natsEventsTemplate)
+	}
+
+	if p.HasDependency("rabbitmq") {
+
+		files["internal/config/rabbitmq.go"] = configRabbitMQTemplate
+
+		files["internal/bootstrap/rabbitmq.go"] = p.render("bootstraprabbitmq", bootstrapRabbitMQTemplate)
+
+		files["internal/messaging/rabbitmq/rabbitmq.go"] = p.render("rabbitmq", rabbitmqTemplate)
+		files["internal/messaging/rabbitmq/events.go"] = p.render("rabbitmqevents", rabbitmqEventsTemplate)
 	}
 
 	if p.HasDependency("docker") {
-		var dockerComposeContent strings.Builder
-		dockerComposeTmpl := template.Must(template.New("dockercompose").Parse(dockerComposeTemplate))
-		dockerComposeTmpl.Execute(&dockerComposeContent, p)
-		files["docker-compose.yml"] = dockerComposeContent.String()
+		files["docker-compose.yml"] = p.render("dockercompose", dockerComposeTemplate)
 	}
 
 	return files
 }
 
-func (p *ProjectConfig) GenerateProject() map[string]string {
+// GenerateProject рендерит все файлы проекта. Ошибка выполнения любого
+// шаблона возвращается вместо частично сгенерированного проекта
+func (p *ProjectConfig) GenerateProject() (map[string]string, error) {
+	p.prepareEntities()
+	p.renderErr = nil
+
 	files := p.BaseFiles()
 
 	additionalFiles := p.AdditionalFiles()
@@ -138,5 +607,8 @@ func (p *ProjectConfig) GenerateProject() map[string]string {
 		files[path] = content
 	}
 
-	return files
+	if p.renderErr != nil {
+		return nil, p.renderErr
+	}
+	return files, nil
 }
@@ -0,0 +1,106 @@
+package project_templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderError(t *testing.T) {
+	p := &ProjectConfig{Name: "example.com/demo"}
+
+	p.render("broken", "package demo\n{{.Entities.Missing}}\n")
+
+	if p.renderErr == nil {
+		t.Fatal("expected the template error to be recorded")
+	}
+}
+
+func TestGenerateProject(t *testing.T) {
+	p := &ProjectConfig{Name: "example.com/demo", Dependencies: []string{"http"}}
+
+	files, err := p.GenerateProject()
+	if err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+	if files["main.go"] == "" {
+		t.Fatal("expected main.go to be generated")
+	}
+}
+
+func TestGenerateProjectWithoutHTTP(t *testing.T) {
+	// Радио "No HTTP API" отправляет пустую зависимость
+	p := &ProjectConfig{Name: "example.com/demo", Dependencies: []string{"", "grpc", "docker"}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	files, err := p.GenerateProject()
+	if err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+
+	for path := range files {
+		if strings.HasPrefix(path, "internal/delivery/http/") || path == "internal/bootstrap/http.go" {
+			t.Errorf("unexpected HTTP file %s", path)
+		}
+	}
+	for _, path := range []string{"go.mod", "internal/app/app.go", "internal/bootstrap/fx.go", "Dockerfile"} {
+		if strings.Contains(files[path], "echo") || strings.Contains(files[path], "NewHTTPServer") || strings.Contains(files[path], "EXPOSE 8080") {
+			t.Errorf("%s still refers to the HTTP server", path)
+		}
+	}
+	if files["internal/delivery/grpc/server.go"] == "" {
+		t.Error("expected the gRPC server to be generated")
+	}
+}
+
+func TestValidateRequiresAnAPI(t *testing.T) {
+	tests := []struct {
+		name         string
+		dependencies []string
+		valid        bool
+	}{
+		{"echo", []string{"http"}, true},
+		{"grpc only", []string{"grpc"}, true},
+		{"no api", []string{"postgres"}, false},
+		{"graphql without http", []string{"grpc", "graphql"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ProjectConfig{Name: "example.com/demo", Dependencies: tt.dependencies}
+			err := p.Validate()
+			if tt.valid && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestValidateOutboxDependencies(t *testing.T) {
+	tests := []struct {
+		name         string
+		dependencies []string
+		valid        bool
+	}{
+		{"postgres and kafka", []string{"http", "postgres", "kafka", "outbox"}, true},
+		{"without kafka", []string{"http", "postgres", "outbox"}, false},
+		{"without postgres", []string{"http", "kafka", "outbox"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ProjectConfig{Name: "example.com/demo", Dependencies: tt.dependencies}
+			err := p.Validate()
+			if tt.valid && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -12,17 +12,121 @@ const readmeTemplate = `# {{.GetProjectName}}
 {{- if .HasDependency "postgres"}}
 - PostgreSQL для хранения данных
 {{- end}}
-{{- if .HasDependency "redis"}}
+{{- if .HasRepositoryCache}}
+- Redis для кеширования: чтение сущностей по id идет через кеш поверх PostgreSQL
+{{- else if .HasDependency "redis"}}
 - Redis для кеширования
 {{- end}}
 {{- if .HasDependency "kafka"}}
 - Kafka для обмена сообщениями
 {{- end}}
-{{- if .HasDependency "http"}}
+{{- if .HasOutbox}}
+- Transactional outbox: события пишутся в PostgreSQL в одной транзакции с данными и пересылаются в Kafka, опубликованные записи удаляются через OUTBOX_RETENTION_MS
+{{- end}}
+{{- if .HasDependency "nats"}}
+- NATS JetStream для обмена сообщениями
+{{- end}}
+{{- if .HasDependency "rabbitmq"}}
+- RabbitMQ для обмена сообщениями
+{{- end}}
+{{- if not .HasMessaging}}
+- События без брокера: шина в памяти процесса доставляет их подписчикам из fx группы event_subscribers
+{{- end}}
+{{- if .HasHTTP}}
+{{- if eq .HTTPFramework "echo"}}
 - HTTP API (Echo framework)
+{{- else if eq .HTTPFramework "chi"}}
+- HTTP API (chi router)
+{{- else if eq .HTTPFramework "gin"}}
+- HTTP API (Gin framework)
+{{- else if eq .HTTPFramework "fiber"}}
+- HTTP API (Fiber framework)
+{{- else}}
+- HTTP API (net/http ServeMux)
+{{- end}}
+{{- end}}
+{{- if .HasGateway}}
+- REST API через grpc-gateway: HTTP маршруты описаны google.api.http правилами в api/proto
+{{- end}}
+{{- if .Proto}}
+- gRPC API из загруженных .proto файлов: доменные типы и use case интерфейсы в internal/domain, заглушки use case в internal/usecase возвращают domain.ErrNotImplemented (codes.Unimplemented), пока их не реализуют
+{{- end}}
+{{- if .HasDependency "grpc"}}
+- gRPC API: Go код для api/proto/*.proto уже сгенерирован, после изменения .proto выполните ` + "`make proto`" + ` (нужен [buf](https://buf.build/docs/installation))
+- gRPC health (grpc.health.v1) по доступности зависимостей, reflection (GRPC_REFLECTION) и interceptors: логирование, recovery, request ID, дедлайны
+{{- end}}
+{{- if .HasDependency "otel"}}
+- OpenTelemetry: трейсы и метрики HTTP, gRPC, PostgreSQL, Redis, Kafka и use case слоя (OTLP или stdout)
+{{- end}}
+{{- if .HasDependency "prometheus"}}
+- Prometheus: /metrics на отдельном admin порту, гистограммы длительности запросов, метрики пулов и Go runtime
+{{- end}}
+{{- if .HasDependency "realtime"}}
+- Realtime: события сущностей через WebSocket (GET /ws/...) и SSE (GET /events/...)
+{{- end}}
+{{- if .HasDependency "auth"}}
+- JWT аутентификация: HS256 с общим секретом и RS256 с ключами из JWKS, principal запроса в context
+{{- end}}
+{{- if .HasDependency "rbac"}}
+- RBAC: роли из токена проверяются по политике internal/rbac/policy.yaml в каждом методе use case
+{{- end}}
+{{- if .HasDependency "graphql"}}
+- GraphQL API (gqlgen) с dataloader для загрузки сущностей без N+1 запросов
+{{- end}}
+
+## Сущности
+
+Для каждой сущности сгенерирован CRUD стек: доменная структура и интерфейсы в internal/domain,
+use case, репозиторий{{if .HasDependency "postgres"}}, миграция в migrations{{end}}{{if .HasHTTP}}, REST API{{end}}{{if and (.HasDependency "grpc") (not .Proto)}}, gRPC сервис{{end}}{{if .HasDependency "graphql"}}, GraphQL API{{end}}
+и события created/updated/deleted.
+{{range .Entities}}
+- {{.GoName}}{{if $.HasHTTP}} - /api/{{.Route}}{{end}}: id{{range .Fields}}, {{.JSONName}} ({{.Type}}{{if .Values}}: {{range $i, $v := .Values}}{{if $i}}|{{end}}{{$v}}{{end}}{{end}}{{if .Ref}} -> {{.Ref}}{{end}}{{if .Required}}, required{{end}}{{if .Unique}}, unique{{end}}{{if .Indexed}}, indexed{{end}}){{end}}{{if .HasTimestamps}}, created_at, updated_at{{end}}
+{{- end}}
+
+### Списки
+
+List возвращает страницу {"items": [...], "next_page_token": "..."}, по умолчанию 20 элементов, не больше 100.
+{{- if and .HasHTTP (not .OpenAPI)}}
+{{- with index .Entities 0}}
+
+` + "```bash" + `
+curl 'localhost:8080/api/{{.Route}}?limit=10&sort=-{{(index .SortFields 0).Name}}'
+curl 'localhost:8080/api/{{.Route}}?limit=10&sort=-{{(index .SortFields 0).Name}}&page_token=<next_page_token>'
+` + "```" + `
+{{- end}}
+{{- end}}
+
+- limit и offset - размер страницы и смещение, page_token - продолжение после последнего элемента предыдущей страницы (keyset pagination, не пропускает и не повторяет элементы при вставках)
+- sort - поле сортировки, "-" в начале сортирует по убыванию; при равных значениях порядок задает id
+- остальные параметры - фильтры по равенству полей, например ?email=...
+- неизвестное поле сортировки, некорректный фильтр или токен другой сортировки дают 400{{if and (.HasDependency "grpc") (not .Proto)}} (InvalidArgument в gRPC){{end}}
+
+### Валидация и ошибки
+
+Правила полей заданы тегами validate в internal/domain (go-playground/validator), use case проверяет их в Create и Update
+и возвращает domain.ValidationError со списком полей. Уникальные поля проверяются через List до записи, занятое
+значение дает domain.ConflictError.
+
+Виды ошибок объявлены в internal/domain/errors.go: ErrNotFound, ErrConflict, ErrInvalidArgument, ErrUnauthorized, ErrForbidden.
+Use case и репозитории возвращают их через domain.Errorf{{if .HasDependency "postgres"}}, репозитории PostgreSQL переводят в них
+нарушения ограничений (unique - ErrConflict, внешний ключ и CHECK - ErrInvalidArgument){{end}}. Остальные ошибки
+считаются внутренними: клиент получает 500 без подробностей, причина пишется в лог.
+{{- if and .HasHTTP (not .OpenAPI) (not .HasGateway)}}
+
+HTTP отвечает ошибками в формате RFC 7807 (application/problem+json):
+
+` + "```json" + `
+{"type": "about:blank", "title": "Bad Request", "status": 400, "detail": "validation failed: email must be a valid email address",
+ "instance": "/api/users", "fields": [{"field": "email", "message": "must be a valid email address"}]}
+` + "```" + `
+
+ErrInvalidArgument - 400, ErrUnauthorized - 401, ErrForbidden - 403, ErrNotFound - 404, ErrConflict - 409.
 {{- end}}
 {{- if .HasDependency "grpc"}}
-- gRPC API
+В gRPC это InvalidArgument (с google.rpc.BadRequest в details для ошибок валидации), Unauthenticated, PermissionDenied, NotFound и AlreadyExists.
+{{- end}}
+{{- if .HasDependency "graphql"}}
+В GraphQL вид ошибки приходит в extensions.code (BAD_USER_INPUT, UNAUTHENTICATED, FORBIDDEN, NOT_FOUND, CONFLICT), поля - в extensions.fields.
 {{- end}}
 
 ## Запуск
@@ -38,10 +142,209 @@ go run main.go
 ### С использованием Docker
 
 ` + "```bash" + `
+{{- if .HasDependency "auth"}}
+export JWT_HS256_SECRET=$(openssl rand -hex 32)
+{{- end}}
 docker-compose up -d
 ` + "```" + `
 {{- end}}
 
+
+## Health checks
+{{- if .HasHTTP}}
+
+- GET /healthz - liveness: процесс жив и обслуживает HTTP, зависимости не проверяются
+- GET /readyz - readiness: 503, пока недоступна хотя бы одна зависимость, в ответе статус каждой проверки
+{{- end}}
+
+Проверки регистрируют bootstrap провайдеры через fx value group "health_checkers" (internal/health.Checker),
+все проверки выполняются параллельно с таймаутом HEALTH_CHECK_TIMEOUT_MS.
+{{- if .HasDependency "grpc"}}
+Статус grpc.health.v1 вычисляется по тем же проверкам.
+{{- end}}
+{{- if and (.HasDependency "docker") .HasHTTP}}
+HEALTHCHECK в Dockerfile использует /healthz, docker-compose ждет готовности зависимостей и проверяет приложение через /readyz.
+{{- end}}
+{{- if .HasDependency "auth"}}
+
+## Аутентификация
+
+{{if .OpenAPI}}Операции спецификации{{else}}Маршруты /api{{end}}{{if .HasDependency "graphql"}}{{if .HasDependency "grpc"}}, /graphql{{else}} и /graphql{{end}}{{end}}{{if .HasDependency "grpc"}} и все gRPC методы, кроме health и reflection,{{end}} требуют заголовок
+Authorization: Bearer <token>. Без токена или с невалидным токеном HTTP отвечает 401{{if .HasDependency "grpc"}}, gRPC - Unauthenticated{{end}}.
+/healthz, /readyz{{if not .OpenAPI}} и документация{{end}} доступны без токена.
+{{- if .HasDependency "realtime"}}
+/ws и /events тоже требуют токен. Браузерные WebSocket и EventSource не передают заголовок Authorization,
+поэтому для них токен можно передать в query параметре access_token: /events/...?access_token=<token>.
+{{- if .HasDependency "rbac"}} Подписка требует права read на сущность.{{end}}
+{{- end}}
+
+- HS256 - токены, подписанные JWT_HS256_SECRET
+- RS256 - ключи загружаются с JWT_JWKS_URL и кешируются на JWT_JWKS_CACHE_TTL_MS; токен с неизвестным kid
+  вызывает повторную загрузку (ротация ключей), но не чаще раза в 30 секунд. Загрузка идет в фоне,
+  запросы тем временем проверяются закешированными ключами
+- JWT_ISSUER и JWT_AUDIENCE, если заданы, сверяются с iss и aud; exp обязателен
+
+Без JWT_HS256_SECRET и JWT_JWKS_URL, а также с секретом change-me из примера ниже приложение не запускается. Middleware кладет domain.Principal (sub и claim roles)
+в context запроса, use case получают его через domain.PrincipalFromContext.
+
+Для тестов и разработки internal/auth/authtest заменяет identity provider: выпускает RS256 токены и отдает JWKS.
+cmd/authdev запускает его локально:
+
+` + "```bash" + `
+make auth-dev
+JWT_JWKS_URL=http://localhost:8090/.well-known/jwks.json go run main.go
+TOKEN=$(curl -s 'localhost:8090/token?sub=alice&role=admin')
+{{- if and .HasHTTP (not .OpenAPI)}}
+curl -H "Authorization: Bearer $TOKEN" localhost:8080/api/{{(index .Entities 0).Route}}
+{{- end}}
+
+# или HS256 токен, подписанный JWT_HS256_SECRET
+JWT_HS256_SECRET=change-me go run ./cmd/authdev -hs256 -sub alice -roles admin
+` + "```" + `
+{{- end}}
+{{- if .HasDependency "rbac"}}
+
+## RBAC
+
+Use case каждой сущности перед работой вызывает domain.Authorizer: действие (create, read, list, update, delete)
+над ресурсом ({{range $i, $e := .Entities}}{{if $i}}, {{end}}{{.Route}}{{end}}) разрешено, если его разрешает хотя бы одна роль из claim roles токена.
+Политика описана в internal/rbac/policy.yaml и встроена в бинарник, RBAC_POLICY_FILE подменяет ее файлом:
+
+` + "```yaml" + `
+roles:
+  admin:
+    "*": ["*"]
+  viewer:
+{{- range .Entities}}
+    {{.Route}}: [read, list]
+{{- end}}
+` + "```" + `
+
+Неизвестные ресурсы и действия в политике останавливают запуск. Запрет - domain.ErrForbidden: 403{{if .HasDependency "grpc"}}, PermissionDenied в gRPC{{end}}.
+Таблица разрешенных и запрещенных комбинаций для ролей по умолчанию проверяется в internal/rbac/policy_test.go.
+{{- end}}
+{{- if .HasRepositoryCache}}
+
+## Кеширование
+
+Postgres репозитории обернуты декораторами из internal/repository/redis (fx.Decorate в internal/repository/repository.go),
+use case об этом не знают:
+
+- GetByID сначала читает Redis (ключ <сущность>:<id>), при промахе читает PostgreSQL и кладет результат в кеш на REDIS_CACHE_TTL_MS (по умолчанию час)
+- одновременные промахи по одному id выполняют одну загрузку (singleflight); она не отменяется вместе с запросом, который ее начал, и ограничена REDIS_CACHE_LOAD_TIMEOUT_MS
+- загрузка, во время которой сущность изменили, не кладет прочитанное в кеш
+- Update и Delete удаляют запись из кеша после успешной записи в PostgreSQL
+- List и Create идут напрямую в PostgreSQL
+- ошибки Redis пишутся в лог и не ломают запрос: данные читаются из PostgreSQL
+{{- end}}
+{{- if .HasDependency "otel"}}
+
+## OpenTelemetry
+
+Провайдеры трейсов и метрик настраиваются в internal/bootstrap/otel.go и регистрируются глобально,
+поэтому инструментированные клиенты подхватывают их автоматически. Контекст запроса передается
+через use case интерфейсы domain, каждый вызов use case оборачивается в span (internal/usecase/tracing.go).
+
+- OTEL_EXPORTER=otlp - отправка по OTLP/gRPC на OTEL_EXPORTER_OTLP_ENDPOINT
+- OTEL_EXPORTER=stdout - вывод в консоль для локальной разработки
+- семплирование задается стандартными OTEL_TRACES_SAMPLER и OTEL_TRACES_SAMPLER_ARG
+{{- if .HasDependency "kafka"}}
+- trace context передается в заголовках Kafka сообщений, consumer продолжает trace продюсера
+{{- end}}
+{{- if .HasDependency "docker"}}
+
+docker-compose поднимает otel-collector (конфигурация в deploy/otel-collector.yaml) и Jaeger,
+трейсы доступны в Jaeger UI: http://localhost:16686
+{{- end}}
+{{- end}}
+{{- if .HasDependency "prometheus"}}
+
+## Prometheus
+
+Метрики отдаются на admin порту (METRICS_PORT, по умолчанию 8081): http://localhost:8081/metrics.
+Публичный API и метрики разделены, admin порт не нужно открывать наружу.
+
+{{if .HasHTTP -}}
+- http_server_request_duration_seconds{method,route,status} - RED метрики HTTP, route это шаблон маршрута, а не путь
+{{- end}}
+{{- if .HasDependency "grpc"}}
+- grpc_server_handling_seconds{grpc_service,grpc_method,grpc_type,grpc_code} - длительность gRPC вызовов
+{{- end}}
+{{- if .HasDependency "postgres"}}
+- pgxpool_* - состояние пула соединений PostgreSQL
+{{- end}}
+{{- if .HasDependency "redis"}}
+- redis_pool_* - состояние пула соединений Redis
+{{- end}}
+{{- if .HasDependency "kafka"}}
+- kafka_consumer_lag{topic,group} - отставание consumer событий
+{{- end}}
+- go_* и process_* - Go runtime и процесс
+{{- if .HasDependency "docker"}}
+
+docker-compose поднимает Prometheus (http://localhost:9091, конфигурация в deploy/prometheus.yml)
+и Grafana (http://localhost:3000) со стартовым дашбордом из deploy/grafana/dashboards.
+{{- end}}
+{{- end}}
+{{- if .HasDependency "realtime"}}
+
+## Realtime
+
+internal/realtime содержит hub, который рассылает события сущностей подписанным на них клиентам:
+{{range .Entities}}
+- GET /ws/{{.Route}} - WebSocket, каждое событие {{.Label}} приходит текстовым JSON сообщением
+- GET /events/{{.Route}} - Server-Sent Events, событие в поле data
+{{- end}}
+
+{{- if .HasDependency "kafka"}}
+
+Hub получает события из Kafka consumer, поэтому клиенты видят изменения, сделанные любым экземпляром сервиса.
+Каждый экземпляр должен читать все сообщения топика: задайте уникальный KAFKA_GROUP_ID для каждой реплики.
+{{- else}}
+
+Hub получает события напрямую от use case через domain.EventPublisher.
+{{- end}}
+
+У каждого соединения своя горутина и ограниченный буфер (REALTIME_SEND_BUFFER): медленный клиент отключается,
+а не задерживает остальных. Для WebSocket с другого домена перечислите разрешенные Origin в REALTIME_ALLOWED_ORIGINS.
+
+` + "```js" + `
+new EventSource("/events/{{(index .Entities 0).Route}}").onmessage = (e) => console.log(JSON.parse(e.data))
+` + "```" + `
+{{- end}}
+{{- if .HasDependency "graphql"}}
+
+## GraphQL
+
+Схема описана в internal/delivery/graphql/schema/schema.graphqls, конфигурация gqlgen в gqlgen.yml.
+Исполняемая схема (internal/delivery/graphql/generated) и модели (internal/delivery/graphql/model)
+не хранятся в шаблоне: сгенерируйте их перед первой сборкой и после каждого изменения схемы
+
+` + "```bash" + `
+make graphql
+` + "```" + `
+
+Резолверы в internal/delivery/graphql/schema.resolvers.go вызывают use case сущностей,
+gqlgen сохраняет их реализацию при повторной генерации.
+
+- POST /graphql - GraphQL endpoint
+- GET /playground - GraphQL playground
+
+` + "```graphql" + `
+{{- with index .Entities 0}}
+query {
+  {{.GraphQLPluralName}}(limit: 10) {
+    items { id{{range .Fields}} {{.GraphQLName}}{{end}} }
+    nextPageToken
+  }
+}
+{{- end}}
+` + "```" + `
+
+Запросы одной сущности по id проходят через dataloader (internal/delivery/graphql/loader): в рамках одного
+HTTP запроса одинаковые id загружаются один раз, а разные собираются в один batch.
+{{- end}}
+
 ## Структура проекта
 
 Проект следует принципам чистой архитектуры:
@@ -59,9 +362,19 @@ go 1.24
 require (
 	go.uber.org/fx v1.20.1
 	go.uber.org/zap v1.27.0
-{{- if .HasDependency "http"}}
+	github.com/go-playground/validator/v10 v10.22.1
+{{- if eq .HTTPFramework "echo"}}
 	github.com/labstack/echo/v4 v4.13.3
 {{- end}}
+{{- if .HasDependency "chi"}}
+	github.com/go-chi/chi/v5 v5.1.0
+{{- end}}
+{{- if .HasDependency "gin"}}
+	github.com/gin-gonic/gin v1.10.0
+{{- end}}
+{{- if .HasDependency "fiber"}}
+	github.com/gofiber/fiber/v2 v2.52.5
+{{- end}}
 {{- if .HasDependency "postgres"}}
 	github.com/doug-martin/goqu/v9 v9.19.0
 	github.com/jackc/pgx/v5 v5.5.5
@@ -72,22 +385,91 @@ require (
 {{- if .HasDependency "kafka"}}
 	github.com/segmentio/kafka-go v0.4.47
 {{- end}}
+{{- if .HasDependency "nats"}}
+	github.com/nats-io/nats.go v1.37.0
+{{- end}}
+{{- if .HasDependency "rabbitmq"}}
+	github.com/rabbitmq/amqp091-go v1.10.0
+{{- end}}
 {{- if .HasDependency "grpc"}}
 	google.golang.org/grpc v1.62.1
-	google.golang.org/protobuf v1.33.0
+	google.golang.org/protobuf v1.36.5
+{{- end}}
+{{- if .HasGateway}}
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.19.1
+{{- end}}
+{{- if .HasGoogleAPIs}}
+	google.golang.org/genproto/googleapis/api v0.0.0-20240125205218-1f4bbc51befe
+{{- end}}
+{{- if .HasDependency "realtime"}}
+	github.com/gorilla/websocket v1.5.3
+{{- end}}
+{{- if .HasDependency "auth"}}
+	github.com/golang-jwt/jwt/v5 v5.2.1
+{{- end}}
+{{- if .HasDependency "rbac"}}
+	gopkg.in/yaml.v3 v3.0.1
+{{- end}}
+{{- if .HasRepositoryCache}}
+	golang.org/x/sync v0.10.0
+{{- end}}
+{{- if .HasDependency "otel"}}
+	go.opentelemetry.io/otel v1.39.0
+	go.opentelemetry.io/otel/sdk v1.39.0
+	go.opentelemetry.io/otel/sdk/metric v1.39.0
+	go.opentelemetry.io/otel/trace v1.39.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.39.0
+	go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc v1.39.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.39.0
+	go.opentelemetry.io/otel/exporters/stdout/stdoutmetric v1.39.0
+	go.opentelemetry.io/contrib/instrumentation/runtime v0.64.0
+{{- if eq .HTTPFramework "echo"}}
+	go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho v0.63.0
+{{- end}}
+{{- if or (.HasDependency "chi") (.HasDependency "servemux")}}
+	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.64.0
+{{- end}}
+{{- if .HasDependency "gin"}}
+	go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin v0.64.0
+{{- end}}
+{{- if .HasDependency "fiber"}}
+	github.com/gofiber/contrib/otelfiber v1.0.10
+{{- end}}
+{{- if .HasDependency "grpc"}}
+	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.63.0
+{{- end}}
+{{- if .HasDependency "postgres"}}
+	github.com/exaring/otelpgx v0.9.3
+{{- end}}
+{{- if .HasDependency "redis"}}
+	github.com/redis/go-redis/extra/redisotel/v9 v9.5.3
+{{- end}}
+{{- end}}
+{{- if .HasDependency "prometheus"}}
+	github.com/prometheus/client_golang v1.23.2
+{{- end}}
+{{- if .HasDependency "graphql"}}
+	github.com/99designs/gqlgen v0.17.73
+	github.com/vektah/gqlparser/v2 v2.5.27
+	github.com/vikstrous/dataloadgen v0.0.10
 {{- end}}
 )
+{{- if .HasDependency "graphql"}}
+
+tool github.com/99designs/gqlgen
+{{- end}}
 `
 
 const mainTemplate = `package main
 
 import (
+	"{{.Name}}/internal/app"
 	"{{.Name}}/internal/bootstrap"
 )
 
 func main() {
 	
-	bootstrap.BuildApp().Run()
+	bootstrap.BuildApp(app.Module).Run()
 }
 `
 
@@ -130,6 +512,9 @@ COPY go.mod go.sum ./
 RUN go mod download
 
 COPY . .
+{{- if .HasDependency "graphql"}}
+RUN go tool gqlgen generate
+{{- end}}
 RUN CGO_ENABLED=0 GOOS=linux go build -o app .
 
 FROM alpine:latest
@@ -143,10 +528,21 @@ COPY --from=builder /app/app .
 COPY --from=builder /app/api ./api
 {{- end}}
 
+{{- if .HasHTTP}}
 EXPOSE 8080
+{{- end}}
 {{- if .HasDependency "grpc"}}
 EXPOSE 9090
 {{- end}}
+{{- if .HasDependency "prometheus"}}
+EXPOSE 8081
+{{- end}}
+{{- if .HasHTTP}}
+
+# Liveness only: dependency outages are reported by /readyz and should not restart the container
+HEALTHCHECK --interval=10s --timeout=3s --start-period=10s --retries=3 \
+  CMD wget -qO- "http://127.0.0.1:${SERVER_PORT:-8080}/healthz" >/dev/null || exit 1
+{{- end}}
 
 CMD ["./app"]
 `
@@ -13,19 +13,52 @@ import (
 var Module = fx.Options(
 	fx.Provide(
 		NewLogger,
+{{- if .HasHTTP}}
 		NewHTTPServer,
+{{- end}}
 		config.GetConfig,
+{{- if .HasDependency "postgres"}}
+		NewPostgresConnection,
+		NewGoquDatabase,
+{{- end}}
+{{- if .HasDependency "kafka"}}
+		NewKafkaWriter,
+		NewKafkaReader,
+{{- end}}
+{{- if .HasDependency "nats"}}
+		NewNATSConnection,
+		NewJetStream,
+{{- end}}
+{{- if .HasDependency "rabbitmq"}}
+		NewRabbitMQConnection,
+{{- end}}
+{{- if .HasDependency "redis"}}
+		NewRedisClient,
+{{- end}}
+{{- if .HasDependency "grpc"}}
+		NewGRPCServer,
+		NewGRPCHealthServer,
+{{- end}}
+{{- if .HasDependency "otel"}}
+		NewTelemetryResource,
+		NewTracerProvider,
+		NewMeterProvider,
+{{- end}}
 	),
+{{- if .HasDependency "otel"}}
+	// Runs before the other invokes, so every instrumented component sees the providers
+	fx.Invoke(RegisterTelemetry),
+{{- end}}
+{{- if .HasDependency "grpc"}}
+	fx.Invoke(WatchGRPCHealth),
+{{- end}}
 )
 
-func BuildApp() *fx.App {
+// BuildApp builds the application from modules passed by main.
+// app.Module already includes Module, so it is not added here
+func BuildApp(modules ...fx.Option) *fx.App {
 	return fx.New(
-		// Provide core dependencies
-		Module,
-
-		// Import application module
-		fx.Provide(config.NewConfig),
-		fx.Import("{{.Name}}/internal/app"),
+		fx.Options(modules...),
 
 		// Register lifecycle hooks
 		fx.Invoke(RegisterHooks),
@@ -72,20 +105,33 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+{{- if .HasDependency "otel"}}
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+{{- end}}
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
 	"{{.Name}}/internal/config"
+{{- if .HasDependency "prometheus"}}
+	"{{.Name}}/internal/metrics"
+{{- end}}
 )
 
-func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *echo.Echo {
+func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger{{if .HasDependency "prometheus"}}, httpMetrics *metrics.HTTPMetrics{{end}}) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
 
 	// Middleware
+{{- if .HasDependency "otel"}}
+	e.Use(otelecho.Middleware(cfg.Otel.ServiceName))
+{{- end}}
+{{- if .HasDependency "prometheus"}}
+	e.Use(httpMetrics.Middleware())
+{{- end}}
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
+	e.Use(requestTimeout(cfg.Server.RequestTimeout))
 
 	// Lifecycle hooks
 	lc.Append(fx.Hook{
@@ -119,27 +165,50 @@ import (
 
 	"github.com/doug-martin/goqu/v9"
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+{{- if .HasDependency "otel"}}
+	"github.com/exaring/otelpgx"
+{{- end}}
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
 	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/health"
 )
 
-func NewPostgresConnection(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*pgxpool.Pool, error) {
+// PostgresResult provides the pool and registers its readiness check
+type PostgresResult struct {
+	fx.Out
+
+	Pool        *pgxpool.Pool
+	HealthCheck health.Checker ` + "`group:\"health_checkers\"`" + `
+}
+
+func NewPostgresConnection(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (PostgresResult, error) {
 	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
 		cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Host,
 		cfg.Postgres.Port, cfg.Postgres.Database, cfg.Postgres.SSLMode)
 
 	poolConfig, err := pgxpool.ParseConfig(connString)
 	if err != nil {
-		return nil, err
+		return PostgresResult{}, err
 	}
 
+{{- if .HasDependency "otel"}}
+	poolConfig.ConnConfig.Tracer = otelpgx.NewTracer()
+{{- end}}
+
 	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		return nil, err
+		return PostgresResult{}, err
+	}
+{{- if .HasDependency "otel"}}
+
+	// Pool gauges: acquired, idle and total connections
+	if err := otelpgx.RecordStats(pool); err != nil {
+		return PostgresResult{}, err
 	}
+{{- end}}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -156,7 +225,10 @@ func NewPostgresConnection(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logg
 		},
 	})
 
-	return pool, nil
+	return PostgresResult{
+		Pool:        pool,
+		HealthCheck: health.Checker{Name: "postgres", Check: pool.Ping},
+	}, nil
 }
 
 func NewGoquDatabase() *goqu.Database {
@@ -170,19 +242,40 @@ import (
 	"context"
 	"fmt"
 
+{{- if .HasDependency "otel"}}
+	"github.com/redis/go-redis/extra/redisotel/v9"
+{{- end}}
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
 	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/health"
 )
 
-func NewRedisClient(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*redis.Client, error) {
+// RedisResult provides the client and registers its readiness check
+type RedisResult struct {
+	fx.Out
+
+	Client      *redis.Client
+	HealthCheck health.Checker ` + "`group:\"health_checkers\"`" + `
+}
+
+func NewRedisClient(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (RedisResult, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
 	})
+{{- if .HasDependency "otel"}}
+
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		return RedisResult{}, err
+	}
+	if err := redisotel.InstrumentMetrics(client); err != nil {
+		return RedisResult{}, err
+	}
+{{- end}}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -197,7 +290,12 @@ func NewRedisClient(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*r
 		},
 	})
 
-	return client, nil
+	return RedisResult{
+		Client: client,
+		HealthCheck: health.Checker{Name: "redis", Check: func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		}},
+	}, nil
 }
 `
 
@@ -205,15 +303,25 @@ const bootstrapKafkaTemplate = `package bootstrap
 
 import (
 	"context"
+	"errors"
 
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
 	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/health"
 )
 
-func NewKafkaWriter(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *kafka.Writer {
+// KafkaWriterResult provides the writer and registers a broker readiness check
+type KafkaWriterResult struct {
+	fx.Out
+
+	Writer      *kafka.Writer
+	HealthCheck health.Checker ` + "`group:\"health_checkers\"`" + `
+}
+
+func NewKafkaWriter(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) KafkaWriterResult {
 	writer := kafka.NewWriter(kafka.WriterConfig{
 		Brokers: cfg.Kafka.Brokers,
 		Topic:   cfg.Kafka.Topic,
@@ -232,7 +340,26 @@ func NewKafkaWriter(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *ka
 		},
 	})
 
-	return writer
+	return KafkaWriterResult{
+		Writer:      writer,
+		HealthCheck: health.Checker{Name: "kafka", Check: kafkaBrokerCheck(cfg.Kafka.Brokers)},
+	}
+}
+
+// kafkaBrokerCheck passes when at least one broker accepts a connection,
+// the writer itself only dials lazily on the first message
+func kafkaBrokerCheck(brokers []string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		err := errors.New("no Kafka brokers configured")
+		for _, broker := range brokers {
+			var conn *kafka.Conn
+			conn, err = kafka.DialContext(ctx, "tcp", broker)
+			if err == nil {
+				return conn.Close()
+			}
+		}
+		return err
+	}
 }
 
 func NewKafkaReader(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *kafka.Reader {
@@ -264,21 +391,68 @@ const bootstrapGRPCTemplate = `package bootstrap
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 
+{{- if .HasDependency "otel"}}
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+{{- end}}
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
+{{- if .HasDependency "auth"}}
+	"{{.Name}}/internal/auth"
+{{- end}}
 	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/delivery/grpc/interceptor"
+	"{{.Name}}/internal/health"
+{{- if .HasDependency "prometheus"}}
+	"{{.Name}}/internal/metrics"
+{{- end}}
 )
 
-func NewGRPCServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *grpc.Server {
-	server := grpc.NewServer()
+// GRPCServerResult provides the server and registers a check that it is serving
+type GRPCServerResult struct {
+	fx.Out
+
+	Server      *grpc.Server
+	HealthCheck health.Checker ` + "`group:\"health_checkers\"`" + `
+}
+
+func NewGRPCServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger, healthServer *grpchealth.Server{{if .HasDependency "prometheus"}}, grpcMetrics *metrics.GRPCMetrics{{end}}{{if .HasDependency "auth"}}, authenticator *auth.Authenticator{{end}}) GRPCServerResult {
+	// Services are registered by delivery/grpc before the server starts
+	opts := interceptor.ServerOptions(logger, cfg.GRPC.DefaultTimeout, cfg.GRPC.MaxTimeout)
+{{- if .HasDependency "otel"}}
+	// Spans and rpc.server.* metrics for every call
+	opts = append(opts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+{{- end}}
+{{- if .HasDependency "prometheus"}}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(grpcMetrics.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(grpcMetrics.StreamServerInterceptor()),
+	)
+{{- end}}
+{{- if .HasDependency "auth"}}
+	// Last in the chain, so rejected calls are still logged and counted
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(authenticator.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(authenticator.StreamServerInterceptor()),
+	)
+{{- end}}
+	server := grpc.NewServer(opts...)
+
+	healthpb.RegisterHealthServer(server, healthServer)
+	if cfg.GRPC.Reflection {
+		reflection.Register(server)
+	}
 
-	// Register services here
-	// Example: pb.RegisterUserServiceServer(server, userService)
+	var serving atomic.Bool
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -290,7 +464,9 @@ func NewGRPCServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *grp
 
 			logger.Info("Starting gRPC server", zap.String("addr", addr))
 
+			serving.Store(true)
 			go func() {
+				defer serving.Store(false)
 				if err := server.Serve(listener); err != nil {
 					logger.Error("Failed to start gRPC server", zap.Error(err))
 				}
@@ -300,11 +476,816 @@ func NewGRPCServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *grp
 		},
 		OnStop: func(ctx context.Context) error {
 			logger.Info("Stopping gRPC server")
+			serving.Store(false)
 			server.GracefulStop()
 			return nil
 		},
 	})
 
+	return GRPCServerResult{
+		Server: server,
+		HealthCheck: health.Checker{Name: "grpc", Check: func(context.Context) error {
+			if !serving.Load() {
+				return errors.New("gRPC server is not serving")
+			}
+			return nil
+		}},
+	}
+}
+`
+
+const bootstrapGRPCHealthTemplate = `package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/health"
+)
+
+
+// NewGRPCHealthServer serves grpc.health.v1. The overall status ("") is
+// SERVING only while every registered health check passes
+func NewGRPCHealthServer(lc fx.Lifecycle) *grpchealth.Server {
+	server := grpchealth.NewServer()
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			server.Shutdown()
+			return nil
+		},
+	})
+
 	return server
 }
+
+
+// WatchGRPCHealth keeps the gRPC health status in sync with the same checks
+// that back /readyz. It is an invoke rather than part of NewGRPCHealthServer:
+// the gRPC server registers a check of its own and depends on the health server
+func WatchGRPCHealth(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger, server *grpchealth.Server, checks *health.Service) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				watchHealth(ctx, server, checks, cfg.GRPC.HealthInterval, logger)
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			<-done
+			return nil
+		},
+	})
+}
+
+
+func watchHealth(ctx context.Context, server *grpchealth.Server, checks *health.Service, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		updateHealth(ctx, server, checks, logger)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+
+func updateHealth(ctx context.Context, server *grpchealth.Server, checks *health.Service, logger *zap.Logger) {
+	status := healthpb.HealthCheckResponse_SERVING
+
+	report := checks.Check(ctx)
+	for _, name := range report.Failed() {
+		logger.Warn("Health check failed", zap.String("dependency", name), zap.String("error", report.Checks[name].Error))
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	server.SetServingStatus("", status)
+}
+`
+
+const bootstrapGRPCHealthTestTemplate = `package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"{{.Name}}/internal/health"
+)
+
+
+func newHealthClient(t *testing.T, server *grpchealth.Server) healthpb.HealthClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+
+func checkStatus(t *testing.T, client healthpb.HealthClient) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	return resp.GetStatus()
+}
+
+
+func TestUpdateHealthServingWhenChecksPass(t *testing.T) {
+	server := grpchealth.NewServer()
+	client := newHealthClient(t, server)
+
+	checks := health.New(time.Second,
+		health.Checker{Name: "ok", Check: func(context.Context) error { return nil }},
+	)
+	updateHealth(context.Background(), server, checks, zap.NewNop())
+
+	if got := checkStatus(t, client); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("status = %v, want SERVING", got)
+	}
+}
+
+
+func TestUpdateHealthNotServingWhenCheckFails(t *testing.T) {
+	server := grpchealth.NewServer()
+	client := newHealthClient(t, server)
+
+	checks := health.New(time.Second,
+		health.Checker{Name: "ok", Check: func(context.Context) error { return nil }},
+		health.Checker{Name: "down", Check: func(context.Context) error { return errors.New("connection refused") }},
+	)
+	updateHealth(context.Background(), server, checks, zap.NewNop())
+
+	if got := checkStatus(t, client); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("status = %v, want NOT_SERVING", got)
+	}
+}
+
+
+func TestUpdateHealthRecovers(t *testing.T) {
+	server := grpchealth.NewServer()
+	client := newHealthClient(t, server)
+
+	var down bool
+	checks := health.New(time.Second,
+		health.Checker{Name: "flaky", Check: func(context.Context) error {
+			if down {
+				return errors.New("timeout")
+			}
+			return nil
+		}},
+	)
+
+	down = true
+	updateHealth(context.Background(), server, checks, zap.NewNop())
+	down = false
+	updateHealth(context.Background(), server, checks, zap.NewNop())
+
+	if got := checkStatus(t, client); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("status = %v, want SERVING", got)
+	}
+}
+`
+
+const bootstrapNATSTemplate = `package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/health"
+)
+
+// NATSResult provides the connection and registers its readiness check
+type NATSResult struct {
+	fx.Out
+
+	Conn        *nats.Conn
+	HealthCheck health.Checker ` + "`group:\"health_checkers\"`" + `
+}
+
+func NewNATSConnection(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (NATSResult, error) {
+	conn, err := nats.Connect(cfg.NATS.URL,
+		nats.Name(cfg.App.Name),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Warn("Disconnected from NATS", zap.Error(err))
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Info("Reconnected to NATS", zap.String("url", conn.ConnectedUrl()))
+		}),
+	)
+	if err != nil {
+		return NATSResult{}, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Connected to NATS", zap.String("url", conn.ConnectedUrl()))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Draining NATS connection")
+			return conn.Drain()
+		},
+	})
+
+	return NATSResult{
+		Conn: conn,
+		HealthCheck: health.Checker{Name: "nats", Check: func(context.Context) error {
+			// The client reconnects on its own, only report the current state
+			if status := conn.Status(); status != nats.CONNECTED {
+				return fmt.Errorf("connection is %s", status)
+			}
+			return nil
+		}},
+	}, nil
+}
+
+func NewJetStream(conn *nats.Conn) (jetstream.JetStream, error) {
+	return jetstream.New(conn)
+}
+`
+
+const bootstrapRabbitMQTemplate = `package bootstrap
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/health"
+)
+
+// RabbitMQConnection keeps an AMQP connection open and re-dials it when the broker drops it
+type RabbitMQConnection struct {
+	url    string
+	logger *zap.Logger
+	mu     sync.RWMutex
+	conn   *amqp.Connection
+	done   chan struct{}
+}
+
+// RabbitMQResult provides the connection and registers its readiness check
+type RabbitMQResult struct {
+	fx.Out
+
+	Conn        *RabbitMQConnection
+	HealthCheck health.Checker ` + "`group:\"health_checkers\"`" + `
+}
+
+func NewRabbitMQConnection(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) RabbitMQResult {
+	rc := &RabbitMQConnection{
+		url:    cfg.RabbitMQ.URL,
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Connecting to RabbitMQ")
+			if err := rc.connect(); err != nil {
+				return err
+			}
+
+			go rc.watch()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Closing RabbitMQ connection")
+			close(rc.done)
+
+			rc.mu.RLock()
+			defer rc.mu.RUnlock()
+			if rc.conn == nil || rc.conn.IsClosed() {
+				return nil
+			}
+			return rc.conn.Close()
+		},
+	})
+
+	return RabbitMQResult{
+		Conn: rc,
+		HealthCheck: health.Checker{Name: "rabbitmq", Check: func(context.Context) error {
+			// Opening a channel proves the connection is usable, not only open
+			ch, err := rc.Channel()
+			if err != nil {
+				return err
+			}
+			return ch.Close()
+		}},
+	}
+}
+
+// Channel opens a new channel on the current connection
+func (c *RabbitMQConnection) Channel() (*amqp.Channel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil, amqp.ErrClosed
+	}
+	return c.conn.Channel()
+}
+
+func (c *RabbitMQConnection) connect() error {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *RabbitMQConnection) watch() {
+	for {
+		c.mu.RLock()
+		closed := c.conn.NotifyClose(make(chan *amqp.Error, 1))
+		c.mu.RUnlock()
+
+		select {
+		case <-c.done:
+			return
+		case reason := <-closed:
+			select {
+			case <-c.done:
+				return
+			default:
+			}
+
+			var err error = amqp.ErrClosed
+			if reason != nil {
+				err = reason
+			}
+			c.logger.Warn("RabbitMQ connection lost", zap.Error(err))
+		}
+
+		backoff := time.Second
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-time.After(backoff):
+			}
+
+			if err := c.connect(); err != nil {
+				c.logger.Warn("Failed to reconnect to RabbitMQ", zap.Duration("backoff", backoff), zap.Error(err))
+				backoff = min(backoff*2, 30*time.Second)
+				continue
+			}
+
+			c.logger.Info("Reconnected to RabbitMQ")
+			break
+		}
+	}
+}
+`
+
+const bootstrapChiTemplate = `package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+{{- if .HasDependency "otel"}}
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+{{- end}}
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+{{- if .HasDependency "prometheus"}}
+	"{{.Name}}/internal/metrics"
+{{- end}}
+)
+
+func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger{{if .HasDependency "prometheus"}}, httpMetrics *metrics.HTTPMetrics{{end}}) *chi.Mux {
+	router := chi.NewRouter()
+
+	// Middleware
+	router.Use(middleware.RequestID)
+	router.Use(middleware.RealIP)
+{{- if .HasDependency "prometheus"}}
+	router.Use(httpMetrics.Middleware)
+{{- end}}
+	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
+	router.Use(requestTimeout(cfg.Server.RequestTimeout))
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+{{- if .HasDependency "otel"}}
+		Handler: otelhttp.NewHandler(router, cfg.Otel.ServiceName),
+{{- else}}
+		Handler: router,
+{{- end}}
+	}
+
+	// Lifecycle hooks
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Starting HTTP server", zap.String("addr", server.Addr))
+
+			go func() {
+				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logger.Error("Failed to start HTTP server", zap.Error(err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Stopping HTTP server")
+			return server.Shutdown(ctx)
+		},
+	})
+
+	return router
+}
+`
+
+const bootstrapGinTemplate = `package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+{{- if .HasDependency "otel"}}
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+{{- end}}
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+{{- if .HasDependency "prometheus"}}
+	"{{.Name}}/internal/metrics"
+{{- end}}
+)
+
+func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger{{if .HasDependency "prometheus"}}, httpMetrics *metrics.HTTPMetrics{{end}}) *gin.Engine {
+	if !cfg.App.Debug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	engine := gin.New()
+
+	// Middleware
+{{- if .HasDependency "otel"}}
+	engine.Use(otelgin.Middleware(cfg.Otel.ServiceName))
+{{- end}}
+{{- if .HasDependency "prometheus"}}
+	engine.Use(httpMetrics.Middleware())
+{{- end}}
+	engine.Use(gin.Logger())
+	engine.Use(gin.Recovery())
+	engine.Use(requestTimeout(cfg.Server.RequestTimeout))
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler: engine,
+	}
+
+	// Lifecycle hooks
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Starting HTTP server", zap.String("addr", server.Addr))
+
+			go func() {
+				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logger.Error("Failed to start HTTP server", zap.Error(err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Stopping HTTP server")
+			return server.Shutdown(ctx)
+		},
+	})
+
+	return engine
+}
+`
+
+const bootstrapFiberTemplate = `package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	fiberlogger "github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+{{- if .HasDependency "otel"}}
+	"github.com/gofiber/contrib/otelfiber"
+{{- end}}
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+{{- if .HasDependency "prometheus"}}
+	"{{.Name}}/internal/metrics"
+{{- end}}
+)
+
+func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger{{if .HasDependency "prometheus"}}, httpMetrics *metrics.HTTPMetrics{{end}}) *fiber.App {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+
+	// Middleware
+{{- if .HasDependency "otel"}}
+	app.Use(otelfiber.Middleware(otelfiber.WithServerName(cfg.Otel.ServiceName)))
+{{- end}}
+{{- if .HasDependency "prometheus"}}
+	app.Use(httpMetrics.Middleware())
+{{- end}}
+	app.Use(fiberlogger.New())
+	app.Use(recover.New())
+	app.Use(cors.New())
+	app.Use(requestTimeout(cfg.Server.RequestTimeout))
+
+	// Lifecycle hooks
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+			logger.Info("Starting HTTP server", zap.String("addr", addr))
+
+			go func() {
+				if err := app.Listen(addr); err != nil {
+					logger.Error("Failed to start HTTP server", zap.Error(err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Stopping HTTP server")
+			return app.ShutdownWithContext(ctx)
+		},
+	})
+
+	return app
+}
+`
+
+const bootstrapServeMuxTemplate = `package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+{{- if .HasDependency "otel"}}
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+{{- end}}
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+{{- if .HasDependency "prometheus"}}
+	"{{.Name}}/internal/metrics"
+{{- end}}
+)
+
+func NewHTTPServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger{{if .HasDependency "prometheus"}}, httpMetrics *metrics.HTTPMetrics{{end}}) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	var handler http.Handler = mux
+{{- if .HasDependency "prometheus"}}
+	// Wraps the mux directly, the route pattern is only known after routing
+	handler = httpMetrics.Middleware(handler)
+{{- end}}
+	handler = requestTimeout(cfg.Server.RequestTimeout)(handler)
+	handler = recoverer(logger, requestLogger(logger, handler))
+{{- if .HasDependency "otel"}}
+	handler = otelhttp.NewHandler(handler, cfg.Otel.ServiceName)
+{{- end}}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler: handler,
+	}
+
+	// Lifecycle hooks
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Starting HTTP server", zap.String("addr", server.Addr))
+
+			go func() {
+				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logger.Error("Failed to start HTTP server", zap.Error(err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Stopping HTTP server")
+			return server.Shutdown(ctx)
+		},
+	})
+
+	return mux
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func requestLogger(logger *zap.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		logger.Info("HTTP request",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", rec.status),
+			zap.Duration("latency", time.Since(start)))
+	})
+}
+
+func recoverer(logger *zap.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				logger.Error("Recovered from panic", zap.Any("panic", rec))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+`
+
+const bootstrapTimeoutTemplate = `package bootstrap
+
+import (
+	"context"
+{{- if or (eq .HTTPFramework "chi") (eq .HTTPFramework "servemux")}}
+	"net/http"
+{{- end}}
+{{- if .HasDependency "realtime"}}
+	"strings"
+{{- end}}
+	"time"
+
+{{- if eq .HTTPFramework "gin"}}
+	"github.com/gin-gonic/gin"
+{{- else if eq .HTTPFramework "fiber"}}
+	"github.com/gofiber/fiber/v2"
+{{- else if eq .HTTPFramework "echo"}}
+	"github.com/labstack/echo/v4"
+{{- end}}
+)
+{{- if .HasDependency "realtime"}}
+
+// streamingPaths hold WebSocket and SSE connections open for as long as
+// the client listens, the request timeout does not apply to them
+var streamingPaths = []string{"/ws/", "/events/"}
+{{- end}}
+
+// requestContext bounds ctx by the configured request timeout, so the handler,
+// use case and repository of one request give up together
+func requestContext(ctx context.Context, path string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+{{- if .HasDependency "realtime"}}
+	for _, prefix := range streamingPaths {
+		if strings.HasPrefix(path, prefix) {
+			return ctx, func() {}
+		}
+	}
+{{- end}}
+	return context.WithTimeout(ctx, timeout)
+}
+{{- if eq .HTTPFramework "echo"}}
+
+func requestTimeout(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, cancel := requestContext(c.Request().Context(), c.Request().URL.Path, timeout)
+			defer cancel()
+
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+{{- else if eq .HTTPFramework "gin"}}
+
+func requestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := requestContext(c.Request.Context(), c.Request.URL.Path, timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+{{- else if eq .HTTPFramework "fiber"}}
+
+// requestTimeout sets the user context that handlers pass on with c.UserContext()
+func requestTimeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := requestContext(c.UserContext(), c.Path(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+{{- else}}
+
+func requestTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := requestContext(r.Context(), r.URL.Path, timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+{{- end}}
 `
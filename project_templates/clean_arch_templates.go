@@ -7,25 +7,114 @@ const appTemplate = `package app
 import (
 	"go.uber.org/fx"
 
-	"%s/internal/bootstrap"
-	"%s/internal/delivery/http"
-	"%s/internal/usecase"
-	"%s/internal/repository"
-	"%s/internal/config"
+	"{{.Name}}/internal/bootstrap"
+{{- if .HasHTTP}}
+	"{{.Name}}/internal/delivery/http"
+{{- end}}
+	"{{.Name}}/internal/health"
+{{- if .HasDependency "grpc"}}
+	"{{.Name}}/internal/delivery/grpc"
+{{- end}}
+{{- if .HasDependency "graphql"}}
+	"{{.Name}}/internal/delivery/graphql"
+{{- end}}
+	"{{.Name}}/internal/usecase"
+	"{{.Name}}/internal/repository"
+	"{{.Name}}/internal/messaging"
+{{- if not .HasMessaging}}
+	"{{.Name}}/internal/messaging/memory"
+{{- end}}
+{{- if .HasDependency "kafka"}}
+	"{{.Name}}/internal/messaging/kafka"
+{{- end}}
+{{- if .HasOutbox}}
+	"{{.Name}}/internal/outbox"
+{{- end}}
+{{- if .HasDependency "nats"}}
+	"{{.Name}}/internal/messaging/nats"
+{{- end}}
+{{- if .HasDependency "rabbitmq"}}
+	"{{.Name}}/internal/messaging/rabbitmq"
+{{- end}}
+{{- if .HasDependency "realtime"}}
+	"{{.Name}}/internal/realtime"
+{{- end}}
+{{- if .HasDependency "prometheus"}}
+	"{{.Name}}/internal/metrics"
+{{- end}}
+{{- if .HasDependency "auth"}}
+	"{{.Name}}/internal/auth"
+{{- end}}
+{{- if .HasDependency "rbac"}}
+	"{{.Name}}/internal/rbac"
+{{- end}}
 )
 
 // Module provides dependencies for the application
 var Module = fx.Options(
 	// Core dependencies
 	bootstrap.Module,
+{{- if .HasDependency "prometheus"}}
+	// Expose Prometheus metrics on the admin port
+	metrics.Module,
+{{- end}}
+{{- if .HasDependency "auth"}}
+	// Validate JWT bearer tokens on the HTTP and gRPC APIs
+	auth.Module,
+{{- end}}
+{{- if .HasDependency "rbac"}}
+	// Check the caller's roles against the RBAC policy in every use case
+	rbac.Module,
+{{- end}}
 	// Provide all usecases
 	usecase.Module,
 	// Provide all repositories
 	repository.Module,
+{{- if .HasHTTP}}
 	// Provide HTTP handlers
 	http.Module,
 	// Register HTTP routes
 	fx.Invoke(http.RegisterRoutes),
+	// Serve /healthz and /readyz from the checks registered by bootstrap providers
+	health.Module,
+{{- else}}
+	// Aggregate the checks registered by bootstrap providers for grpc.health.v1
+	health.Module,
+{{- end}}
+{{- if .HasDependency "grpc"}}
+	// Provide and register gRPC services
+	grpc.Module,
+{{- end}}
+{{- if .HasDependency "graphql"}}
+	// Serve the GraphQL API and playground on the HTTP server
+	graphql.Module,
+{{- end}}
+	// Provide the domain event publisher
+	messaging.Module,
+{{- if not .HasMessaging}}
+	// Log events until a broker is configured
+	memory.Module,
+{{- end}}
+{{- if .HasDependency "kafka"}}
+	// Provide Kafka publishers
+	kafka.Module,
+{{- end}}
+{{- if .HasOutbox}}
+	// Relay outbox records to Kafka
+	outbox.Module,
+{{- end}}
+{{- if .HasDependency "nats"}}
+	// Provide NATS JetStream publishers and consumers
+	nats.Module,
+{{- end}}
+{{- if .HasDependency "rabbitmq"}}
+	// Provide RabbitMQ publishers and consumers
+	rabbitmq.Module,
+{{- end}}
+{{- if .HasDependency "realtime"}}
+	// Push entity events to WebSocket and SSE clients
+	realtime.Module,
+{{- end}}
 )
 `
 
@@ -71,155 +160,1017 @@ func getEnvAsInt(name string, defaultVal int) int {
 }
 `
 
-const userDomainTemplate = `package domain
+const entityDomainTemplate = `package domain
 
 import (
+	"context"
+	"fmt"
+{{- if .Entity.SortsBy "int"}}
+	"strconv"
+{{- end}}
+{{- if or .Entity.HasTimestamps .Entity.HasTime}}
 	"time"
+{{- end}}
+)
+{{- with .Entity}}
+{{- range .Enums}}
+{{- $enum := .EnumType}}
+
+
+type {{$enum}} string
+
+const (
+{{- range .EnumValues}}
+	{{.Const}} {{$enum}} = "{{.Value}}"
+{{- end}}
+)
+
+// Valid reports whether the value is one of the declared constants
+func (v {{$enum}}) Valid() bool {
+	switch v {
+	case {{range $i, $v := .EnumValues}}{{if $i}}, {{end}}{{$v.Const}}{{end}}:
+		return true
+	}
+	return false
+}
+{{- end}}
+
+
+type {{.GoName}} struct {
+{{- range .StructFields}}
+	{{.Name}} {{.Type}} ` + "`json:\"{{.JSON}}\"{{with .Validate}} validate:\"{{.}}\"{{end}}`" + `
+{{- end}}
+}
+
+
+const (
+	{{.GoName}}Created EventType = "{{.Snake}}_created"
+	{{.GoName}}Updated EventType = "{{.Snake}}_updated"
+	{{.GoName}}Deleted EventType = "{{.Snake}}_deleted"
 )
 
-type User struct {
-	ID        string    ` + "`json:\"id\"`" + `
-	Username  string    ` + "`json:\"username\"`" + `
-	Email     string    ` + "`json:\"email\"`" + `
-	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
-	UpdatedAt time.Time ` + "`json:\"updated_at\"`" + `
+// {{.GoName}}SortFields are the fields a list of {{.PluralLabel}} can be sorted by,
+// the first one is the default
+var {{.GoName}}SortFields = []string{ {{- range $i, $f := .SortFields}}{{if $i}}, {{end}}"{{$f.Name}}"{{end -}} }
+
+
+// {{.GoName}}Filter keeps {{.PluralLabel}} whose fields equal every value that is set
+type {{.GoName}}Filter struct {
+{{- range .FilterStructFields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+
+
+// {{.GoName}}ListQuery selects a page of {{.PluralLabel}}. Repositories expect a
+// query that went through Normalize
+type {{.GoName}}ListQuery struct {
+	Filter {{.GoName}}Filter
+	Sort   SortOrder
+	Pagination
+
+	// After is the last item of the previous page, decoded from PageToken
+	After *{{.GoName}}
+}
+
+
+// {{.GoName}}List is one page of {{.PluralLabel}}. NextPageToken is empty on the last page
+type {{.GoName}}List struct {
+	Items         []*{{.GoName}} ` + "`json:\"items\"`" + `
+	NextPageToken string ` + "`json:\"next_page_token,omitempty\"`" + `
 }
 
-//go:generate mockery --name=UserRepository --output=../mocks --outpkg=mocks
-type UserRepository interface {
-	Create(user *User) error
-	GetByID(id string) (*User, error)
-	List() ([]*User, error)
-	Update(user *User) error
-	Delete(id string) error
+
+// Normalize fills in the defaults and validates the query
+func (q *{{.GoName}}ListQuery) Normalize() error {
+{{- range .FilterFields}}{{if eq .Type "enum"}}
+	if q.Filter.{{.GoName}} != nil && !q.Filter.{{.GoName}}.Valid() {
+		return fmt.Errorf("%w: unknown {{.JSONName}} %q", ErrInvalidListQuery, *q.Filter.{{.GoName}})
+	}
+{{- end}}{{end}}
+	if err := q.Sort.normalize({{.GoName}}SortFields); err != nil {
+		return err
+	}
+	if err := q.Pagination.normalize(); err != nil {
+		return err
+	}
+	
+	q.After = nil
+	cursor, err := q.Pagination.cursor(q.Sort)
+	if err != nil || cursor == nil {
+		return err
+	}
+	
+	after := &{{.GoName}}{ID: cursor.ID}
+	if err := after.setSortValue(q.Sort.Field, cursor.Value); err != nil {
+		return fmt.Errorf("%w: malformed page_token", ErrInvalidListQuery)
+	}
+	q.After = after
+	return nil
 }
 
-//go:generate mockery --name=UserUseCase --output=../mocks --outpkg=mocks
-type UserUseCase interface {
-	Create(user *User) error
-	GetByID(id string) (*User, error)
-	List() ([]*User, error)
-	Update(user *User) error
-	Delete(id string) error
+
+// NextPageToken returns the token of the page that follows last
+func (q {{.GoName}}ListQuery) NextPageToken(last *{{.GoName}}) string {
+	return PageCursor{Sort: q.Sort.String(), Value: last.SortValue(q.Sort.Field), ID: last.ID}.Encode()
 }
+
+
+// SortValue returns the value of a sort field the way page tokens store it
+func ({{.VarName}} *{{.GoName}}) SortValue(field string) string {
+	switch field {
+{{- range .SortFields}}{{if ne .Name "id"}}
+	case "{{.Name}}":
+{{- if eq .Type "time"}}
+		return {{$.Entity.VarName}}.{{.GoName}}.Format(time.RFC3339Nano)
+{{- else if eq .Type "int"}}
+		return strconv.FormatInt({{$.Entity.VarName}}.{{.GoName}}, 10)
+{{- else if eq .Type "enum"}}
+		return string({{$.Entity.VarName}}.{{.GoName}})
+{{- else}}
+		return {{$.Entity.VarName}}.{{.GoName}}
+{{- end}}
+{{- end}}{{end}}
+	}
+	return {{.VarName}}.ID
+}
+
+
+func ({{.VarName}} *{{.GoName}}) setSortValue(field, value string) error {
+	var err error
+	switch field {
+{{- range .SortFields}}{{if ne .Name "id"}}
+	case "{{.Name}}":
+{{- if eq .Type "time"}}
+		{{$.Entity.VarName}}.{{.GoName}}, err = time.Parse(time.RFC3339Nano, value)
+{{- else if eq .Type "int"}}
+		{{$.Entity.VarName}}.{{.GoName}}, err = strconv.ParseInt(value, 10, 64)
+{{- else if eq .Type "enum"}}
+		{{$.Entity.VarName}}.{{.GoName}} = {{$.Entity.GoName}}{{.GoName}}(value)
+{{- else}}
+		{{$.Entity.VarName}}.{{.GoName}} = value
+{{- end}}
+{{- end}}{{end}}
+	}
+	return err
+}
+
+//go:generate mockery --name={{.GoName}}Repository --output=../mocks --outpkg=mocks
+type {{.GoName}}Repository interface {
+	Create(ctx context.Context, {{.VarName}} *{{.GoName}}) error
+	GetByID(ctx context.Context, id string) (*{{.GoName}}, error)
+	List(ctx context.Context, query {{.GoName}}ListQuery) (*{{.GoName}}List, error)
+	Update(ctx context.Context, {{.VarName}} *{{.GoName}}) error
+	Delete(ctx context.Context, id string) error
+}
+
+//go:generate mockery --name={{.GoName}}UseCase --output=../mocks --outpkg=mocks
+type {{.GoName}}UseCase interface {
+	Create(ctx context.Context, {{.VarName}} *{{.GoName}}) error
+	GetByID(ctx context.Context, id string) (*{{.GoName}}, error)
+	List(ctx context.Context, query {{.GoName}}ListQuery) (*{{.GoName}}List, error)
+	Update(ctx context.Context, {{.VarName}} *{{.GoName}}) error
+	Delete(ctx context.Context, id string) error
+}
+{{- end}}
 `
 
-const userUsecaseTemplate = `package usecase
+const eventsDomainTemplate = `package domain
 
 import (
-	"time"
+	"context"
+)
+
+type EventType string
+
+// Event is the envelope every entity change is published in.
+// Entity and ID identify the aggregate, Data carries its state
+// (only the ID for deletions)
+type Event struct {
+	Type   EventType ` + "`json:\"type\"`" + `
+	Entity string    ` + "`json:\"entity\"`" + `
+	ID     string    ` + "`json:\"id\"`" + `
+	Data   any       ` + "`json:\"data,omitempty\"`" + `
+}
+
+//go:generate mockery --name=EventPublisher --output=../mocks --outpkg=mocks
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+`
+
+// listDomainTemplate - общие типы списков: страница, сортировка и токен
+// следующей страницы. Фильтры и поля сортировки объявлены у каждой сущности
+const listDomainTemplate = `package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is used when a list request does not set a limit
+	DefaultPageSize = 20
+	// MaxPageSize caps the number of items returned in one page
+	MaxPageSize = 100
+)
+
+// ErrInvalidListQuery is returned for unknown sort fields or filter values,
+// negative limits and offsets, and page tokens that cannot be decoded.
+// It is an ErrInvalidArgument
+var ErrInvalidListQuery = Errorf(ErrInvalidArgument, "invalid list query")
+
+// Pagination selects one page of a list. A page token from the previous
+// response continues right after its last item (keyset pagination) and is
+// preferred for walking a whole list; Offset skips items instead
+type Pagination struct {
+	Limit     int
+	Offset    int
+	PageToken string
+}
+
+func (p *Pagination) normalize() error {
+	if p.Limit < 0 || p.Offset < 0 {
+		return fmt.Errorf("%w: limit and offset must not be negative", ErrInvalidListQuery)
+	}
+	if p.PageToken != "" && p.Offset > 0 {
+		return fmt.Errorf("%w: offset cannot be combined with page_token", ErrInvalidListQuery)
+	}
+	switch {
+	case p.Limit == 0:
+		p.Limit = DefaultPageSize
+	case p.Limit > MaxPageSize:
+		p.Limit = MaxPageSize
+	}
+	return nil
+}
+
+// cursor decodes the page token. A token is only valid for the sort order
+// of the list it came from
+func (p Pagination) cursor(sort SortOrder) (*PageCursor, error) {
+	if p.PageToken == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(p.PageToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed page_token", ErrInvalidListQuery)
+	}
+	var cursor PageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: malformed page_token", ErrInvalidListQuery)
+	}
+	if cursor.Sort != sort.String() {
+		return nil, fmt.Errorf("%w: page_token was issued for sort %q", ErrInvalidListQuery, cursor.Sort)
+	}
+	return &cursor, nil
+}
+
+// SortOrder is the field a list is sorted by. Items with equal values are
+// ordered by ID, so every page has a stable position
+type SortOrder struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSortOrder reads "name" as ascending and "-name" as descending order
+func ParseSortOrder(s string) SortOrder {
+	if field, ok := strings.CutPrefix(s, "-"); ok {
+		return SortOrder{Field: field, Desc: true}
+	}
+	return SortOrder{Field: s}
+}
+
+func (s SortOrder) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// normalize checks the field against the sortable ones, the first of
+// which is the default
+func (s *SortOrder) normalize(fields []string) error {
+	if s.Field == "" {
+		s.Field = fields[0]
+		return nil
+	}
+	if !slices.Contains(fields, s.Field) {
+		return fmt.Errorf("%w: cannot sort by %q, expected one of: %s", ErrInvalidListQuery, s.Field, strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// PageCursor is what a page token carries: the sort order and the sort
+// value and ID of the last item of the page
+type PageCursor struct {
+	Sort  string ` + "`json:\"s\"`" + `
+	Value string ` + "`json:\"v\"`" + `
+	ID    string ` + "`json:\"id\"`" + `
+}
+
+// Encode returns the opaque page token
+func (c PageCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+`
+
+const domainErrorsTemplate = `package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failures the delivery layers know how to report. Use cases and
+// repositories return them wrapped, check them with errors.Is:
+// ErrNotFound is 404 / NotFound, ErrConflict is 409 / AlreadyExists,
+// ErrInvalidArgument is 400 / InvalidArgument, ErrUnauthorized is 401 / Unauthenticated,
+// ErrForbidden is 403 / PermissionDenied
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+{{- if .Proto}}
+	// ErrNotImplemented is returned by use case stubs generated from .proto files
+	ErrNotImplemented = errors.New("not implemented")
+{{- end}}
+)
+
+// Errorf formats an error of the given kind. The message is what clients
+// see, the kind only decides the status:
+//
+//	domain.Errorf(domain.ErrNotFound, "user %s not found", id)
+func Errorf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, message: fmt.Sprintf(format, args...)}
+}
+
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// ConflictError is returned when a unique field already belongs to another
+// record. Field is the JSON name of the field
+type ConflictError struct {
+	Entity string
+	Field  string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s with this %s already exists", e.Entity, e.Field)
+}
+
+// Unwrap makes errors.Is(err, ErrConflict) hold for conflict errors
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+`
+
+const validationDomainTemplate = `package domain
+
+import (
+	"strings"
+)
+
+// FieldError describes why a single request field was rejected. Field is the
+// JSON name, so clients can map it straight back to their form
+type FieldError struct {
+	Field   string ` + "`json:\"field\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// ValidationError is returned by use cases when the input breaks the field
+// rules; delivery layers turn it into 400 / InvalidArgument
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + " " + f.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Unwrap makes errors.Is(err, ErrInvalidArgument) hold for validation errors
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidArgument
+}
+`
+
+const validationUsecaseTemplate = `package usecase
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	
-	"go.uber.org/fx"
-	"go.uber.org/zap"
+	"github.com/go-playground/validator/v10"
 	
 	"{{.Name}}/internal/domain"
 )
 
+// validate checks the struct tags of the domain types
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report JSON names, the ones clients actually send
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// validateStruct runs the validate tags and converts failures to a
+// domain.ValidationError with one entry per field
+func validateStruct(v any) error {
+	err := validate.Struct(v)
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	
+	fields := make([]domain.FieldError, len(errs))
+	for i, e := range errs {
+		fields[i] = domain.FieldError{Field: e.Field(), Message: fieldMessage(e)}
+	}
+	return &domain.ValidationError{Fields: fields}
+}
+
+func fieldMessage(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "uuid":
+		return "must be a valid UUID"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(e.Param(), " ", ", ")
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", e.Param())
+	}
+	return "is invalid"
+}
+`
+
+const usecaseModuleTemplate = `package usecase
+
+import (
+	"crypto/rand"
+	"fmt"
+	
+	"go.uber.org/fx"
+)
+
 var Module = fx.Options(
-	fx.Provide(NewUserUseCase),
+	fx.Provide(
+{{- range .Entities}}
+		New{{.GoName}}UseCase,
+{{- end}}
+{{- if .Proto}}
+{{- range .Proto.Services}}
+		New{{.GoName}}UseCase,
+{{- end}}
+{{- end}}
+	),
+{{- if .HasDependency "otel"}}
+	// Wrap every use case call in a span
+{{- range .Entities}}
+	fx.Decorate(NewTraced{{.GoName}}UseCase),
+{{- end}}
+{{- end}}
 )
 
-type userUseCase struct {
-	repo   domain.UserRepository
+// newID returns a random RFC 4122 version 4 UUID
+func newID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+`
+
+const entityUsecaseTemplate = `package usecase
+
+import (
+	"context"
+{{- if .Entity.HasTimestamps}}
+	"time"
+{{- end}}
+	
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
+
+type {{.VarName}}UseCase struct {
+	repo   domain.{{.GoName}}Repository
+	events domain.EventPublisher
+{{- if $.HasDependency "rbac"}}
+	authz  domain.Authorizer
+{{- end}}
 	logger *zap.Logger
 }
 
-func NewUserUseCase(repo domain.UserRepository, logger *zap.Logger) domain.UserUseCase {
-	return &userUseCase{
+func New{{.GoName}}UseCase(repo domain.{{.GoName}}Repository, events domain.EventPublisher{{if $.HasDependency "rbac"}}, authz domain.Authorizer{{end}}, logger *zap.Logger) domain.{{.GoName}}UseCase {
+	return &{{.VarName}}UseCase{
 		repo:   repo,
+		events: events,
+{{- if $.HasDependency "rbac"}}
+		authz:  authz,
+{{- end}}
 		logger: logger,
 	}
 }
 
-func (u *userUseCase) Create(user *domain.User) error {
-	u.logger.Info("Creating new user", zap.String("username", user.Username))
+func (u *{{.VarName}}UseCase) Create(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+{{- if $.HasDependency "rbac"}}
+	if err := u.authz.Authorize(ctx, domain.{{.GoName}}Resource, domain.ActionCreate); err != nil {
+		return err
+	}
+{{- end}}
+	u.logger.Info("Creating new {{.Label}}")
 	
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
+	if err := validateStruct({{.VarName}}); err != nil {
+		return err
+	}
+{{- if .UniqueFields}}
+	if err := u.checkUnique(ctx, {{.VarName}}); err != nil {
+		return err
+	}
+{{- end}}
+	
+{{- if not .IDGenerated}}
+	{{.VarName}}.ID = newID()
+{{- end}}
+{{- if .HasTimestamps}}
+	{{.VarName}}.CreatedAt = time.Now()
+	{{.VarName}}.UpdatedAt = time.Now()
+{{- end}}
+	
+	if err := u.repo.Create(ctx, {{.VarName}}); err != nil {
+		return err
+	}
 	
-	return u.repo.Create(user)
+	u.publish(ctx, domain.{{.GoName}}Created, {{.VarName}}.ID, {{.VarName}})
+	return nil
+}
+
+func (u *{{.VarName}}UseCase) GetByID(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+{{- if $.HasDependency "rbac"}}
+	if err := u.authz.Authorize(ctx, domain.{{.GoName}}Resource, domain.ActionRead); err != nil {
+		return nil, err
+	}
+{{- end}}
+	u.logger.Info("Getting {{.Label}} by ID", zap.String("id", id))
+	return u.repo.GetByID(ctx, id)
 }
 
-func (u *userUseCase) GetByID(id string) (*domain.User, error) {
-	u.logger.Info("Getting user by ID", zap.String("id", id))
-	return u.repo.GetByID(id)
+func (u *{{.VarName}}UseCase) List(ctx context.Context, query domain.{{.GoName}}ListQuery) (*domain.{{.GoName}}List, error) {
+{{- if $.HasDependency "rbac"}}
+	if err := u.authz.Authorize(ctx, domain.{{.GoName}}Resource, domain.ActionList); err != nil {
+		return nil, err
+	}
+{{- end}}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	
+	u.logger.Info("Getting list of {{.PluralLabel}}",
+		zap.String("sort", query.Sort.String()),
+		zap.Int("limit", query.Limit))
+	return u.repo.List(ctx, query)
 }
 
-func (u *userUseCase) List() ([]*domain.User, error) {
-	u.logger.Info("Getting list of users")
-	return u.repo.List()
+func (u *{{.VarName}}UseCase) Update(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+{{- if $.HasDependency "rbac"}}
+	if err := u.authz.Authorize(ctx, domain.{{.GoName}}Resource, domain.ActionUpdate); err != nil {
+		return err
+	}
+{{- end}}
+	u.logger.Info("Updating {{.Label}}", zap.String("id", {{.VarName}}.ID))
+	
+	if err := validateStruct({{.VarName}}); err != nil {
+		return err
+	}
+{{- if .UniqueFields}}
+	if err := u.checkUnique(ctx, {{.VarName}}); err != nil {
+		return err
+	}
+{{- end}}
+{{- if .HasTimestamps}}
+	
+	{{.VarName}}.UpdatedAt = time.Now()
+{{- end}}
+	
+	if err := u.repo.Update(ctx, {{.VarName}}); err != nil {
+		return err
+	}
+	
+	u.publish(ctx, domain.{{.GoName}}Updated, {{.VarName}}.ID, {{.VarName}})
+	return nil
 }
 
-func (u *userUseCase) Update(user *domain.User) error {
-	u.logger.Info("Updating user", zap.String("id", user.ID))
+func (u *{{.VarName}}UseCase) Delete(ctx context.Context, id string) error {
+{{- if $.HasDependency "rbac"}}
+	if err := u.authz.Authorize(ctx, domain.{{.GoName}}Resource, domain.ActionDelete); err != nil {
+		return err
+	}
+{{- end}}
+	u.logger.Info("Deleting {{.Label}}", zap.String("id", id))
 	
-	user.UpdatedAt = time.Now()
+	if err := u.repo.Delete(ctx, id); err != nil {
+		return err
+	}
 	
-	return u.repo.Update(user)
+	u.publish(ctx, domain.{{.GoName}}Deleted, id, nil)
+	return nil
 }
 
-func (u *userUseCase) Delete(id string) error {
-	u.logger.Info("Deleting user", zap.String("id", id))
-	return u.repo.Delete(id)
+{{- if .UniqueFields}}
+{{- $v := .VarName}}
+
+// checkUnique makes sure no other {{.Label}} already uses the unique fields,
+// so clients get a ConflictError instead of a storage failure
+func (u *{{.VarName}}UseCase) checkUnique(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+{{- range .UniqueFields}}
+{{- if eq .Type "bool"}}
+	if err := u.ensureUnique(ctx, {{$v}}.ID, "{{.JSONName}}", domain.{{$.Entity.GoName}}Filter{ {{- .GoName}}: &{{$v}}.{{.GoName}}}); err != nil {
+		return err
+	}
+{{- else}}
+	if {{$v}}.{{.GoName}} != {{if eq .Type "int"}}0{{else}}""{{end}} {
+		if err := u.ensureUnique(ctx, {{$v}}.ID, "{{.JSONName}}", domain.{{$.Entity.GoName}}Filter{ {{- .GoName}}: &{{$v}}.{{.GoName}}}); err != nil {
+			return err
+		}
+	}
+{{- end}}
+{{- end}}
+	return nil
+}
+
+// ensureUnique fails with a ConflictError when a {{.Label}} other than id
+// matches the filter
+func (u *{{.VarName}}UseCase) ensureUnique(ctx context.Context, id, field string, filter domain.{{.GoName}}Filter) error {
+	query := domain.{{.GoName}}ListQuery{Filter: filter, Pagination: domain.Pagination{Limit: 2}}
+	if err := query.Normalize(); err != nil {
+		return err
+	}
+	
+	existing, err := u.repo.List(ctx, query)
+	if err != nil {
+		return err
+	}
+	for _, item := range existing.Items {
+		if item.ID != id {
+			return &domain.ConflictError{Entity: "{{.Label}}", Field: field}
+		}
+	}
+	return nil
 }
+{{- end}}
+
+// publish reports the change to subscribers; the write has already
+// succeeded, so a failed publish is logged rather than returned
+func (u *{{.VarName}}UseCase) publish(ctx context.Context, eventType domain.EventType, id string, {{.VarName}} *domain.{{.GoName}}) {
+	event := domain.Event{Type: eventType, Entity: "{{.Snake}}", ID: id}
+	if {{.VarName}} != nil {
+		event.Data = {{.VarName}}
+	}
+	if err := u.events.Publish(ctx, event); err != nil {
+		u.logger.Error("Failed to publish {{.Label}} event",
+			zap.String("type", string(eventType)),
+			zap.String("id", id),
+			zap.Error(err))
+	}
+}
+{{- end}}
 `
 
-const userRepositoryTemplate = `package repository
+const repositoryModuleTemplate = `package repository
 
 import (
 	"go.uber.org/fx"
+{{- if .HasDependency "postgres"}}
 	
-	"{{.Name}}/internal/domain"
+	"{{.Name}}/internal/repository/postgres"
+{{- end}}
+{{- if .HasRepositoryCache}}
+	"{{.Name}}/internal/repository/redis"
+{{- end}}
 )
 
 var Module = fx.Options(
-	fx.Provide(NewUserRepository),
+	fx.Provide(
+{{- range .Entities}}
+{{- if $.HasDependency "postgres"}}
+		postgres.New{{.GoName}}Repository,
+{{- else}}
+		New{{.GoName}}Repository,
+{{- end}}
+{{- if $.HasRepositoryCache}}
+		redis.New{{.GoName}}Cache,
+{{- end}}
+{{- end}}
+	),
+{{- if .HasRepositoryCache}}
+	// Use case получают postgres репозитории, обернутые кешем в Redis
+	fx.Decorate(
+{{- range .Entities}}
+		redis.NewCached{{.GoName}}Repository,
+{{- end}}
+	),
+{{- end}}
 )
+`
 
-func NewUserRepository() domain.UserRepository {
+const entityRepositoryTemplate = `package repository
 
+import (
+	"cmp"
+	"context"
+	"slices"
+{{- if .Entity.IDGenerated}}
+	"strconv"
+{{- end}}
+	"sync"
+	
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
 
-	return NewInMemoryUserRepository()
+func New{{.GoName}}Repository() domain.{{.GoName}}Repository {
+	return NewInMemory{{.GoName}}Repository()
 }
 
-type InMemoryUserRepository struct {
-	users map[string]*domain.User
+type InMemory{{.GoName}}Repository struct {
+	mu    sync.RWMutex
+	items map[string]*domain.{{.GoName}}
+{{- if .IDGenerated}}
+	// seq заменяет последовательность, которая выдает ключ в базе данных
+	seq   int64
+{{- end}}
 }
 
-func NewInMemoryUserRepository() *InMemoryUserRepository {
-	return &InMemoryUserRepository{
-		users: make(map[string]*domain.User),
+func NewInMemory{{.GoName}}Repository() *InMemory{{.GoName}}Repository {
+	return &InMemory{{.GoName}}Repository{
+		items: make(map[string]*domain.{{.GoName}}),
 	}
 }
 
-func (r *InMemoryUserRepository) Create(user *domain.User) error {
-	r.users[user.ID] = user
+func (r *InMemory{{.GoName}}Repository) Create(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	{{if .IDGenerated}}
+	if {{.VarName}}.ID == "" {
+		r.seq++
+		{{.VarName}}.ID = strconv.FormatInt(r.seq, 10)
+	}
+	{{end}}
+	r.items[{{.VarName}}.ID] = {{.VarName}}
 	return nil
 }
 
-func (r *InMemoryUserRepository) GetByID(id string) (*domain.User, error) {
-	user, exists := r.users[id]
+func (r *InMemory{{.GoName}}Repository) GetByID(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	{{.VarName}}, exists := r.items[id]
 	if !exists {
-		return nil, nil
+		return nil, domain.Errorf(domain.ErrNotFound, "{{.Label}} %s not found", id)
 	}
-	return user, nil
+	return {{.VarName}}, nil
 }
 
-func (r *InMemoryUserRepository) List() ([]*domain.User, error) {
-	users := make([]*domain.User, 0, len(r.users))
-	for _, user := range r.users {
-		users = append(users, user)
+func (r *InMemory{{.GoName}}Repository) List(ctx context.Context, query domain.{{.GoName}}ListQuery) (*domain.{{.GoName}}List, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	// Тот же порядок, что и в SQL: по полю сортировки, затем по ID
+	compare := func(a, b *domain.{{.GoName}}) int {
+		c := cmp.Or({{.VarName}}SortFields[query.Sort.Field](a, b), cmp.Compare(a.ID, b.ID))
+		if query.Sort.Desc {
+			return -c
+		}
+		return c
+	}
+	
+	items := make([]*domain.{{.GoName}}, 0, len(r.items))
+	for _, {{.VarName}} := range r.items {
+		if !match{{.GoName}}({{.VarName}}, query.Filter) {
+			continue
+		}
+		if query.After != nil && compare({{.VarName}}, query.After) <= 0 {
+			continue
+		}
+		items = append(items, {{.VarName}})
 	}
-	return users, nil
+	slices.SortFunc(items, compare)
+	
+	if query.After == nil {
+		items = items[min(query.Offset, len(items)):]
+	}
+	
+	result := &domain.{{.GoName}}List{Items: items}
+	if len(items) > query.Limit {
+		result.Items = items[:query.Limit]
+		result.NextPageToken = query.NextPageToken(result.Items[query.Limit-1])
+	}
+	return result, nil
 }
 
-func (r *InMemoryUserRepository) Update(user *domain.User) error {
-	r.users[user.ID] = user
+func (r *InMemory{{.GoName}}Repository) Update(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	if _, exists := r.items[{{.VarName}}.ID]; !exists {
+		return domain.Errorf(domain.ErrNotFound, "{{.Label}} %s not found", {{.VarName}}.ID)
+	}
+	r.items[{{.VarName}}.ID] = {{.VarName}}
 	return nil
 }
 
-func (r *InMemoryUserRepository) Delete(id string) error {
-	delete(r.users, id)
+func (r *InMemory{{.GoName}}Repository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	if _, exists := r.items[id]; !exists {
+		return domain.Errorf(domain.ErrNotFound, "{{.Label}} %s not found", id)
+	}
+	delete(r.items, id)
 	return nil
 }
+
+// {{.VarName}}SortFields сравнивают {{.PluralLabel}} по каждому полю из domain.{{.GoName}}SortFields
+var {{.VarName}}SortFields = map[string]func(a, b *domain.{{.GoName}}) int{
+{{- range .SortFields}}
+{{- if eq .Type "time"}}
+	"{{.Name}}": func(a, b *domain.{{$.Entity.GoName}}) int { return a.{{.GoName}}.Compare(b.{{.GoName}}) },
+{{- else}}
+	"{{.Name}}": func(a, b *domain.{{$.Entity.GoName}}) int { return cmp.Compare(a.{{.GoName}}, b.{{.GoName}}) },
+{{- end}}
+{{- end}}
+}
+
+func match{{.GoName}}({{.VarName}} *domain.{{.GoName}}, filter domain.{{.GoName}}Filter) bool {
+{{- range .FilterFields}}
+	if filter.{{.GoName}} != nil && {{$.Entity.VarName}}.{{.GoName}} != *filter.{{.GoName}} {
+		return false
+	}
+{{- end}}
+	return true
+}
+{{- end}}
+`
+
+// entityRepositoryTestTemplate проверяет постраничный обход списка
+// in-memory репозитория на первой сущности проекта
+const entityRepositoryTestTemplate = `package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
+
+
+func new{{.GoName}}TestRepository(t *testing.T, n int) *InMemory{{.GoName}}Repository {
+	t.Helper()
+	
+	repo := NewInMemory{{.GoName}}Repository()
+	for i := range n {
+		if err := repo.Create(context.Background(), &domain.{{.GoName}}{ID: fmt.Sprintf("id-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+
+func list{{.GoName}}IDs(t *testing.T, repo *InMemory{{.GoName}}Repository, query domain.{{.GoName}}ListQuery) ([]string, string) {
+	t.Helper()
+	
+	if err := query.Normalize(); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	page, err := repo.List(context.Background(), query)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	
+	var ids []string
+	for _, item := range page.Items {
+		ids = append(ids, item.ID)
+	}
+	return ids, page.NextPageToken
+}
+
+
+func Test{{.GoName}}ListPageTokens(t *testing.T) {
+	repo := new{{.GoName}}TestRepository(t, 5)
+	
+	query := domain.{{.GoName}}ListQuery{
+		Sort:       domain.ParseSortOrder("-id"),
+		Pagination: domain.Pagination{Limit: 2},
+	}
+	var pages []string
+	for {
+		ids, next := list{{.GoName}}IDs(t, repo, query)
+		pages = append(pages, strings.Join(ids, ","))
+		if next == "" {
+			break
+		}
+		query.PageToken = next
+	}
+	
+	if got, want := strings.Join(pages, " "), "id-4,id-3 id-2,id-1 id-0"; got != want {
+		t.Errorf("pages = %q, want %q", got, want)
+	}
+}
+
+
+func Test{{.GoName}}ListOffset(t *testing.T) {
+	repo := new{{.GoName}}TestRepository(t, 5)
+	
+	ids, next := list{{.GoName}}IDs(t, repo, domain.{{.GoName}}ListQuery{
+		Sort:       domain.ParseSortOrder("id"),
+		Pagination: domain.Pagination{Limit: 2, Offset: 3},
+	})
+	if got, want := strings.Join(ids, ","), "id-3,id-4"; got != want {
+		t.Errorf("ids = %q, want %q", got, want)
+	}
+	if next != "" {
+		t.Errorf("next page token = %q on the last page", next)
+	}
+}
+
+
+func Test{{.GoName}}ListInvalidQuery(t *testing.T) {
+	queries := map[string]domain.{{.GoName}}ListQuery{
+		"unknown sort field":  {Sort: domain.ParseSortOrder("unknown")},
+		"negative limit":      {Pagination: domain.Pagination{Limit: -1}},
+		"malformed token":     {Pagination: domain.Pagination{PageToken: "???"}},
+		"token of other sort": {Sort: domain.ParseSortOrder("-id"), Pagination: domain.Pagination{PageToken: domain.PageCursor{Sort: "id", ID: "id-1"}.Encode()}},
+	}
+	for name, query := range queries {
+		if err := query.Normalize(); !errors.Is(err, domain.ErrInvalidListQuery) {
+			t.Errorf("%s: Normalize() error = %v, want ErrInvalidListQuery", name, err)
+		}
+		if err := query.Normalize(); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Errorf("%s: Normalize() error = %v, want ErrInvalidArgument", name, err)
+		}
+	}
+}
+
+
+func Test{{.GoName}}NotFound(t *testing.T) {
+	repo := new{{.GoName}}TestRepository(t, 1)
+	ctx := context.Background()
+	
+	if _, err := repo.GetByID(ctx, "missing"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByID() error = %v, want ErrNotFound", err)
+	}
+	if err := repo.Update(ctx, &domain.{{.GoName}}{ID: "missing"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Update() error = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, "missing"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Delete() error = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, "id-0"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+}
+{{- end}}
 `
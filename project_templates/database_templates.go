@@ -1,103 +1,111 @@
 package project_templates
 
-const postgresTemplate = `package postgres
+const postgresHelpersTemplate = `package postgres
 
 import (
-	"context"
-	"fmt"
+	"errors"
+	"strings"
 	
-	"github.com/doug-martin/goqu/v9"
-	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"go.uber.org/fx"
-	"go.uber.org/zap"
+	"github.com/jackc/pgx/v5/pgconn"
 	
-	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/domain"
 )
 
 
-type PostgresConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	Database string
-}
+// SQLSTATE коды, которые репозитории переводят в ошибки domain
+const (
+	notNullViolation          = "23502"
+	foreignKeyViolation       = "23503"
+	uniqueViolation           = "23505"
+	checkViolation            = "23514"
+	stringDataRightTruncation = "22001"
+	invalidTextRepresentation = "22P02"
+)
 
 
-func NewPostgresConfig(cfg *config.Config) *PostgresConfig {
-	return &PostgresConfig{
-		Host:     cfg.GetEnv("POSTGRES_HOST", "localhost"),
-		Port:     cfg.GetEnvAsInt("POSTGRES_PORT", 5432),
-		User:     cfg.GetEnv("POSTGRES_USER", "postgres"),
-		Password: cfg.GetEnv("POSTGRES_PASSWORD", "postgres"),
-		Database: cfg.GetEnv("POSTGRES_DB", "app"),
+// dbError translates constraint violations into domain errors, so the
+// delivery layer answers 409 or 400 instead of 500. Other errors are
+// returned unchanged
+func dbError(entity string, err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	
+	switch pgErr.Code {
+	case uniqueViolation:
+		return &domain.ConflictError{Entity: entity, Field: keyColumns(pgErr)}
+	case foreignKeyViolation:
+		// Удаление строки, на которую еще ссылаются, - конфликт, запись
+		// ссылки на несуществующую строку - некорректный аргумент
+		if strings.Contains(pgErr.Detail, "still referenced") {
+			return domain.Errorf(domain.ErrConflict, "%s is still referenced", entity)
+		}
+		return domain.Errorf(domain.ErrInvalidArgument, "%s references a missing record", keyColumns(pgErr))
+	case notNullViolation:
+		return domain.Errorf(domain.ErrInvalidArgument, "%s is required", pgErr.ColumnName)
+	case checkViolation, stringDataRightTruncation, invalidTextRepresentation:
+		return domain.Errorf(domain.ErrInvalidArgument, "invalid %s", entity)
 	}
+	return err
 }
 
 
-func NewPostgresConnection(lc fx.Lifecycle, cfg *PostgresConfig, logger *zap.Logger) (*pgxpool.Pool, error) {
-	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s", 
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
-	
-	poolConfig, err := pgxpool.ParseConfig(connString)
-	if err != nil {
-		return nil, err
-	}
-	
-	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
-	if err != nil {
-		return nil, err
+// keyColumns reads the columns from a detail like
+// "Key (email)=(a@example.com) already exists."
+func keyColumns(pgErr *pgconn.PgError) string {
+	rest, ok := strings.CutPrefix(pgErr.Detail, "Key (")
+	if columns, _, found := strings.Cut(rest, ")="); ok && found {
+		return columns
 	}
-	
-	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			logger.Info("Connecting to PostgreSQL", 
-				zap.String("host", cfg.Host), 
-				zap.Int("port", cfg.Port),
-				zap.String("database", cfg.Database))
-			return pool.Ping(ctx)
-		},
-		OnStop: func(ctx context.Context) error {
-			logger.Info("Closing PostgreSQL connection")
-			pool.Close()
-			return nil
-		},
-	})
-	
-	return pool, nil
+	return pgErr.ConstraintName
 }
 
 
-func NewGoquDatabase(pool *pgxpool.Pool) *goqu.Database {
-	return goqu.New("postgres", nil)
+// nullable stores an empty optional reference, enum or UUID as NULL:
+// an empty string would fail the foreign key, CHECK or type of the column
+func nullable[T comparable](value T) any {
+	var zero T
+	if value == zero {
+		return nil
+	}
+	return value
 }
 `
 
-const postgresUserRepositoryTemplate = `package postgres
+const postgresEntityRepositoryTemplate = `package postgres
 
 import (
 	"context"
 	"errors"
-	"time"
 	
 	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 	
 	"{{.Name}}/internal/domain"
 )
+{{- with .Entity}}
+
+
+const {{.VarName}}Table = "{{.Table}}"
 
 
-type UserRepository struct {
+// {{.VarName}}Columns are selected in the order scan{{.GoName}} reads them
+var {{.VarName}}Columns = goqu.L(` + "`" + `{{.SelectColumns}}` + "`" + `)
+
+
+type {{.GoName}}Repository struct {
 	pool   *pgxpool.Pool
 	db     *goqu.Database
 	logger *zap.Logger
 }
 
 
-func NewUserRepository(pool *pgxpool.Pool, db *goqu.Database, logger *zap.Logger) domain.UserRepository {
-	return &UserRepository{
+func New{{.GoName}}Repository(pool *pgxpool.Pool, db *goqu.Database, logger *zap.Logger) domain.{{.GoName}}Repository {
+	return &{{.GoName}}Repository{
 		pool:   pool,
 		db:     db,
 		logger: logger,
@@ -105,239 +113,790 @@ func NewUserRepository(pool *pgxpool.Pool, db *goqu.Database, logger *zap.Logger
 }
 
 
-func (r *UserRepository) Create(user *domain.User) error {
-	query, _, err := r.db.Insert("users").
+func (r *{{.GoName}}Repository) Create(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	query, _, err := r.db.Insert({{.VarName}}Table).
 		Rows(goqu.Record{
-			"id":         user.ID,
-			"username":   user.Username,
-			"email":      user.Email,
-			"created_at": user.CreatedAt,
-			"updated_at": user.UpdatedAt,
-		}).ToSQL()
+{{- if not .IDGenerated}}
+			"{{.IDColumn}}": {{.VarName}}.ID,
+{{- end}}
+{{- $v := .VarName}}
+{{- range .Fields}}
+			"{{.Column}}": {{.RecordValue $v}},
+{{- end}}
+{{- if .HasTimestamps}}
+			"created_at": {{.VarName}}.CreatedAt,
+			"updated_at": {{.VarName}}.UpdatedAt,
+{{- end}}
+		}).
+{{- if .IDGenerated}}
+		// Ключ выдает база данных
+		Returning(goqu.L(` + "`" + `"{{.IDColumn}}"::text` + "`" + `)).
+{{- end}}
+		ToSQL()
 	
 	if err != nil {
 		return err
 	}
-	
-	_, err = r.pool.Exec(context.Background(), query)
-	return err
+	{{if $.HasOutbox}}
+{{- if .IDGenerated}}
+	event := &domain.Event{Type: domain.{{.GoName}}Created, Entity: "{{.Snake}}", Data: {{.VarName}}}
+	err = withOutbox(ctx, r.pool, event, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query).Scan(&{{.VarName}}.ID); err != nil {
+			return err
+		}
+		event.ID = {{.VarName}}.ID
+		return nil
+	})
+{{- else}}
+	err = withOutbox(ctx, r.pool, &domain.Event{Type: domain.{{.GoName}}Created, Entity: "{{.Snake}}", ID: {{.VarName}}.ID, Data: {{.VarName}}}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query)
+		return err
+	})
+{{- end}}
+{{- else if .IDGenerated}}
+	err = r.pool.QueryRow(ctx, query).Scan(&{{.VarName}}.ID)
+{{- else}}
+	_, err = r.pool.Exec(ctx, query)
+{{- end}}
+	return dbError("{{.Label}}", err)
 }
 
 
-func (r *UserRepository) GetByID(id string) (*domain.User, error) {
-	query, _, err := r.db.From("users").
-		Where(goqu.C("id").Eq(id)).
+func (r *{{.GoName}}Repository) GetByID(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	query, _, err := r.db.From({{.VarName}}Table).
+		Select({{.VarName}}Columns).
+		Where(goqu.C("{{.IDColumn}}").Eq(id)).
 		ToSQL()
 	
 	if err != nil {
 		return nil, err
 	}
 	
-	var user domain.User
-	err = r.pool.QueryRow(context.Background(), query).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-	
+	{{.VarName}}, err := scan{{.GoName}}(r.pool.QueryRow(ctx, query))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.Errorf(domain.ErrNotFound, "{{.Label}} %s not found", id)
+	}
 	if err != nil {
-		return nil, err
+		return nil, dbError("{{.Label}}", err)
 	}
 	
-	return &user, nil
+	return {{.VarName}}, nil
 }
 
 
-func (r *UserRepository) List() ([]*domain.User, error) {
-	query, _, err := r.db.From("users").ToSQL()
+func (r *{{.GoName}}Repository) List(ctx context.Context, query domain.{{.GoName}}ListQuery) (*domain.{{.GoName}}List, error) {
+	ds := r.db.From({{.VarName}}Table).Select({{.VarName}}Columns)
+{{- range .FilterFields}}
+	if query.Filter.{{.GoName}} != nil {
+{{- if eq .Type "enum"}}
+		ds = ds.Where(goqu.C("{{.Column}}").Eq(string(*query.Filter.{{.GoName}})))
+{{- else if or (eq .Type "uuid") (eq .Type "relation")}}
+		// Сравнение текстом: некорректный UUID просто ничего не находит
+		ds = ds.Where(goqu.Cast(goqu.C("{{.Column}}"), "TEXT").Eq(*query.Filter.{{.GoName}}))
+{{- else}}
+		ds = ds.Where(goqu.C("{{.Column}}").Eq(*query.Filter.{{.GoName}}))
+{{- end}}
+	}
+{{- end}}
+	
+	idColumn := goqu.I("{{.IDColumn}}")
+	sortColumn := goqu.I(query.Sort.Field)
+{{- if ne .IDColumn "id"}}
+	if query.Sort.Field == "id" {
+		sortColumn = idColumn
+	}
+{{- end}}
+	if query.Sort.Desc {
+		ds = ds.Order(sortColumn.Desc(), idColumn.Desc())
+	} else {
+		ds = ds.Order(sortColumn.Asc(), idColumn.Asc())
+	}
+	
+	switch {
+	case query.After != nil:
+		// Keyset pagination: строки после последней строки предыдущей страницы
+		op := ">"
+		if query.Sort.Desc {
+			op = "<"
+		}
+		ds = ds.Where(goqu.L("(?, ?) "+op+" (?, ?)", sortColumn, idColumn, query.After.SortValue(query.Sort.Field), query.After.ID))
+	case query.Offset > 0:
+		ds = ds.Offset(uint(query.Offset))
+	}
+	
+	// Лишняя строка показывает, есть ли следующая страница
+	sql, _, err := ds.Limit(uint(query.Limit + 1)).ToSQL()
 	if err != nil {
 		return nil, err
 	}
 	
-	rows, err := r.pool.Query(context.Background(), query)
+	rows, err := r.pool.Query(ctx, sql)
 	if err != nil {
-		return nil, err
+		return nil, dbError("{{.Label}}", err)
 	}
 	defer rows.Close()
 	
-	var users []*domain.User
+	items := make([]*domain.{{.GoName}}, 0, query.Limit+1)
 	for rows.Next() {
-		var user domain.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Username,
-			&user.Email,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		{{.VarName}}, err := scan{{.GoName}}(rows)
 		if err != nil {
 			return nil, err
 		}
-		users = append(users, &user)
+		items = append(items, {{.VarName}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError("{{.Label}}", err)
 	}
 	
-	return users, nil
+	result := &domain.{{.GoName}}List{Items: items}
+	if len(items) > query.Limit {
+		result.Items = items[:query.Limit]
+		result.NextPageToken = query.NextPageToken(result.Items[query.Limit-1])
+	}
+	return result, nil
 }
 
 
-func (r *UserRepository) Update(user *domain.User) error {
-	query, _, err := r.db.Update("users").
+func (r *{{.GoName}}Repository) Update(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	query, _, err := r.db.Update({{.VarName}}Table).
 		Set(goqu.Record{
-			"username":   user.Username,
-			"email":      user.Email,
-			"updated_at": time.Now(),
+{{- range .Fields}}
+			"{{.Column}}": {{.RecordValue $v}},
+{{- end}}
+{{- if .HasTimestamps}}
+			"updated_at": {{.VarName}}.UpdatedAt,
+{{- end}}
 		}).
-		Where(goqu.C("id").Eq(user.ID)).
+		Where(goqu.C("{{.IDColumn}}").Eq({{.VarName}}.ID)).
 		ToSQL()
 	
 	if err != nil {
 		return err
 	}
-	
-	_, err = r.pool.Exec(context.Background(), query)
-	return err
+	{{if $.HasOutbox}}
+	err = withOutbox(ctx, r.pool, &domain.Event{Type: domain.{{.GoName}}Updated, Entity: "{{.Snake}}", ID: {{.VarName}}.ID, Data: {{.VarName}}}, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query)
+		return affected{{.GoName}}(tag, err, {{.VarName}}.ID)
+	})
+{{- else}}
+	tag, err := r.pool.Exec(ctx, query)
+	err = affected{{.GoName}}(tag, err, {{.VarName}}.ID)
+{{- end}}
+	return dbError("{{.Label}}", err)
 }
 
 
-func (r *UserRepository) Delete(id string) error {
-	query, _, err := r.db.Delete("users").
-		Where(goqu.C("id").Eq(id)).
+func (r *{{.GoName}}Repository) Delete(ctx context.Context, id string) error {
+	query, _, err := r.db.Delete({{.VarName}}Table).
+		Where(goqu.C("{{.IDColumn}}").Eq(id)).
 		ToSQL()
 	
 	if err != nil {
 		return err
 	}
-	
-	_, err = r.pool.Exec(context.Background(), query)
+	{{if $.HasOutbox}}
+	err = withOutbox(ctx, r.pool, &domain.Event{Type: domain.{{.GoName}}Deleted, Entity: "{{.Snake}}", ID: id}, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query)
+		return affected{{.GoName}}(tag, err, id)
+	})
+{{- else}}
+	tag, err := r.pool.Exec(ctx, query)
+	err = affected{{.GoName}}(tag, err, id)
+{{- end}}
+	return dbError("{{.Label}}", err)
+}
+
+
+// affected{{.GoName}} reports a missing {{.Label}} when the statement changed no rows
+func affected{{.GoName}}(tag pgconn.CommandTag, err error, id string) error {
+	if err == nil && tag.RowsAffected() == 0 {
+		return domain.Errorf(domain.ErrNotFound, "{{.Label}} %s not found", id)
+	}
 	return err
 }
+
+
+func scan{{.GoName}}(row pgx.Row) (*domain.{{.GoName}}, error) {
+	var {{.VarName}} domain.{{.GoName}}
+	err := row.Scan(
+		&{{.VarName}}.ID,
+{{- range .Fields}}
+		&{{$v}}.{{.GoName}},
+{{- end}}
+{{- if .HasTimestamps}}
+		&{{.VarName}}.CreatedAt,
+		&{{.VarName}}.UpdatedAt,
+{{- end}}
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.VarName}}, nil
+}
+{{- end}}
 `
 
-const redisTemplate = `package redis
+const redisEntityCacheTemplate = `package redis
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
+	"time"
 	
 	"github.com/redis/go-redis/v9"
-	"go.uber.org/fx"
 	"go.uber.org/zap"
 	
 	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/domain"
 )
+{{- with .Entity}}
 
 
-type RedisConfig struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
+type {{.GoName}}Cache struct {
+	client *redis.Client
+	logger *zap.Logger
+	ttl    time.Duration
 }
 
 
-func NewRedisConfig(cfg *config.Config) *RedisConfig {
-	return &RedisConfig{
-		Host:     cfg.GetEnv("REDIS_HOST", "localhost"),
-		Port:     cfg.GetEnvAsInt("REDIS_PORT", 6379),
-		Password: cfg.GetEnv("REDIS_PASSWORD", ""),
-		DB:       cfg.GetEnvAsInt("REDIS_DB", 0),
+func New{{.GoName}}Cache(client *redis.Client, cfg *config.Config, logger *zap.Logger) *{{.GoName}}Cache {
+	return &{{.GoName}}Cache{
+		client: client,
+		logger: logger,
+		ttl:    cfg.Redis.CacheTTL,
 	}
 }
 
 
-func NewRedisClient(lc fx.Lifecycle, cfg *RedisConfig, logger *zap.Logger) (*redis.Client, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+func (c *{{.GoName}}Cache) Set(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	data, err := json.Marshal({{.VarName}})
+	if err != nil {
+		return err
+	}
 	
-	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			logger.Info("Connecting to Redis", 
-				zap.String("host", cfg.Host), 
-				zap.Int("port", cfg.Port))
-			return client.Ping(ctx).Err()
-		},
-		OnStop: func(ctx context.Context) error {
-			logger.Info("Closing Redis connection")
-			return client.Close()
-		},
-	})
+	key := c.key({{.VarName}}.ID)
+	return c.client.Set(ctx, key, data, c.ttl).Err()
+}
+
+
+func (c *{{.GoName}}Cache) Get(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	key := c.key(id)
+	data, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.Errorf(domain.ErrNotFound, "{{.Label}} %s is not cached", id)
+	}
+	if err != nil {
+		return nil, err
+	}
 	
-	return client, nil
+	var {{.VarName}} domain.{{.GoName}}
+	if err := json.Unmarshal(data, &{{.VarName}}); err != nil {
+		return nil, err
+	}
+	
+	return &{{.VarName}}, nil
+}
+
+
+func (c *{{.GoName}}Cache) Delete(ctx context.Context, id string) error {
+	key := c.key(id)
+	return c.client.Del(ctx, key).Err()
 }
+
+
+func (c *{{.GoName}}Cache) key(id string) string {
+	return "{{.Snake}}:" + id
+}
+{{- end}}
 `
 
-const redisUserCacheTemplate = `package redis
+// redisEntityRepositoryTemplate - декоратор domain репозитория поверх
+// кешем сущности, repository.Module оборачивает им postgres репозиторий
+const redisEntityRepositoryTemplate = `package redis
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"sync/atomic"
 	"time"
 	
-	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 	
+	"{{.Name}}/internal/config"
 	"{{.Name}}/internal/domain"
 )
+{{- with .Entity}}
 
 
-type UserCache struct {
-	client *redis.Client
-	logger *zap.Logger
-	ttl    time.Duration
+// {{.VarName}}Cache is the part of {{.GoName}}Cache the repository uses
+type {{.VarName}}Cache interface {
+	Get(ctx context.Context, id string) (*domain.{{.GoName}}, error)
+	Set(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error
+	Delete(ctx context.Context, id string) error
 }
 
 
-func NewUserCache(client *redis.Client, logger *zap.Logger) *UserCache {
-	return &UserCache{
-		client: client,
-		logger: logger,
-		ttl:    time.Hour, 
+// Cached{{.GoName}}Repository reads {{.Label}} by id through the cache
+// (cache-aside) and drops the cached entry after Update and Delete.
+// Concurrent misses for the same id share one load from the wrapped
+// repository. Cache failures are logged and the wrapped repository is used,
+// an entry that could not be dropped expires after REDIS_CACHE_TTL_MS
+type Cached{{.GoName}}Repository struct {
+	next        domain.{{.GoName}}Repository
+	cache       {{.VarName}}Cache
+	loads       singleflight.Group
+	loadTimeout time.Duration
+	// versions are bumped by every write, a load caches what it read only
+	// if no write to its id landed in between. Ids share the counters by
+	// hash, a collision just leaves an entry uncached
+	versions [64]atomic.Uint64
+	logger   *zap.Logger
+}
+
+
+func NewCached{{.GoName}}Repository(next domain.{{.GoName}}Repository, cache *{{.GoName}}Cache, cfg *config.Config, logger *zap.Logger) domain.{{.GoName}}Repository {
+	return &Cached{{.GoName}}Repository{
+		next:        next,
+		cache:       cache,
+		loadTimeout: cfg.Redis.CacheLoadTimeout,
+		logger:      logger,
 	}
 }
 
 
-func (c *UserCache) Set(user *domain.User) error {
-	data, err := json.Marshal(user)
+func (r *Cached{{.GoName}}Repository) Create(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	return r.next.Create(ctx, {{.VarName}})
+}
+
+
+func (r *Cached{{.GoName}}Repository) GetByID(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	cached, err := r.cache.Get(ctx, id)
+	if err == nil {
+		return cached, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		r.logger.Warn("Failed to read cached {{.Label}}", zap.String("id", id), zap.Error(err))
+	}
+	
+	// Загрузка общая для всех, кто ждет этот id, поэтому не зависит от
+	// отмены ctx первого вызова. Вызов, чей ctx отменен, перестает ждать
+	loads := r.loads.DoChan(id, func() (any, error) {
+		return r.load(context.WithoutCancel(ctx), id)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-loads:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		// Каждый вызывающий получает свою копию: use case меняет прочитанное
+		{{.VarName}} := *result.Val.(*domain.{{.GoName}})
+		return &{{.VarName}}, nil
+	}
+}
+
+
+// load reads {{.Label}} from the wrapped repository and caches it
+func (r *Cached{{.GoName}}Repository) load(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.loadTimeout)
+	defer cancel()
+	
+	// Промах мог случиться, пока предыдущая загрузка еще не положила
+	// запись в кеш
+	if cached, err := r.cache.Get(ctx, id); err == nil {
+		return cached, nil
+	}
+	
+	version := r.version(id)
+	start := version.Load()
+	{{.VarName}}, err := r.next.GetByID(ctx, id)
 	if err != nil {
+		return nil, err
+	}
+	
+	// Запись, которая пришла во время загрузки, уже удалила запись из кеша,
+	// прочитанное до нее значение туда не кладется
+	if version.Load() != start {
+		return {{.VarName}}, nil
+	}
+	if err := r.cache.Set(ctx, {{.VarName}}); err != nil {
+		r.logger.Warn("Failed to cache {{.Label}}", zap.String("id", id), zap.Error(err))
+		return {{.VarName}}, nil
+	}
+	if version.Load() != start {
+		r.drop(ctx, id)
+	}
+	return {{.VarName}}, nil
+}
+
+
+func (r *Cached{{.GoName}}Repository) List(ctx context.Context, query domain.{{.GoName}}ListQuery) (*domain.{{.GoName}}List, error) {
+	return r.next.List(ctx, query)
+}
+
+
+func (r *Cached{{.GoName}}Repository) Update(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	if err := r.next.Update(ctx, {{.VarName}}); err != nil {
 		return err
 	}
+	r.invalidate(ctx, {{.VarName}}.ID)
+	return nil
+}
+
+
+func (r *Cached{{.GoName}}Repository) Delete(ctx context.Context, id string) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+
+// invalidate drops the cached entry after a write. Bumping the version
+// keeps a load that is already running from caching what it read before the
+// write, Forget makes the next miss load again instead of joining it
+func (r *Cached{{.GoName}}Repository) invalidate(ctx context.Context, id string) {
+	r.version(id).Add(1)
+	r.loads.Forget(id)
+	r.drop(ctx, id)
+}
+
+
+func (r *Cached{{.GoName}}Repository) drop(ctx context.Context, id string) {
+	if err := r.cache.Delete(ctx, id); err != nil {
+		r.logger.Warn("Failed to invalidate cached {{.Label}}", zap.String("id", id), zap.Error(err))
+	}
+}
+
+
+func (r *Cached{{.GoName}}Repository) version(id string) *atomic.Uint64 {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return &r.versions[h.Sum32()%uint32(len(r.versions))]
+}
+{{- end}}
+`
+
+const redisEntityRepositoryTestTemplate = `package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
+
+
+// fake{{.GoName}}Repository counts the loads that reach it. When release is
+// set GetByID reads the {{.Label}}, reports it on loaded and waits for
+// release to be closed before returning what it read
+type fake{{.GoName}}Repository struct {
+	domain.{{.GoName}}Repository
+	mu      sync.Mutex
+	items   map[string]domain.{{.GoName}}
+	loads   atomic.Int32
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+
+func (r *fake{{.GoName}}Repository) GetByID(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	r.loads.Add(1)
+	r.mu.Lock()
+	{{.VarName}}, ok := r.items[id]
+	r.mu.Unlock()
 	
-	key := c.userKey(user.ID)
-	return c.client.Set(context.Background(), key, data, c.ttl).Err()
+	if r.release != nil {
+		if r.loaded != nil {
+			r.loaded <- struct{}{}
+		}
+		<-r.release
+	}
+	if !ok {
+		return nil, domain.Errorf(domain.ErrNotFound, "{{.Label}} %s not found", id)
+	}
+	return &{{.VarName}}, nil
 }
 
 
-func (c *UserCache) Get(id string) (*domain.User, error) {
-	key := c.userKey(id)
-	data, err := c.client.Get(context.Background(), key).Bytes()
+func (r *fake{{.GoName}}Repository) Update(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items[{{.VarName}}.ID] = *{{.VarName}}
+	return nil
+}
+
+
+func (r *fake{{.GoName}}Repository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+	return nil
+}
+
+
+// fake{{.GoName}}Cache keeps entries in memory, misses counts Get calls
+// that found nothing
+type fake{{.GoName}}Cache struct {
+	mu      sync.Mutex
+	entries map[string]domain.{{.GoName}}
+	misses  sync.WaitGroup
+	fail    error
+}
+
+
+func (c *fake{{.GoName}}Cache) Get(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail != nil {
+		return nil, c.fail
+	}
+	{{.VarName}}, ok := c.entries[id]
+	if !ok {
+		c.misses.Done()
+		return nil, domain.Errorf(domain.ErrNotFound, "{{.Label}} %s is not cached", id)
+	}
+	return &{{.VarName}}, nil
+}
+
+
+func (c *fake{{.GoName}}Cache) Set(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail != nil {
+		return c.fail
+	}
+	c.entries[{{.VarName}}.ID] = *{{.VarName}}
+	return nil
+}
+
+
+func (c *fake{{.GoName}}Cache) Delete(ctx context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail != nil {
+		return c.fail
+	}
+	delete(c.entries, id)
+	return nil
+}
+
+
+func newCached{{.GoName}}TestRepository() (*Cached{{.GoName}}Repository, *fake{{.GoName}}Repository, *fake{{.GoName}}Cache) {
+	repo := &fake{{.GoName}}Repository{items: map[string]domain.{{.GoName}}{"id-1": {ID: "id-1"}}}
+	cache := &fake{{.GoName}}Cache{entries: map[string]domain.{{.GoName}}{}}
+	return &Cached{{.GoName}}Repository{next: repo, cache: cache, loadTimeout: time.Second, logger: zap.NewNop()}, repo, cache
+}
+
+
+// getByID expects the {{.Label}} to be found, misses is the number of cache
+// misses the call is going to see. A load checks the cache once more, so a
+// read that reaches the repository misses twice
+func getByID(t *testing.T, cached *Cached{{.GoName}}Repository, cache *fake{{.GoName}}Cache, misses int) *domain.{{.GoName}} {
+	t.Helper()
+	cache.misses.Add(misses)
+	{{.VarName}}, err := cached.GetByID(context.Background(), "id-1")
 	if err != nil {
-		if err == redis.Nil {
-			return nil, nil 
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	return {{.VarName}}
+}
+
+
+func TestCached{{.GoName}}RepositoryReadsThroughCache(t *testing.T) {
+	cached, repo, cache := newCached{{.GoName}}TestRepository()
+	
+	getByID(t, cached, cache, 2)
+	getByID(t, cached, cache, 0)
+	
+	if got := repo.loads.Load(); got != 1 {
+		t.Fatalf("expected 1 load, got %d", got)
+	}
+}
+
+
+func TestCached{{.GoName}}RepositoryInvalidates(t *testing.T) {
+	ctx := context.Background()
+	
+	t.Run("update", func(t *testing.T) {
+		cached, repo, cache := newCached{{.GoName}}TestRepository()
+		{{.VarName}} := getByID(t, cached, cache, 2)
+		
+		if err := cached.Update(ctx, {{.VarName}}); err != nil {
+			t.Fatalf("Update() error = %v", err)
 		}
-		return nil, err
+		getByID(t, cached, cache, 2)
+		
+		if got := repo.loads.Load(); got != 2 {
+			t.Fatalf("expected the update to drop the cached entry, got %d loads", got)
+		}
+	})
+	
+	t.Run("delete", func(t *testing.T) {
+		cached, _, cache := newCached{{.GoName}}TestRepository()
+		getByID(t, cached, cache, 2)
+		
+		if err := cached.Delete(ctx, "id-1"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		cache.misses.Add(2)
+		if _, err := cached.GetByID(ctx, "id-1"); !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound after delete, got %v", err)
+		}
+	})
+}
+
+
+func TestCached{{.GoName}}RepositorySharesLoads(t *testing.T) {
+	const callers = 10
+	cached, repo, cache := newCached{{.GoName}}TestRepository()
+	repo.release = make(chan struct{})
+	// Каждый вызов промахивается один раз, загрузка - еще раз перед
+	// чтением репозитория. Вызов, который не успел присоединиться к
+	// загрузке, найдет ее результат в кеше
+	cache.misses.Add(callers + 1)
+	
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cached.GetByID(context.Background(), "id-1")
+			errs <- err
+		}()
 	}
 	
-	var user domain.User
-	if err := json.Unmarshal(data, &user); err != nil {
-		return nil, err
+	cache.misses.Wait()
+	close(repo.release)
+	wg.Wait()
+	close(errs)
+	
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+	}
+	if got := repo.loads.Load(); got != 1 {
+		t.Fatalf("expected concurrent misses to share 1 load, got %d", got)
+	}
+}
+
+
+func TestCached{{.GoName}}RepositoryDropsLoadRacingUpdate(t *testing.T) {
+	ctx := context.Background()
+	cached, repo, cache := newCached{{.GoName}}TestRepository()
+	repo.loaded = make(chan struct{})
+	repo.release = make(chan struct{})
+	cache.misses.Add(2)
+	
+	loads := make(chan error, 1)
+	go func() {
+		_, err := cached.GetByID(ctx, "id-1")
+		loads <- err
+	}()
+	
+	// Загрузка прочитала {{.Label}} до обновления и вернет его после
+	<-repo.loaded
+	if err := cached.Update(ctx, &domain.{{.GoName}}{ID: "id-1"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	repo.loaded = nil
+	close(repo.release)
+	if err := <-loads; err != nil {
+		t.Fatalf("GetByID() error = %v", err)
 	}
 	
-	return &user, nil
+	// Устаревший {{.Label}} не попал в кеш, поэтому чтение снова идет в репозиторий
+	getByID(t, cached, cache, 2)
+	if got := repo.loads.Load(); got != 2 {
+		t.Fatalf("expected the {{.Label}} read before the update not to be cached, got %d loads", got)
+	}
 }
 
 
-func (c *UserCache) Delete(id string) error {
-	key := c.userKey(id)
-	return c.client.Del(context.Background(), key).Err()
+func TestCached{{.GoName}}RepositoryCancelledCallerDoesNotFailLoad(t *testing.T) {
+	cached, repo, cache := newCached{{.GoName}}TestRepository()
+	repo.loaded = make(chan struct{})
+	repo.release = make(chan struct{})
+	cache.misses.Add(3)
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := cached.GetByID(ctx, "id-1")
+		first <- err
+	}()
+	<-repo.loaded
+	
+	// Второй вызов ждет загрузку, которую начал первый, и получает ее
+	// результат, хотя первый вызов отменен
+	second := make(chan error, 1)
+	go func() {
+		_, err := cached.GetByID(context.Background(), "id-1")
+		second <- err
+	}()
+	cache.misses.Wait()
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled call to return context.Canceled, got %v", err)
+	}
+	close(repo.release)
+	
+	if err := <-second; err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got := repo.loads.Load(); got != 1 {
+		t.Fatalf("expected 1 shared load, got %d", got)
+	}
 }
 
 
-func (c *UserCache) userKey(id string) string {
-	return "user:" + id
+func TestCached{{.GoName}}RepositoryFallsBackWhenCacheFails(t *testing.T) {
+	cached, repo, cache := newCached{{.GoName}}TestRepository()
+	cache.fail = errors.New("connection refused")
+	
+	getByID(t, cached, cache, 0)
+	getByID(t, cached, cache, 0)
+	
+	if got := repo.loads.Load(); got != 2 {
+		t.Fatalf("expected every read to reach the repository, got %d loads", got)
+	}
 }
+{{- end}}
+`
+
+// entityMigrationUpTemplate создает таблицу сущности. id - строка, его
+// выдает use case, у импортированных таблиц ключ сохраняет свой тип.
+// Колонки relation полей повторяют тип ключа, на который ссылаются
+const entityMigrationUpTemplate = `{{with .Entity}}CREATE TABLE IF NOT EXISTS "{{.Table}}" (
+    "{{.IDColumn}}" {{.IDSQLType}} PRIMARY KEY
+{{- range .Fields}},
+    {{.SQLDefinition}}
+{{- end}}
+{{- if .HasTimestamps}},
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT now(),
+    "updated_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+{{- end}}
+);
+{{- $table := .Table}}
+{{- range .Fields}}{{if and .Indexed (not .Unique)}}
+
+CREATE INDEX IF NOT EXISTS "{{$table}}_{{.Column}}_idx" ON "{{$table}}" ("{{.Column}}");
+{{- end}}{{end}}
+{{end}}`
+
+const entityMigrationDownTemplate = `DROP TABLE IF EXISTS "{{.Entity.Table}}";
 `
@@ -0,0 +1,1197 @@
+package project_templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Типы полей, которые понимает дизайнер сущностей
+const (
+	FieldString   = "string"
+	FieldInt      = "int"
+	FieldBool     = "bool"
+	FieldTime     = "time"
+	FieldUUID     = "uuid"
+	FieldEnum     = "enum"
+	FieldRelation = "relation"
+)
+
+var fieldTypes = []string{FieldString, FieldInt, FieldBool, FieldTime, FieldUUID, FieldEnum, FieldRelation}
+
+// FieldTypes возвращает поддерживаемые типы полей в порядке, в котором их показывает дизайнер
+func FieldTypes() []string {
+	return append([]string(nil), fieldTypes...)
+}
+
+// Ограничения на размер схемы, чтобы один запрос не генерировал бесконечный проект
+const (
+	maxEntities = 32
+	maxFields   = 64
+)
+
+// Entity описывает сущность, для которой генерируется CRUD стек: домен,
+// репозитории, use case, HTTP/gRPC/GraphQL API, миграции и события.
+// ID добавляется к каждой сущности автоматически, CreatedAt и UpdatedAt - если
+// не задано NoTimestamps
+type Entity struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+
+	// TableName, IDColumnName, IDColumnType и NoTimestamps заполняет импорт
+	// SQL схемы, чтобы репозиторий работал с существующей таблицей как она есть
+	TableName    string `json:"table,omitempty"`
+	IDColumnName string `json:"id_column,omitempty"`
+	IDColumnType string `json:"id_column_type,omitempty"`
+	NoTimestamps bool   `json:"no_timestamps,omitempty"`
+}
+
+type Field struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+	Unique   bool   `json:"unique,omitempty"`
+	Indexed  bool   `json:"indexed,omitempty"`
+	// Values - допустимые значения enum поля
+	Values []string `json:"values,omitempty"`
+	// Ref - имя сущности, на которую ссылается relation поле
+	Ref string `json:"ref,omitempty"`
+	// ColumnName задает имя существующей колонки, если оно не выводится из Name,
+	// ColumnType - ее тип, если он отличается от типа по умолчанию
+	ColumnName string `json:"column,omitempty"`
+	ColumnType string `json:"column_type,omitempty"`
+	// Null - колонка существующей таблицы допускает NULL
+	Null bool `json:"null,omitempty"`
+
+	// entity - Go имя сущности-владельца, проставляется в Entities.link
+	entity string
+	// refTable, refID и refType - таблица, ключ и тип ключа сущности Ref
+	refTable string
+	refID    string
+	refType  string
+}
+
+// Entities - список сущностей проекта. Из HTML формы он приходит JSON
+// строкой в поле "entities", поэтому реализует echo.BindUnmarshaler
+type Entities []Entity
+
+func (e *Entities) UnmarshalParam(param string) error {
+	if strings.TrimSpace(param) == "" {
+		*e = nil
+		return nil
+	}
+	var entities []Entity
+	if err := json.Unmarshal([]byte(param), &entities); err != nil {
+		return fmt.Errorf("invalid entities: %w", err)
+	}
+	*e = entities
+	return nil
+}
+
+// DefaultEntities - схема, которую получает проект без своих сущностей
+func DefaultEntities() Entities {
+	return Entities{
+		{
+			Name: "User",
+			Fields: []Field{
+				{Name: "username", Type: FieldString, Required: true},
+				{Name: "email", Type: FieldString, Required: true, Unique: true},
+			},
+		},
+	}
+}
+
+var (
+	entityNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_ -]*$`)
+	fieldNamePattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_ -]*$`)
+	enumValuePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	// Имена таблиц и колонок попадают в SQL, JSON теги и proto файлы
+	sqlNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	sqlTypePattern = regexp.MustCompile(`^[A-Z][A-Z0-9 ]*(\([0-9]+(,[0-9]+)?\))?(\[\])?$`)
+)
+
+// reservedEntities занимают имена, которые генератор уже использует в пакете domain
+var reservedEntities = map[string]bool{
+	"Event": true, "EventType": true, "EventPublisher": true,
+	"Pagination": true, "SortOrder": true, "PageCursor": true,
+	"FieldError": true, "ValidationError": true, "ConflictError": true, "Errorf": true,
+	"Principal": true, "Authorizer": true,
+}
+
+// entityMethods - методы, которые шаблон домена генерирует для сущности и
+// ее ListQuery. Поле с таким же Go именем не скомпилируется
+var entityMethods = map[string]bool{
+	"SortValue": true, "setSortValue": true, "Normalize": true, "NextPageToken": true,
+}
+
+// listTypes - суффиксы типов, которые генерируются для списка каждой сущности
+var listTypes = []string{"Filter", "ListQuery", "List", "SortFields"}
+
+// implicitFields есть у каждой сущности, кроме временных меток при NoTimestamps
+var implicitFields = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true,
+}
+
+// implicitField сообщает, добавляется ли колонка к сущности автоматически.
+// Без временных меток created_at или updated_at - обычное поле
+func (e Entity) implicitField(column string) bool {
+	if column != "id" && !e.HasTimestamps() {
+		return false
+	}
+	return implicitFields[column]
+}
+
+func (e Entities) Validate() error {
+	if len(e) > maxEntities {
+		return fmt.Errorf("too many entities: %d, at most %d are supported", len(e), maxEntities)
+	}
+
+	names := make(map[string]bool, len(e))
+	tables := make(map[string]bool, len(e))
+	for _, entity := range e {
+		if !entityNamePattern.MatchString(entity.Name) {
+			return fmt.Errorf("invalid entity name %q: use letters, digits and underscores, starting with a letter", entity.Name)
+		}
+		for _, sqlName := range []string{entity.TableName, entity.IDColumnName} {
+			if sqlName != "" && !sqlNamePattern.MatchString(sqlName) {
+				return fmt.Errorf("%s: invalid table or column name %q", entity.Name, sqlName)
+			}
+		}
+		if !entity.validIDType() {
+			return fmt.Errorf("%s: unsupported id column type %q, use SMALLSERIAL, SERIAL or BIGSERIAL for keys generated by the database, UUID, TEXT or VARCHAR(36+) for generated UUIDs", entity.Name, entity.IDColumnType)
+		}
+		name := entity.GoName()
+		if reservedEntities[name] {
+			return fmt.Errorf("entity name %q is reserved", entity.Name)
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate entity %q", entity.Name)
+		}
+		names[name] = true
+		if tables[entity.Table()] {
+			return fmt.Errorf("entities %q and another one map to the same table %q", entity.Name, entity.Table())
+		}
+		tables[entity.Table()] = true
+	}
+
+	for _, entity := range e {
+		for _, suffix := range listTypes {
+			if names[entity.GoName()+suffix] {
+				return fmt.Errorf("entity name %q clashes with the %s type generated for %q", entity.GoName()+suffix, suffix, entity.Name)
+			}
+		}
+		if err := entity.validateFields(names); err != nil {
+			return err
+		}
+	}
+
+	_, err := e.sorted()
+	return err
+}
+
+func (e Entity) validateFields(entities map[string]bool) error {
+	if len(e.Fields) == 0 {
+		return fmt.Errorf("entity %q has no fields", e.Name)
+	}
+	if len(e.Fields) > maxFields {
+		return fmt.Errorf("entity %q has too many fields: %d, at most %d are supported", e.Name, len(e.Fields), maxFields)
+	}
+
+	columns := make(map[string]bool, len(e.Fields))
+	names := make(map[string]bool, len(e.Fields))
+	for _, f := range e.Fields {
+		if !fieldNamePattern.MatchString(f.Name) {
+			return fmt.Errorf("%s: invalid field name %q", e.Name, f.Name)
+		}
+		if f.ColumnName != "" && !sqlNamePattern.MatchString(f.ColumnName) {
+			return fmt.Errorf("%s.%s: invalid column name %q", e.Name, f.Name, f.ColumnName)
+		}
+		if f.ColumnType != "" && !sqlTypePattern.MatchString(f.ColumnType) {
+			return fmt.Errorf("%s.%s: invalid column type %q", e.Name, f.Name, f.ColumnType)
+		}
+		column := f.Column()
+		if e.implicitField(column) || e.implicitField(snakeCase(f.Name)) || column == e.IDColumn() {
+			return fmt.Errorf("%s.%s: id, created_at and updated_at are added automatically", e.Name, f.Name)
+		}
+		if columns[column] || names[f.GoName()] {
+			return fmt.Errorf("%s: duplicate field %q", e.Name, f.Name)
+		}
+		if entityMethods[f.GoName()] {
+			return fmt.Errorf("%s.%s: the name clashes with the generated %s method", e.Name, f.Name, f.GoName())
+		}
+		columns[column] = true
+		names[f.GoName()] = true
+
+		switch f.Type {
+		case FieldString, FieldInt, FieldBool, FieldTime, FieldUUID:
+		case FieldEnum:
+			if len(f.Values) == 0 {
+				return fmt.Errorf("%s.%s: enum needs at least one value", e.Name, f.Name)
+			}
+			values := make(map[string]bool, len(f.Values))
+			for _, v := range f.Values {
+				if !enumValuePattern.MatchString(v) {
+					return fmt.Errorf("%s.%s: invalid enum value %q, use lower_snake_case", e.Name, f.Name, v)
+				}
+				if values[v] {
+					return fmt.Errorf("%s.%s: duplicate enum value %q", e.Name, f.Name, v)
+				}
+				values[v] = true
+			}
+		case FieldRelation:
+			if !entities[goName(f.Ref)] {
+				return fmt.Errorf("%s.%s: relation to unknown entity %q", e.Name, f.Name, f.Ref)
+			}
+		default:
+			return fmt.Errorf("%s.%s: unknown field type %q, expected one of: %s", e.Name, f.Name, f.Type, strings.Join(fieldTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// sorted возвращает сущности в порядке, в котором можно создавать их таблицы:
+// сущность идет после всех, на которые ссылается. Ссылка на себя допустима
+func (e Entities) sorted() (Entities, error) {
+	index := make(map[string]int, len(e))
+	for i, entity := range e {
+		index[entity.GoName()] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(e))
+	result := make(Entities, 0, len(e))
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("cyclic relations involving entity %q are not supported", e[i].Name)
+		}
+		state[i] = visiting
+		for _, f := range e[i].Fields {
+			if f.Type != FieldRelation {
+				continue
+			}
+			ref, ok := index[goName(f.Ref)]
+			if !ok || ref == i {
+				continue
+			}
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		result = append(result, e[i])
+		return nil
+	}
+
+	for i := range e {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// link копирует сущности и проставляет полям контекст, нужный шаблонам
+func (e Entities) link() Entities {
+	tables := make(map[string]string, len(e))
+	ids := make(map[string]string, len(e))
+	types := make(map[string]string, len(e))
+	for _, entity := range e {
+		tables[entity.GoName()] = entity.Table()
+		ids[entity.GoName()] = entity.IDColumn()
+		types[entity.GoName()] = entity.idRefType()
+	}
+
+	linked := make(Entities, len(e))
+	for i, entity := range e {
+		fields := make([]Field, len(entity.Fields))
+		for j, f := range entity.Fields {
+			f.entity = entity.GoName()
+			f.refTable = tables[goName(f.Ref)]
+			f.refID = ids[goName(f.Ref)]
+			f.refType = types[goName(f.Ref)]
+			fields[j] = f
+		}
+		entity.Fields = fields
+		linked[i] = entity
+	}
+	return linked
+}
+
+// Migrations возвращает сущности в порядке создания таблиц
+func (e Entities) Migrations() Entities {
+	sorted, err := e.sorted()
+	if err != nil {
+		return e
+	}
+	return sorted
+}
+
+// GoName - имя типа: "order_item" -> "OrderItem"
+func (e Entity) GoName() string {
+	return goName(e.Name)
+}
+
+// VarName - имя локальной переменной: "OrderItem" -> "orderItem".
+// Имена, которые совпадают с ключевыми словами или переменными
+// шаблонов, получают суффикс Item
+func (e Entity) VarName() string {
+	name := lowerFirstWord(e.GoName())
+	if token.IsKeyword(name) || reservedVars[name] {
+		name += "Item"
+	}
+	return name
+}
+
+// PluralGoName: "OrderItem" -> "OrderItems"
+func (e Entity) PluralGoName() string {
+	return goName(pluralize(snakeCase(e.Name)))
+}
+
+// PluralVarName: "OrderItem" -> "orderItems"
+func (e Entity) PluralVarName() string {
+	name := lowerFirstWord(e.PluralGoName())
+	if token.IsKeyword(name) || reservedVars[name] {
+		name += "List"
+	}
+	return name
+}
+
+// Snake используется в именах файлов, событий и proto пакетов: "order_item"
+func (e Entity) Snake() string {
+	return snakeCase(e.Name)
+}
+
+// Table - имя таблицы: "order_items"
+func (e Entity) Table() string {
+	if e.TableName != "" {
+		return e.TableName
+	}
+	return pluralize(snakeCase(e.Name))
+}
+
+// IDColumn - колонка первичного ключа, в домене она всегда ID
+func (e Entity) IDColumn() string {
+	if e.IDColumnName != "" {
+		return e.IDColumnName
+	}
+	return "id"
+}
+
+// IDSQLType - тип колонки первичного ключа. Ключ без типа - текст,
+// в который use case пишет UUID
+func (e Entity) IDSQLType() string {
+	if e.IDColumnType != "" {
+		return e.IDColumnType
+	}
+	return "TEXT"
+}
+
+// IDGenerated сообщает, что ключ выдает база данных: репозиторий не
+// передает его в INSERT и читает через RETURNING
+func (e Entity) IDGenerated() bool {
+	return strings.HasSuffix(e.IDSQLType(), "SERIAL")
+}
+
+// idRefType - тип колонки, которая ссылается на ключ сущности
+func (e Entity) idRefType() string {
+	switch typ := e.IDSQLType(); typ {
+	case "SMALLSERIAL":
+		return "SMALLINT"
+	case "SERIAL":
+		return "INTEGER"
+	case "BIGSERIAL":
+		return "BIGINT"
+	default:
+		return typ
+	}
+}
+
+// validIDType проверяет, что ключ выдает либо база данных, либо use case:
+// во втором случае колонка должна вмещать UUID
+func (e Entity) validIDType() bool {
+	typ := e.IDSQLType()
+	if m := varcharPattern.FindStringSubmatch(typ); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return n >= 36
+	}
+	switch typ {
+	case "SMALLSERIAL", "SERIAL", "BIGSERIAL", "UUID", "TEXT", "VARCHAR":
+		return true
+	}
+	return false
+}
+
+// HasTimestamps сообщает, хранит ли таблица created_at и updated_at
+func (e Entity) HasTimestamps() bool {
+	return !e.NoTimestamps
+}
+
+// Route - сегмент REST пути: "order-items"
+func (e Entity) Route() string {
+	return strings.ReplaceAll(e.Table(), "_", "-")
+}
+
+// Label используется в логах и сообщениях об ошибках: "order item"
+func (e Entity) Label() string {
+	return strings.ReplaceAll(e.Snake(), "_", " ")
+}
+
+// PluralLabel: "order items"
+func (e Entity) PluralLabel() string {
+	return strings.ReplaceAll(e.Table(), "_", " ")
+}
+
+// Title: "Order item"
+func (e Entity) Title() string {
+	label := e.Label()
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// GraphQLName - имя поля запроса: "orderItem"
+func (e Entity) GraphQLName() string {
+	return lowerCamel(e.Snake())
+}
+
+// GraphQLPluralName: "orderItems"
+func (e Entity) GraphQLPluralName() string {
+	return lowerCamel(e.Table())
+}
+
+func (e Entity) HasField(kind string) bool {
+	for _, f := range e.Fields {
+		if f.Type == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Entity) HasTime() bool {
+	return e.HasField(FieldTime)
+}
+
+func (e Entity) HasEnum() bool {
+	return e.HasField(FieldEnum)
+}
+
+// HasNullable сообщает, есть ли поля, которые хранятся как NULL, пока не заданы
+func (e Entity) HasNullable() bool {
+	for _, f := range e.Fields {
+		if f.Nullable() {
+			return true
+		}
+	}
+	return false
+}
+
+// Enums возвращает enum поля сущности
+func (e Entity) Enums() []Field {
+	var enums []Field
+	for _, f := range e.Fields {
+		if f.Type == FieldEnum {
+			enums = append(enums, f)
+		}
+	}
+	return enums
+}
+
+// Required возвращает обязательные поля
+func (e Entity) Required() []Field {
+	var required []Field
+	for _, f := range e.Fields {
+		if f.Required {
+			required = append(required, f)
+		}
+	}
+	return required
+}
+
+// SelectColumns - список колонок для SELECT в порядке полей домена.
+// Ключ читается как текст: у импортированных таблиц он бывает числом
+func (e Entity) SelectColumns() string {
+	columns := []string{fmt.Sprintf("%q::text", e.IDColumn())}
+	for _, f := range e.Fields {
+		columns = append(columns, f.SelectExpr())
+	}
+	if e.HasTimestamps() {
+		columns = append(columns, `"created_at"`, `"updated_at"`)
+	}
+	return strings.Join(columns, ", ")
+}
+
+// GoName - имя поля структуры. Relation поле хранит ID: "author" -> "AuthorID"
+func (f Field) GoName() string {
+	return goName(f.baseName())
+}
+
+// VarName - имя аргумента или локальной переменной
+func (f Field) VarName() string {
+	name := lowerFirstWord(f.GoName())
+	if token.IsKeyword(name) || reservedVars[name] {
+		name += "Value"
+	}
+	return name
+}
+
+// Column - имя колонки и JSON поля: "author" -> "author_id"
+func (f Field) Column() string {
+	if f.ColumnName != "" {
+		return f.ColumnName
+	}
+	return snakeCase(f.baseName())
+}
+
+// JSONName совпадает с именем колонки, как у встроенных полей created_at и updated_at
+func (f Field) JSONName() string {
+	return f.Column()
+}
+
+// ProtoGoName - имя поля в коде, сгенерированном protoc-gen-go: "author_id" -> "AuthorId"
+func (f Field) ProtoGoName() string {
+	return protoCamelCase(f.Column())
+}
+
+// GraphQLName: "author_id" -> "authorId"
+func (f Field) GraphQLName() string {
+	return lowerCamel(f.Column())
+}
+
+func (f Field) baseName() string {
+	if f.Type != FieldRelation {
+		return f.Name
+	}
+	words := splitWords(f.Name)
+	if len(words) > 1 && words[len(words)-1] == "id" {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(append(words, "id"), "_")
+}
+
+// Nullable поля хранятся как NULL, пока значение не задано: пустая строка
+// не проходит ни внешний ключ, ни CHECK для enum, ни тип UUID.
+// Необязательные колонки импортированных таблиц тоже могут быть NULL
+func (f Field) Nullable() bool {
+	if f.Required {
+		return false
+	}
+	if f.Null {
+		return true
+	}
+	switch f.Type {
+	case FieldUUID, FieldEnum, FieldRelation:
+		return true
+	}
+	return false
+}
+
+// EnumType - Go тип enum поля: "OrderStatus"
+func (f Field) EnumType() string {
+	return f.entity + f.GoName()
+}
+
+// EnumValues возвращает константы enum поля
+func (f Field) EnumValues() []EnumValue {
+	values := make([]EnumValue, len(f.Values))
+	for i, v := range f.Values {
+		values[i] = EnumValue{Const: f.EnumType() + goName(v), Value: v}
+	}
+	return values
+}
+
+type EnumValue struct {
+	Const string
+	Value string
+}
+
+// GoType - тип поля в домене
+func (f Field) GoType() string {
+	switch f.Type {
+	case FieldInt:
+		return "int64"
+	case FieldBool:
+		return "bool"
+	case FieldTime:
+		return "time.Time"
+	case FieldEnum:
+		return f.EnumType()
+	default:
+		return "string"
+	}
+}
+
+// DomainType - тип поля с квалификатором пакета domain
+func (f Field) DomainType() string {
+	if f.Type == FieldEnum {
+		return "domain." + f.EnumType()
+	}
+	return f.GoType()
+}
+
+// ProtoType - тип поля в .proto. Время передается строкой в RFC 3339,
+// как created_at и updated_at
+func (f Field) ProtoType() string {
+	switch f.Type {
+	case FieldInt:
+		return "int64"
+	case FieldBool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// GraphQLType - тип поля в GraphQL схеме без признака обязательности
+func (f Field) GraphQLType() string {
+	switch f.Type {
+	case FieldInt:
+		return "Int"
+	case FieldBool:
+		return "Boolean"
+	case FieldTime:
+		return "Time"
+	case FieldRelation:
+		return "ID"
+	default:
+		return "String"
+	}
+}
+
+// OpenAPIType - тип и формат поля в OpenAPI схеме
+func (f Field) OpenAPIType() string {
+	switch f.Type {
+	case FieldInt:
+		return "integer"
+	case FieldBool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+func (f Field) OpenAPIFormat() string {
+	switch f.Type {
+	case FieldInt:
+		return "int64"
+	case FieldTime:
+		return "date-time"
+	case FieldUUID:
+		return "uuid"
+	}
+	if f.Type == FieldString && f.Column() == "email" {
+		return "email"
+	}
+	return ""
+}
+
+// SQLType - тип колонки PostgreSQL
+func (f Field) SQLType() string {
+	if f.ColumnType != "" {
+		return f.ColumnType
+	}
+	switch f.Type {
+	case FieldInt:
+		return "BIGINT"
+	case FieldBool:
+		return "BOOLEAN"
+	case FieldTime:
+		return "TIMESTAMPTZ"
+	case FieldUUID:
+		return "UUID"
+	case FieldRelation:
+		if f.refType != "" {
+			return f.refType
+		}
+		return "TEXT"
+	default:
+		return "TEXT"
+	}
+}
+
+// SQLDefinition - определение колонки в CREATE TABLE
+func (f Field) SQLDefinition() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q %s", f.Column(), f.SQLType())
+	if !f.Nullable() {
+		b.WriteString(" NOT NULL")
+	}
+	if f.Unique {
+		b.WriteString(" UNIQUE")
+	}
+	switch f.Type {
+	case FieldEnum:
+		quoted := make([]string, len(f.Values))
+		for i, v := range f.Values {
+			quoted[i] = "'" + v + "'"
+		}
+		fmt.Fprintf(&b, " CHECK (%q IN (%s))", f.Column(), strings.Join(quoted, ", "))
+	case FieldRelation:
+		fmt.Fprintf(&b, " REFERENCES %q (%q)", f.refTable, f.refID)
+		if f.Nullable() {
+			b.WriteString(" ON DELETE SET NULL")
+		}
+	}
+	return b.String()
+}
+
+// SelectExpr читает колонку в значение Go типа поля: NULL становится
+// пустой строкой, UUID, ссылки и строки нетекстовых типов - текстом
+func (f Field) SelectExpr() string {
+	column := fmt.Sprintf("%q", f.Column())
+	cast := f.Type == FieldUUID || f.Type == FieldRelation || f.Type == FieldString && f.ColumnType != ""
+	if cast {
+		column += "::text"
+	}
+	if f.Nullable() {
+		return fmt.Sprintf("COALESCE(%s, %s) AS %q", column, f.zeroSQL(), f.Column())
+	}
+	if cast {
+		return fmt.Sprintf("%s AS %q", column, f.Column())
+	}
+	return column
+}
+
+// zeroSQL - значение, которым читается NULL: нулевое значение Go типа поля
+func (f Field) zeroSQL() string {
+	switch f.Type {
+	case FieldInt:
+		return "0"
+	case FieldBool:
+		return "false"
+	case FieldTime:
+		return "'0001-01-01 00:00:00+00'"
+	}
+	return "''"
+}
+
+// reservedVars - имена переменных, которые уже заняты в сгенерированном коде
+var reservedVars = map[string]bool{
+	"ctx": true, "err": true, "id": true, "query": true, "rows": true, "row": true,
+	"data": true, "event": true, "events": true, "input": true, "req": true,
+	"response": true, "result": true, "c": true, "h": true, "r": true, "s": true,
+	"u": true, "w": true, "p": true, "t": true, "api": true, "server": true,
+	"router": true, "mux": true, "module": true, "logger": true, "span": true,
+	"tracer": true, "string": true, "error": true, "any": true, "len": true,
+	"new": true, "make": true, "append": true, "delete": true, "copy": true,
+	"time": true, "context": true, "domain": true, "zap": true, "fx": true,
+	"http": true, "json": true, "status": true, "codes": true, "goqu": true,
+	"pgx": true, "redis": true, "fiber": true, "gin": true, "echo": true,
+	"chi": true, "loader": true, "model": true, "generated": true, "true": true,
+	"false": true, "nil": true, "iota": true, "bool": true, "int64": true,
+	"items": true, "compare": true, "sql": true, "ds": true, "filter": true,
+}
+
+// commonInitialisms совпадает со списком golint, по которому gqlgen и
+// protoc-gen-go-grpc строят Go имена
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true, "XMPP": true,
+	"XSRF": true, "XSS": true,
+}
+
+// splitWords разбивает "OrderItem", "order_item", "order-item" и "orderItem"
+// на слова в нижнем регистре
+func splitWords(s string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r):
+			// Граница слова: "orderItem" и "HTTPServer" -> "HTTP", "Server"
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+func snakeCase(s string) string {
+	return strings.Join(splitWords(s), "_")
+}
+
+func goName(s string) string {
+	var b strings.Builder
+	for _, word := range splitWords(s) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// lowerCamel: "author_id" -> "authorId", как имена полей GraphQL
+func lowerCamel(s string) string {
+	words := splitWords(s)
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// lowerFirstWord: "OrderItem" -> "orderItem", "URLPath" -> "urlPath"
+func lowerFirstWord(name string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return name
+	}
+	first := words[0]
+	return first + name[len(first):]
+}
+
+// pluralize склоняет последнее слово по простым правилам английского
+func pluralize(snake string) string {
+	switch {
+	case strings.HasSuffix(snake, "y") && len(snake) > 1 && !strings.ContainsRune("aeiou", rune(snake[len(snake)-2])):
+		return snake[:len(snake)-1] + "ies"
+	case strings.HasSuffix(snake, "s"), strings.HasSuffix(snake, "x"), strings.HasSuffix(snake, "z"),
+		strings.HasSuffix(snake, "ch"), strings.HasSuffix(snake, "sh"):
+		return snake + "es"
+	}
+	return snake + "s"
+}
+
+// protoCamelCase повторяет правила protoc-gen-go для имен полей
+func protoCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Подчеркивание перед строчной буквой пропускается, буква становится заглавной
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case isASCIIDigit(c):
+			b = append(b, c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// StructField - строка объявления поля доменной структуры, выровненная как после gofmt
+type StructField struct {
+	Name string
+	Type string
+	JSON string
+	// Validate - правила go-playground/validator, пусто без проверок
+	Validate string
+}
+
+// StructFields возвращает поля доменной структуры вместе с ID и временными метками
+func (e Entity) StructFields() []StructField {
+	fields := []StructField{{Name: "ID", Type: "string", JSON: "id"}}
+	for _, f := range e.Fields {
+		fields = append(fields, StructField{Name: f.GoName(), Type: f.GoType(), JSON: f.JSONName(), Validate: f.ValidateTag()})
+	}
+	if e.HasTimestamps() {
+		fields = append(fields,
+			StructField{Name: "CreatedAt", Type: "time.Time", JSON: "created_at"},
+			StructField{Name: "UpdatedAt", Type: "time.Time", JSON: "updated_at"},
+		)
+	}
+	return alignStructFields(fields)
+}
+
+// alignStructFields дополняет имена и типы пробелами до общей ширины
+func alignStructFields(fields []StructField) []StructField {
+	var nameWidth, typeWidth int
+	for _, f := range fields {
+		nameWidth = max(nameWidth, len(f.Name))
+		typeWidth = max(typeWidth, len(f.Type))
+	}
+	for i := range fields {
+		fields[i].Name += strings.Repeat(" ", nameWidth-len(fields[i].Name))
+		fields[i].Type += strings.Repeat(" ", typeWidth-len(fields[i].Type))
+	}
+	return fields
+}
+
+// RecordValue - значение поля переменной v для goqu.Record
+func (f Field) RecordValue(v string) string {
+	value := v + "." + f.GoName()
+	if f.Type == FieldEnum {
+		value = "string(" + value + ")"
+	}
+	if f.Nullable() {
+		value = "nullable(" + value + ")"
+	}
+	return value
+}
+
+// ProtoValue - значение поля переменной v для proto сообщения
+func (f Field) ProtoValue(v string) string {
+	value := v + "." + f.GoName()
+	switch f.Type {
+	case FieldTime:
+		return value + ".Format(time.RFC3339)"
+	case FieldEnum:
+		return "string(" + value + ")"
+	}
+	return value
+}
+
+// RequestValue - значение поля proto запроса req для домена. Время
+// разбирается отдельно, потому что может вернуть ошибку
+func (f Field) RequestValue(req string) string {
+	value := req + "." + f.ProtoGoName()
+	if f.Type == FieldEnum {
+		return "domain." + f.EnumType() + "(" + value + ")"
+	}
+	return value
+}
+
+// ProtoField - поле proto сообщения с его номером
+type ProtoField struct {
+	Type   string
+	Name   string
+	Number int
+}
+
+// ProtoCreateFields - поля запроса на создание
+func (e Entity) ProtoCreateFields() []ProtoField {
+	return e.protoFields(false, false)
+}
+
+// ProtoUpdateFields - поля запроса на обновление, id идет первым
+func (e Entity) ProtoUpdateFields() []ProtoField {
+	return e.protoFields(true, false)
+}
+
+// ProtoResponseFields - поля ответа вместе с временными метками
+func (e Entity) ProtoResponseFields() []ProtoField {
+	return e.protoFields(true, true)
+}
+
+func (e Entity) protoFields(withID, withTimestamps bool) []ProtoField {
+	var fields []ProtoField
+	add := func(kind, name string) {
+		fields = append(fields, ProtoField{Type: kind, Name: name, Number: len(fields) + 1})
+	}
+	if withID {
+		add("string", "id")
+	}
+	for _, f := range e.Fields {
+		add(f.ProtoType(), f.Column())
+	}
+	if withTimestamps && e.HasTimestamps() {
+		add("string", "created_at")
+		add("string", "updated_at")
+	}
+	return fields
+}
+
+// HasField сообщает, есть ли поле такого типа хотя бы у одной сущности
+func (e Entities) HasField(kind string) bool {
+	for _, entity := range e {
+		if entity.HasField(kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// GraphQLInputValue - значение поля из сгенерированной gqlgen модели input.
+// Необязательные поля приходят указателями
+func (f Field) GraphQLInputValue(input string) string {
+	value := input + "." + f.GoName()
+	if !f.Required {
+		value = "valueOf(" + value + ")"
+	}
+	if f.Type == FieldEnum {
+		value = "domain." + f.EnumType() + "(" + value + ")"
+	}
+	return value
+}
+
+// listParams - параметры запроса списка. Поле с таким же именем
+// нельзя использовать как фильтр, оно бы совпало с параметром
+var listParams = map[string]bool{
+	"limit": true, "offset": true, "page_token": true, "sort": true,
+}
+
+// Filterable сообщает, можно ли фильтровать список по равенству поля.
+// Время и массивы не сравниваются с одним значением из строки запроса
+func (f Field) Filterable() bool {
+	return f.Type != FieldTime && !strings.HasSuffix(f.ColumnType, "[]") && !listParams[f.JSONName()]
+}
+
+// FilterFields - поля фильтра списка
+func (e Entity) FilterFields() []Field {
+	var fields []Field
+	for _, f := range e.Fields {
+		if f.Filterable() {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// FilterStructFields - поля доменной структуры фильтра, выровненные как после gofmt
+func (e Entity) FilterStructFields() []StructField {
+	var fields []StructField
+	for _, f := range e.FilterFields() {
+		fields = append(fields, StructField{Name: f.GoName(), Type: "*" + f.GoType()})
+	}
+	fields = alignStructFields(fields)
+	for i := range fields {
+		fields[i].Type = strings.TrimRight(fields[i].Type, " ")
+	}
+	return fields
+}
+
+// FilterValue переводит необязательное значение фильтра из proto или
+// GraphQL модели в доменный тип: у enum полей там строки
+func (f Field) FilterValue(value string) string {
+	if f.Type == FieldEnum {
+		return "(*domain." + f.EnumType() + ")(" + value + ")"
+	}
+	return value
+}
+
+// SortField - поле, по которому можно сортировать список. Name - имя в API,
+// Type - тип поля, от него зависит сравнение и запись в токен страницы
+type SortField struct {
+	Name   string
+	GoName string
+	Type   string
+}
+
+// SortFields возвращает поля сортировки, первое задает порядок по умолчанию.
+// Nullable поля и NULL в сравнении строк (a, id) > (b, id) теряли бы
+// элементы, а bool и массивы сортировать бессмысленно
+func (e Entity) SortFields() []SortField {
+	var fields []SortField
+	if e.HasTimestamps() {
+		fields = append(fields,
+			SortField{Name: "created_at", GoName: "CreatedAt", Type: FieldTime},
+			SortField{Name: "updated_at", GoName: "UpdatedAt", Type: FieldTime},
+		)
+	}
+	fields = append(fields, SortField{Name: "id", GoName: "ID", Type: FieldString})
+	for _, f := range e.Fields {
+		if f.Nullable() || f.Type == FieldBool || strings.HasSuffix(f.ColumnType, "[]") {
+			continue
+		}
+		fields = append(fields, SortField{Name: f.JSONName(), GoName: f.GoName(), Type: f.Type})
+	}
+	return fields
+}
+
+// SortsBy сообщает, есть ли среди полей сортировки поле такого типа
+func (e Entity) SortsBy(kind string) bool {
+	for _, f := range e.SortFields() {
+		if f.Type == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ProtoListFields - поля запроса List: страница, сортировка и фильтры.
+// Фильтры optional, чтобы отличать пустое значение от незаданного
+func (e Entity) ProtoListFields() []ProtoField {
+	fields := []ProtoField{
+		{Type: "int32", Name: "limit", Number: 1},
+		{Type: "int32", Name: "offset", Number: 2},
+		{Type: "string", Name: "page_token", Number: 3},
+		{Type: "string", Name: "sort", Number: 4},
+	}
+	for _, f := range e.FilterFields() {
+		fields = append(fields, ProtoField{Type: "optional " + f.ProtoType(), Name: f.Column(), Number: len(fields) + 1})
+	}
+	return fields
+}
+
+// varcharPattern - длина строковой колонки импортированной таблицы
+var varcharPattern = regexp.MustCompile(`^(VARCHAR|CHAR)\(([0-9]+)\)$`)
+
+// ValidateTag - правила go-playground/validator для поля. required не ставится
+// на int и bool: 0 и false - допустимые значения
+func (f Field) ValidateTag() string {
+	var rules []string
+	switch f.Type {
+	case FieldUUID:
+		rules = append(rules, "uuid")
+	case FieldEnum:
+		rules = append(rules, "oneof="+strings.Join(f.Values, " "))
+	case FieldString:
+		if f.Column() == "email" {
+			rules = append(rules, "email")
+		}
+		if m := varcharPattern.FindStringSubmatch(f.ColumnType); m != nil {
+			rules = append(rules, "max="+m[2])
+		}
+	}
+
+	switch {
+	case f.Required && f.Type != FieldInt && f.Type != FieldBool:
+		rules = append([]string{"required"}, rules...)
+	case len(rules) > 0:
+		rules = append([]string{"omitempty"}, rules...)
+	}
+	return strings.Join(rules, ",")
+}
+
+// UniqueFields - уникальные поля, которые use case проверяет перед записью
+// через фильтр списка. Остальные уникальные колонки защищает только индекс
+func (e Entity) UniqueFields() []Field {
+	var fields []Field
+	for _, f := range e.FilterFields() {
+		if f.Unique {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
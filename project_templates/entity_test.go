@@ -0,0 +1,17 @@
+package project_templates
+
+import "testing"
+
+func TestValidateRejectsGeneratedMethodNames(t *testing.T) {
+	for _, name := range []string{"sort_value", "SortValue", "normalize"} {
+		entities := Entities{{Name: "product", Fields: []Field{{Name: name, Type: FieldString}}}}
+		if err := entities.Validate(); err == nil {
+			t.Errorf("expected field %q to be rejected", name)
+		}
+	}
+
+	entities := Entities{{Name: "product", Fields: []Field{{Name: "sort_order", Type: FieldInt}}}}
+	if err := entities.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,459 @@
+package project_templates
+
+// Шаблоны для GraphQL API (gqlgen)
+
+const gqlgenYAMLTemplate = `# gqlgen configuration, see https://gqlgen.com/config/
+# Regenerate the executable schema with: make graphql
+
+schema:
+  - internal/delivery/graphql/schema/*.graphqls
+
+exec:
+  filename: internal/delivery/graphql/generated/generated.go
+  package: generated
+
+model:
+  filename: internal/delivery/graphql/model/models_gen.go
+  package: model
+
+resolver:
+  layout: follow-schema
+  dir: internal/delivery/graphql
+  package: graphql
+  filename_template: "{name}.resolvers.go"
+
+models:
+  ID:
+    model:
+      - github.com/99designs/gqlgen/graphql.ID
+  Int:
+    model:
+      - github.com/99designs/gqlgen/graphql.Int64
+  Time:
+    model:
+      - github.com/99designs/gqlgen/graphql.Time
+{{- range .Entities}}
+  {{.GoName}}:
+    model:
+      - {{$.Name}}/internal/domain.{{.GoName}}
+  {{.GoName}}List:
+    model:
+      - {{$.Name}}/internal/domain.{{.GoName}}List
+{{- end}}
+`
+
+// graphqlSchemaTemplate: enum поля отдаются строками, relation поля - ID
+// связанной сущности
+const graphqlSchemaTemplate = `scalar Time
+{{- range .Entities}}
+
+type {{.GoName}} {
+  id: ID!
+{{- range .Fields}}
+  {{.GraphQLName}}: {{.GraphQLType}}!
+{{- end}}
+{{- if .HasTimestamps}}
+  createdAt: Time!
+  updatedAt: Time!
+{{- end}}
+}
+
+input {{.GoName}}Input {
+{{- range .Fields}}
+  {{.GraphQLName}}: {{.GraphQLType}}{{if .Required}}!{{end}}
+{{- end}}
+}
+{{- if .FilterFields}}
+
+input {{.GoName}}Filter {
+{{- range .FilterFields}}
+  {{.GraphQLName}}: {{.GraphQLType}}
+{{- end}}
+}
+{{- end}}
+
+type {{.GoName}}List {
+  items: [{{.GoName}}!]!
+  nextPageToken: String
+}
+{{- end}}
+
+type Query {
+{{- range .Entities}}
+  {{.GraphQLName}}(id: ID!): {{.GoName}}
+  {{.GraphQLPluralName}}({{if .FilterFields}}filter: {{.GoName}}Filter, {{end}}sort: String, limit: Int, offset: Int, pageToken: String): {{.GoName}}List!
+{{- end}}
+}
+
+type Mutation {
+{{- range .Entities}}
+  create{{.GoName}}(input: {{.GoName}}Input!): {{.GoName}}!
+  update{{.GoName}}(id: ID!, input: {{.GoName}}Input!): {{.GoName}}!
+  delete{{.GoName}}(id: ID!): Boolean!
+{{- end}}
+}
+`
+
+const graphqlResolverTemplate = `package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/delivery/graphql/generated"
+	"{{.Name}}/internal/delivery/graphql/loader"
+	"{{.Name}}/internal/domain"
+)
+
+// This file will not be regenerated automatically.
+//
+// It serves as dependency injection for your app, add any dependencies you require here.
+
+// Module provides the GraphQL API and mounts it on the HTTP server
+var Module = fx.Options(
+	fx.Provide(NewResolver),
+	fx.Provide(NewHandler),
+	fx.Invoke(RegisterRoutes),
+)
+
+type Resolver struct {
+{{- range .Entities}}
+	{{.VarName}}UseCase domain.{{.GoName}}UseCase
+{{- end}}
+	logger *zap.Logger
+}
+
+func NewResolver({{range .Entities}}{{.VarName}}UseCase domain.{{.GoName}}UseCase, {{end}}logger *zap.Logger) *Resolver {
+	return &Resolver{
+{{- range .Entities}}
+		{{.VarName}}UseCase: {{.VarName}}UseCase,
+{{- end}}
+		logger: logger,
+	}
+}
+
+// valueOf returns the value of an optional input field, nil becomes the zero value
+func valueOf[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
+// fail reports a use case error. Domain errors keep their message and get
+// the kind in extensions.code, with the offending fields of a validation
+// error or a conflict in extensions.fields; any other error is logged and
+// replaced by message
+func (r *Resolver) fail(ctx context.Context, message string, err error) error {
+	var code string
+	switch {
+	case errors.Is(err, domain.ErrInvalidArgument):
+		code = "BAD_USER_INPUT"
+	case errors.Is(err, domain.ErrUnauthorized):
+		code = "UNAUTHENTICATED"
+	case errors.Is(err, domain.ErrForbidden):
+		code = "FORBIDDEN"
+	case errors.Is(err, domain.ErrNotFound):
+		code = "NOT_FOUND"
+	case errors.Is(err, domain.ErrConflict):
+		code = "CONFLICT"
+	default:
+		r.logger.Error(message, zap.Error(err))
+		return gqlerror.ErrorPathf(graphql.GetPath(ctx), "%s", message)
+	}
+
+	gqlErr := gqlerror.ErrorPathf(graphql.GetPath(ctx), "%s", err.Error())
+	gqlErr.Extensions = map[string]any{"code": code}
+
+	var validationErr *domain.ValidationError
+	var conflictErr *domain.ConflictError
+	switch {
+	case errors.As(err, &validationErr):
+		gqlErr.Extensions["fields"] = validationErr.Fields
+	case errors.As(err, &conflictErr):
+		field := domain.FieldError{Field: conflictErr.Field, Message: "is already taken"}
+		gqlErr.Extensions["fields"] = []domain.FieldError{field}
+	}
+	return gqlErr
+}
+
+// Handler holds the GraphQL endpoint and the playground UI
+type Handler struct {
+	API        http.Handler
+	Playground http.Handler
+}
+
+func NewHandler(resolver *Resolver{{range .Entities}}, {{.VarName}}UseCase domain.{{.GoName}}UseCase{{end}}) *Handler {
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
+
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
+
+	return &Handler{
+		// Loaders are created per request so cached entities never leak between requests
+		API:        loader.Middleware(srv{{range .Entities}}, {{.VarName}}UseCase{{end}}),
+		Playground: playground.Handler("GraphQL playground", "/graphql"),
+	}
+}
+`
+
+const graphqlSchemaResolversTemplate = `package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"errors"
+
+	"{{.Name}}/internal/delivery/graphql/generated"
+	"{{.Name}}/internal/delivery/graphql/loader"
+	"{{.Name}}/internal/delivery/graphql/model"
+	"{{.Name}}/internal/domain"
+)
+
+{{- range .Entities}}
+{{$entity := .}}
+// Create{{.GoName}} is the resolver for the create{{.GoName}} field.
+func (r *mutationResolver) Create{{.GoName}}(ctx context.Context, input model.{{.GoName}}Input) (*domain.{{.GoName}}, error) {
+	{{.VarName}} := &domain.{{.GoName}}{
+{{- range .Fields}}
+		{{.GoName}}: {{.GraphQLInputValue "input"}},
+{{- end}}
+	}
+
+	if err := r.{{.VarName}}UseCase.Create(ctx, {{.VarName}}); err != nil {
+		return nil, r.fail(ctx, "failed to create {{.Label}}", err)
+	}
+
+	return {{.VarName}}, nil
+}
+
+// Update{{.GoName}} is the resolver for the update{{.GoName}} field.
+func (r *mutationResolver) Update{{.GoName}}(ctx context.Context, id string, input model.{{.GoName}}Input) (*domain.{{.GoName}}, error) {
+	{{.VarName}}, err := r.{{.VarName}}UseCase.GetByID(ctx, id)
+	if err != nil {
+		return nil, r.fail(ctx, "failed to update {{.Label}}", err)
+	}
+{{range .Fields}}
+	{{$entity.VarName}}.{{.GoName}} = {{.GraphQLInputValue "input"}}
+{{- end}}
+
+	if err := r.{{.VarName}}UseCase.Update(ctx, {{.VarName}}); err != nil {
+		return nil, r.fail(ctx, "failed to update {{.Label}}", err)
+	}
+
+	return {{.VarName}}, nil
+}
+
+// Delete{{.GoName}} is the resolver for the delete{{.GoName}} field.
+func (r *mutationResolver) Delete{{.GoName}}(ctx context.Context, id string) (bool, error) {
+	if err := r.{{.VarName}}UseCase.Delete(ctx, id); err != nil {
+		return false, r.fail(ctx, "failed to delete {{.Label}}", err)
+	}
+
+	return true, nil
+}
+{{- end}}
+{{range .Entities}}
+// {{.GoName}} is the resolver for the {{.GraphQLName}} field.
+func (r *queryResolver) {{.GoName}}(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	// Goes through the dataloader: lookups of the same {{.Label}} within one request hit the use case once
+	{{.VarName}}, err := loader.Get{{.GoName}}(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail(ctx, "failed to get {{.Label}}", err)
+	}
+
+	return {{.VarName}}, nil
+}
+
+// {{.PluralGoName}} is the resolver for the {{.GraphQLPluralName}} field.
+func (r *queryResolver) {{.PluralGoName}}(ctx context.Context, {{if .FilterFields}}filter *model.{{.GoName}}Filter, {{end}}sort *string, limit *int64, offset *int64, pageToken *string) (*domain.{{.GoName}}List, error) {
+	query := domain.{{.GoName}}ListQuery{
+		Sort: domain.ParseSortOrder(valueOf(sort)),
+		Pagination: domain.Pagination{
+			Limit:     int(valueOf(limit)),
+			Offset:    int(valueOf(offset)),
+			PageToken: valueOf(pageToken),
+		},
+	}
+{{- if .FilterFields}}
+	if filter != nil {
+		query.Filter = domain.{{.GoName}}Filter{
+{{- range .FilterFields}}
+			{{.GoName}}: {{.FilterValue (print "filter." .GoName)}},
+{{- end}}
+		}
+	}
+{{- end}}
+
+	result, err := r.{{.VarName}}UseCase.List(ctx, query)
+	if err != nil {
+		return nil, r.fail(ctx, "failed to list {{.PluralLabel}}", err)
+	}
+
+	return result, nil
+}
+{{end}}
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
+// Query returns generated.QueryResolver implementation.
+func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+`
+
+const graphqlLoaderTemplate = `package loader
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/vikstrous/dataloadgen"
+
+	"{{.Name}}/internal/domain"
+)
+
+type ctxKey struct{}
+
+// Loaders batches and caches lookups made while resolving a single request
+type Loaders struct {
+{{- range .Entities}}
+	{{.GoName}}ByID *dataloadgen.Loader[string, *domain.{{.GoName}}]
+{{- end}}
+}
+
+func NewLoaders({{range $i, $e := .Entities}}{{if $i}}, {{end}}{{.VarName}}UseCase domain.{{.GoName}}UseCase{{end}}) *Loaders {
+	return &Loaders{
+{{- range .Entities}}
+		{{.GoName}}ByID: dataloadgen.NewLoader({{.VarName}}Fetcher({{.VarName}}UseCase), dataloadgen.WithWait(time.Millisecond)),
+{{- end}}
+	}
+}
+{{range .Entities}}
+// {{.VarName}}Fetcher resolves a batch of distinct IDs (the loader dedupes keys).
+// The use case has no bulk lookup yet, so IDs are fetched one by one;
+// swap the loop for a single "WHERE id = ANY($1)" query when the repository grows one
+func {{.VarName}}Fetcher(useCase domain.{{.GoName}}UseCase) func(ctx context.Context, ids []string) ([]*domain.{{.GoName}}, []error) {
+	return func(ctx context.Context, ids []string) ([]*domain.{{.GoName}}, []error) {
+		{{.PluralVarName}} := make([]*domain.{{.GoName}}, len(ids))
+		errs := make([]error, len(ids))
+		for i, id := range ids {
+			{{.PluralVarName}}[i], errs[i] = useCase.GetByID(ctx, id)
+		}
+		return {{.PluralVarName}}, errs
+	}
+}
+{{end}}
+// Middleware attaches fresh loaders to every request context
+func Middleware(next http.Handler{{range .Entities}}, {{.VarName}}UseCase domain.{{.GoName}}UseCase{{end}}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ctxKey{}, NewLoaders({{range $i, $e := .Entities}}{{if $i}}, {{end}}{{.VarName}}UseCase{{end}}))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// For returns the loaders attached by Middleware
+func For(ctx context.Context) *Loaders {
+	return ctx.Value(ctxKey{}).(*Loaders)
+}
+
+{{- range .Entities}}
+
+// Get{{.GoName}} loads a {{.Label}} through the request scoped dataloader, a missing one is domain.ErrNotFound
+func Get{{.GoName}}(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	return For(ctx).{{.GoName}}ByID.Load(ctx, id)
+}
+{{- end}}
+`
+
+const graphqlRoutesTemplate = `package graphql
+{{if eq .HTTPFramework "chi"}}
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes mounts the GraphQL endpoint and the playground
+func RegisterRoutes(server *chi.Mux, h *Handler) {
+	server.Handle("/graphql", h.API)
+	server.Get("/playground", h.Playground.ServeHTTP)
+}
+{{else if eq .HTTPFramework "gin"}}
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes mounts the GraphQL endpoint and the playground
+func RegisterRoutes(server *gin.Engine, h *Handler) {
+	server.Any("/graphql", gin.WrapH(h.API))
+	server.GET("/playground", gin.WrapH(h.Playground))
+}
+{{else if eq .HTTPFramework "fiber"}}
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// RegisterRoutes mounts the GraphQL endpoint and the playground
+func RegisterRoutes(server *fiber.App, h *Handler) {
+	server.All("/graphql", adaptor.HTTPHandler(h.API))
+	server.Get("/playground", adaptor.HTTPHandler(h.Playground))
+}
+{{else if eq .HTTPFramework "servemux"}}
+import (
+	"net/http"
+)
+
+// RegisterRoutes mounts the GraphQL endpoint and the playground
+func RegisterRoutes(server *http.ServeMux, h *Handler) {
+	server.Handle("/graphql", h.API)
+	server.Handle("GET /playground", h.Playground)
+}
+{{else}}
+import (
+	"github.com/labstack/echo/v4"
+{{- if .HasDependency "auth"}}
+
+	"{{.Name}}/internal/auth"
+{{- end}}
+)
+
+// RegisterRoutes mounts the GraphQL endpoint and the playground
+{{- if .HasDependency "auth"}}
+// The playground page is public, queries need a bearer token
+func RegisterRoutes(server *echo.Echo, h *Handler, authenticator *auth.Authenticator) {
+	server.Any("/graphql", echo.WrapHandler(h.API), authenticator.Middleware())
+{{- else}}
+func RegisterRoutes(server *echo.Echo, h *Handler) {
+	server.Any("/graphql", echo.WrapHandler(h.API))
+{{- end}}
+	server.GET("/playground", echo.WrapHandler(h.Playground))
+}
+{{end}}`
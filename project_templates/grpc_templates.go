@@ -3,234 +3,1080 @@ package project_templates
 const grpcServerTemplate = `package grpc
 
 import (
-	"context"
-	"fmt"
-	"net"
+{{- if .Entities.HasField "time"}}
+	"time"
 	
-	"go.uber.org/fx"
-	"go.uber.org/zap"
+{{end}}	"go.uber.org/fx"
 	"google.golang.org/grpc"
-	
-	"{{.Name}}/internal/config"
 )
 
 
-type GRPCConfig struct {
-	Port int
+var Module = fx.Options(
+	fx.Provide(
+{{- range .Entities}}
+		New{{.GoName}}Service,
+{{- end}}
+	),
+	fx.Invoke(RegisterServices),
+)
+
+
+// RegisterServices registers every gRPC service on the server created in bootstrap
+func RegisterServices(server *grpc.Server{{range .Entities}}, {{.VarName}}Service *{{.GoName}}Service{{end}}) {
+{{- range .Entities}}
+	Register{{.GoName}}ServiceServer(server, {{.VarName}}Service)
+{{- end}}
 }
+{{- if .Entities.HasField "time"}}
 
 
-func NewGRPCConfig(cfg *config.Config) *GRPCConfig {
-	return &GRPCConfig{
-		Port: cfg.GetEnvAsInt("GRPC_PORT", 9090),
+// parseTime reads timestamps sent as RFC 3339 strings, empty string is the zero time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
 	}
+	return time.Parse(time.RFC3339, value)
 }
+{{- end}}
+`
 
+const grpcErrorsTemplate = `package grpc
 
-func NewGRPCServer(lc fx.Lifecycle, cfg *GRPCConfig, logger *zap.Logger, userService *UserService) *grpc.Server {
-	server := grpc.NewServer()
-	
+import (
+	"errors"
 	
-	RegisterUserServiceServer(server, userService)
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	
-	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			addr := fmt.Sprintf(":%d", cfg.Port)
-			listener, err := net.Listen("tcp", addr)
-			if err != nil {
-				return err
-			}
-			
-			logger.Info("Starting gRPC server", zap.String("addr", addr))
-			
-			go func() {
-				if err := server.Serve(listener); err != nil {
-					logger.Error("Failed to start gRPC server", zap.Error(err))
-				}
-			}()
-			
-			return nil
-		},
-		OnStop: func(ctx context.Context) error {
-			logger.Info("Stopping gRPC server")
-			server.GracefulStop()
-			return nil
-		},
-	})
+	"{{.Name}}/internal/domain"
+)
+
+
+// rpcError переводит ошибку use case в gRPC статус по ее виду из domain.
+// Остальные ошибки логируются и возвращаются как Internal без подробностей
+func rpcError(logger *zap.Logger, method string, err error) error {
+	var code codes.Code
+	switch {
+{{- if .Proto}}
+	case errors.Is(err, domain.ErrNotImplemented):
+		return status.Error(codes.Unimplemented, method+" is not implemented")
+{{- end}}
+	case errors.Is(err, domain.ErrInvalidArgument):
+		code = codes.InvalidArgument
+	case errors.Is(err, domain.ErrUnauthorized):
+		code = codes.Unauthenticated
+	case errors.Is(err, domain.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, domain.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, domain.ErrConflict):
+		code = codes.AlreadyExists
+	default:
+		logger.Error("RPC failed", zap.String("method", method), zap.Error(err))
+		return status.Error(codes.Internal, "Internal error")
+	}
 	
-	return server
+	st := status.New(code, err.Error())
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
 }
 `
 
-const grpcUserServiceTemplate = `package grpc
+const entityGRPCServiceTemplate = `package grpc
 
 import (
 	"context"
+{{- if or .Entity.HasTimestamps .Entity.HasTime}}
+	"time"
+{{- end}}
 	
 	"go.uber.org/zap"
+{{- if .Entity.HasTime}}
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+{{- end}}
 	
 	"{{.Name}}/internal/domain"
 )
+{{- with .Entity}}
+{{- $entity := .}}
 
 
-type UserService struct {
-	UnimplementedUserServiceServer
-	useCase domain.UserUseCase
+type {{.GoName}}Service struct {
+	Unimplemented{{.GoName}}ServiceServer
+	useCase domain.{{.GoName}}UseCase
 	logger  *zap.Logger
 }
 
 
-func NewUserService(useCase domain.UserUseCase, logger *zap.Logger) *UserService {
-	return &UserService{
+func New{{.GoName}}Service(useCase domain.{{.GoName}}UseCase, logger *zap.Logger) *{{.GoName}}Service {
+	return &{{.GoName}}Service{
 		useCase: useCase,
 		logger:  logger,
 	}
 }
 
 
-func (s *UserService) CreateUser(ctx context.Context, req *CreateUserRequest) (*UserResponse, error) {
-	user := &domain.User{
-		Username: req.Username,
-		Email:    req.Email,
+func (s *{{.GoName}}Service) Create{{.GoName}}(ctx context.Context, req *Create{{.GoName}}Request) (*{{.GoName}}Response, error) {
+	{{.VarName}} := &domain.{{.GoName}}{
+{{- range .Fields}}{{if ne .Type "time"}}
+		{{.GoName}}: {{.RequestValue "req"}},
+{{- end}}{{end}}
 	}
+{{- if .HasTime}}
 	
-	if err := s.useCase.Create(user); err != nil {
-		s.logger.Error("Failed to create user", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Failed to create user")
+	var err error
+{{- range .Fields}}{{if eq .Type "time"}}
+	if {{$entity.VarName}}.{{.GoName}}, err = parseTime(req.{{.ProtoGoName}}); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid {{.Column}}")
 	}
+{{- end}}{{end}}
+{{- end}}
 	
-	return &UserResponse{
-		Id:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-	}, nil
+	if err := s.useCase.Create(ctx, {{.VarName}}); err != nil {
+		return nil, rpcError(s.logger, "{{.GoName}}Service.Create{{.GoName}}", err)
+	}
+	
+	return to{{.GoName}}Response({{.VarName}}), nil
 }
 
 
-func (s *UserService) GetUser(ctx context.Context, req *GetUserRequest) (*UserResponse, error) {
-	user, err := s.useCase.GetByID(req.Id)
+func (s *{{.GoName}}Service) Get{{.GoName}}(ctx context.Context, req *Get{{.GoName}}Request) (*{{.GoName}}Response, error) {
+	{{.VarName}}, err := s.useCase.GetByID(ctx, req.Id)
 	if err != nil {
-		s.logger.Error("Failed to get user", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Failed to get user")
-	}
-	
-	if user == nil {
-		return nil, status.Error(codes.NotFound, "User not found")
+		return nil, rpcError(s.logger, "{{.GoName}}Service.Get{{.GoName}}", err)
 	}
 	
-	return &UserResponse{
-		Id:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-	}, nil
+	return to{{.GoName}}Response({{.VarName}}), nil
 }
 
 
-func (s *UserService) ListUsers(ctx context.Context, req *ListUsersRequest) (*ListUsersResponse, error) {
-	users, err := s.useCase.List()
+func (s *{{.GoName}}Service) List{{.PluralGoName}}(ctx context.Context, req *List{{.PluralGoName}}Request) (*List{{.PluralGoName}}Response, error) {
+	query := domain.{{.GoName}}ListQuery{
+		Filter: domain.{{.GoName}}Filter{
+{{- range .FilterFields}}
+			{{.GoName}}: {{.FilterValue (print "req." .ProtoGoName)}},
+{{- end}}
+		},
+		Sort: domain.ParseSortOrder(req.Sort),
+		Pagination: domain.Pagination{
+			Limit:     int(req.Limit),
+			Offset:    int(req.Offset),
+			PageToken: req.PageToken,
+		},
+	}
+	
+	result, err := s.useCase.List(ctx, query)
 	if err != nil {
-		s.logger.Error("Failed to list users", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Failed to list users")
+		return nil, rpcError(s.logger, "{{.GoName}}Service.List{{.PluralGoName}}", err)
 	}
 	
-	response := &ListUsersResponse{
-		Users: make([]*UserResponse, 0, len(users)),
+	response := &List{{.PluralGoName}}Response{
+		Items:         make([]*{{.GoName}}Response, 0, len(result.Items)),
+		NextPageToken: result.NextPageToken,
 	}
 	
-	for _, user := range users {
-		response.Users = append(response.Users, &UserResponse{
-			Id:       user.ID,
-			Username: user.Username,
-			Email:    user.Email,
-		})
+	for _, {{.VarName}} := range result.Items {
+		response.Items = append(response.Items, to{{.GoName}}Response({{.VarName}}))
 	}
 	
 	return response, nil
 }
 
 
-func (s *UserService) UpdateUser(ctx context.Context, req *UpdateUserRequest) (*UserResponse, error) {
-	user := &domain.User{
-		ID:       req.Id,
-		Username: req.Username,
-		Email:    req.Email,
+func (s *{{.GoName}}Service) Update{{.GoName}}(ctx context.Context, req *Update{{.GoName}}Request) (*{{.GoName}}Response, error) {
+	{{.VarName}} := &domain.{{.GoName}}{
+		ID: req.Id,
+{{- range .Fields}}{{if ne .Type "time"}}
+		{{.GoName}}: {{.RequestValue "req"}},
+{{- end}}{{end}}
 	}
+{{- if .HasTime}}
 	
-	if err := s.useCase.Update(user); err != nil {
-		s.logger.Error("Failed to update user", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Failed to update user")
+	var err error
+{{- range .Fields}}{{if eq .Type "time"}}
+	if {{$entity.VarName}}.{{.GoName}}, err = parseTime(req.{{.ProtoGoName}}); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid {{.Column}}")
 	}
+{{- end}}{{end}}
+{{- end}}
 	
-	return &UserResponse{
-		Id:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-	}, nil
+	if err := s.useCase.Update(ctx, {{.VarName}}); err != nil {
+		return nil, rpcError(s.logger, "{{.GoName}}Service.Update{{.GoName}}", err)
+	}
+	
+	return to{{.GoName}}Response({{.VarName}}), nil
 }
 
 
-func (s *UserService) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*DeleteUserResponse, error) {
-	if err := s.useCase.Delete(req.Id); err != nil {
-		s.logger.Error("Failed to delete user", zap.Error(err))
-		return nil, status.Error(codes.Internal, "Failed to delete user")
+func (s *{{.GoName}}Service) Delete{{.GoName}}(ctx context.Context, req *Delete{{.GoName}}Request) (*Delete{{.GoName}}Response, error) {
+	if err := s.useCase.Delete(ctx, req.Id); err != nil {
+		return nil, rpcError(s.logger, "{{.GoName}}Service.Delete{{.GoName}}", err)
 	}
 	
-	return &DeleteUserResponse{Success: true}, nil
+	return &Delete{{.GoName}}Response{Success: true}, nil
+}
+
+
+func to{{.GoName}}Response({{.VarName}} *domain.{{.GoName}}) *{{.GoName}}Response {
+	return &{{.GoName}}Response{
+		Id: {{.VarName}}.ID,
+{{- range .Fields}}
+		{{.ProtoGoName}}: {{.ProtoValue $entity.VarName}},
+{{- end}}
+{{- if .HasTimestamps}}
+		CreatedAt: {{.VarName}}.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: {{.VarName}}.UpdatedAt.Format(time.RFC3339),
+{{- end}}
+	}
 }
+{{- end}}
 `
 
-const userProtoTemplate = `syntax = "proto3";
+// entityProtoTemplate - .proto сервиса одной сущности. Каждая сущность
+// живет в своем proto пакете, Go код попадает в общий пакет grpc
+const entityProtoTemplate = `syntax = "proto3";
+
+package {{.Entity.Snake}};
+{{- if .HasGateway}}
 
-package user;
+import "google/api/annotations.proto";
+{{- end}}
 
-option go_package = "internal/delivery/grpc";
+option go_package = "{{.Name}}/internal/delivery/grpc";
+{{- $gateway := .HasGateway}}
+{{- with .Entity}}
 
-service UserService {
-  rpc CreateUser(CreateUserRequest) returns (UserResponse) {}
-  rpc GetUser(GetUserRequest) returns (UserResponse) {}
-  rpc ListUsers(ListUsersRequest) returns (ListUsersResponse) {}
-  rpc UpdateUser(UpdateUserRequest) returns (UserResponse) {}
-  rpc DeleteUser(DeleteUserRequest) returns (DeleteUserResponse) {}
+service {{.GoName}}Service {
+{{- if $gateway}}
+  rpc Create{{.GoName}}(Create{{.GoName}}Request) returns ({{.GoName}}Response) {
+    option (google.api.http) = {
+      post: "/api/{{.Route}}"
+      body: "*"
+    };
+  }
+  rpc Get{{.GoName}}(Get{{.GoName}}Request) returns ({{.GoName}}Response) {
+    option (google.api.http) = {get: "/api/{{.Route}}/{id}"};
+  }
+  rpc List{{.PluralGoName}}(List{{.PluralGoName}}Request) returns (List{{.PluralGoName}}Response) {
+    option (google.api.http) = {get: "/api/{{.Route}}"};
+  }
+  rpc Update{{.GoName}}(Update{{.GoName}}Request) returns ({{.GoName}}Response) {
+    option (google.api.http) = {
+      put: "/api/{{.Route}}/{id}"
+      body: "*"
+    };
+  }
+  rpc Delete{{.GoName}}(Delete{{.GoName}}Request) returns (Delete{{.GoName}}Response) {
+    option (google.api.http) = {delete: "/api/{{.Route}}/{id}"};
+  }
+{{- else}}
+  rpc Create{{.GoName}}(Create{{.GoName}}Request) returns ({{.GoName}}Response) {}
+  rpc Get{{.GoName}}(Get{{.GoName}}Request) returns ({{.GoName}}Response) {}
+  rpc List{{.PluralGoName}}(List{{.PluralGoName}}Request) returns (List{{.PluralGoName}}Response) {}
+  rpc Update{{.GoName}}(Update{{.GoName}}Request) returns ({{.GoName}}Response) {}
+  rpc Delete{{.GoName}}(Delete{{.GoName}}Request) returns (Delete{{.GoName}}Response) {}
+{{- end}}
 }
 
-message CreateUserRequest {
-  string username = 1;
-  string email = 2;
+message Create{{.GoName}}Request {
+{{- range .ProtoCreateFields}}
+  {{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
 }
 
-message GetUserRequest {
+message Get{{.GoName}}Request {
   string id = 1;
 }
 
-message UserResponse {
-  string id = 1;
-  string username = 2;
-  string email = 3;
-  string created_at = 4;
-  string updated_at = 5;
+message {{.GoName}}Response {
+{{- range .ProtoResponseFields}}
+  {{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
 }
 
-message ListUsersRequest {
-  
+// Page size is limited to 100, the default is 20. page_token from the
+// previous response continues the list, sort is a field name, "-" prefix
+// sorts in descending order. Set filters keep items with equal fields
+message List{{.PluralGoName}}Request {
+{{- range .ProtoListFields}}
+  {{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
 }
 
-message ListUsersResponse {
-  repeated UserResponse users = 1;
+message List{{.PluralGoName}}Response {
+  repeated {{.GoName}}Response items = 1;
+  string next_page_token = 2;
 }
 
-message UpdateUserRequest {
-  string id = 1;
-  string username = 2;
-  string email = 3;
+message Update{{.GoName}}Request {
+{{- range .ProtoUpdateFields}}
+  {{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
 }
 
-message DeleteUserRequest {
+message Delete{{.GoName}}Request {
   string id = 1;
 }
 
-message DeleteUserResponse {
+message Delete{{.GoName}}Response {
   bool success = 1;
 }
+{{- end}}
+`
+
+// grpcStubTemplate повторяет вывод protoc-gen-go-grpc v1.3.0 для unary RPC.
+// Выполняется над *protogen.File, импорты расставляет protogen
+const grpcStubTemplate = `// Code generated by Golang Initializr from {{.Desc.Path}}. DO NOT EDIT.
+// Regenerate with: make proto
+
+package {{.GoPackageName}}
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = {{ident "google.golang.org/grpc" "SupportPackageIsVersion7"}}
+{{range $service := .Services}}
+{{- $name := $service.GoName}}
+const (
+{{- range .Methods}}
+	{{$name}}_{{.GoName}}_FullMethodName = "/{{$service.Desc.FullName}}/{{.Desc.Name}}"
+{{- end}}
+)
+
+// {{$name}}Client is the client API for {{$name}} service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type {{$name}}Client interface {
+{{- range .Methods}}
+{{- with .Comments.Leading}}
+{{comments .}}
+{{- end}}
+	{{.GoName}}(ctx {{ident "context" "Context"}}, in *{{qualified .Input.GoIdent}}, opts ...{{ident "google.golang.org/grpc" "CallOption"}}) (*{{qualified .Output.GoIdent}}, error)
+{{- end}}
+}
+
+type {{unexport $name}}Client struct {
+	cc {{ident "google.golang.org/grpc" "ClientConnInterface"}}
+}
+
+func New{{$name}}Client(cc {{ident "google.golang.org/grpc" "ClientConnInterface"}}) {{$name}}Client {
+	return &{{unexport $name}}Client{cc}
+}
+{{range .Methods}}
+func (c *{{unexport $name}}Client) {{.GoName}}(ctx {{ident "context" "Context"}}, in *{{qualified .Input.GoIdent}}, opts ...{{ident "google.golang.org/grpc" "CallOption"}}) (*{{qualified .Output.GoIdent}}, error) {
+	out := new({{qualified .Output.GoIdent}})
+	err := c.cc.Invoke(ctx, {{$name}}_{{.GoName}}_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+{{end}}
+// {{$name}}Server is the server API for {{$name}} service.
+// All implementations must embed Unimplemented{{$name}}Server
+// for forward compatibility
+type {{$name}}Server interface {
+{{- range .Methods}}
+{{- with .Comments.Leading}}
+{{comments .}}
+{{- end}}
+	{{.GoName}}({{ident "context" "Context"}}, *{{qualified .Input.GoIdent}}) (*{{qualified .Output.GoIdent}}, error)
+{{- end}}
+	mustEmbedUnimplemented{{$name}}Server()
+}
+
+// Unimplemented{{$name}}Server must be embedded to have forward compatible implementations.
+type Unimplemented{{$name}}Server struct {
+}
+{{range .Methods}}
+func (Unimplemented{{$name}}Server) {{.GoName}}({{ident "context" "Context"}}, *{{qualified .Input.GoIdent}}) (*{{qualified .Output.GoIdent}}, error) {
+	return nil, {{ident "google.golang.org/grpc/status" "Errorf"}}({{ident "google.golang.org/grpc/codes" "Unimplemented"}}, "method {{.GoName}} not implemented")
+}
+{{- end}}
+func (Unimplemented{{$name}}Server) mustEmbedUnimplemented{{$name}}Server() {}
+
+// Unsafe{{$name}}Server may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to {{$name}}Server will
+// result in compilation errors.
+type Unsafe{{$name}}Server interface {
+	mustEmbedUnimplemented{{$name}}Server()
+}
+
+func Register{{$name}}Server(s {{ident "google.golang.org/grpc" "ServiceRegistrar"}}, srv {{$name}}Server) {
+	s.RegisterService(&{{$name}}_ServiceDesc, srv)
+}
+{{range .Methods}}
+func _{{$name}}_{{.GoName}}_Handler(srv interface{}, ctx {{ident "context" "Context"}}, dec func(interface{}) error, interceptor {{ident "google.golang.org/grpc" "UnaryServerInterceptor"}}) (interface{}, error) {
+	in := new({{qualified .Input.GoIdent}})
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.({{$name}}Server).{{.GoName}}(ctx, in)
+	}
+	info := &{{ident "google.golang.org/grpc" "UnaryServerInfo"}}{
+		Server:     srv,
+		FullMethod: {{$name}}_{{.GoName}}_FullMethodName,
+	}
+	handler := func(ctx {{ident "context" "Context"}}, req interface{}) (interface{}, error) {
+		return srv.({{$name}}Server).{{.GoName}}(ctx, req.(*{{qualified .Input.GoIdent}}))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+{{end}}
+// {{$name}}_ServiceDesc is the grpc.ServiceDesc for {{$name}} service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var {{$name}}_ServiceDesc = {{ident "google.golang.org/grpc" "ServiceDesc"}}{
+	ServiceName: "{{$service.Desc.FullName}}",
+	HandlerType: (*{{$name}}Server)(nil),
+	Methods: []{{ident "google.golang.org/grpc" "MethodDesc"}}{
+{{- range .Methods}}
+		{
+			MethodName: "{{.Desc.Name}}",
+			Handler:    _{{$name}}_{{.GoName}}_Handler,
+		},
+{{- end}}
+	},
+	Streams:  []{{ident "google.golang.org/grpc" "StreamDesc"}}{},
+	Metadata: "{{$.Desc.Path}}",
+}
+{{end}}`
+
+// buf.yaml: модуль с корнем в api/proto, чтобы пути в дескрипторах
+// совпадали с теми, что зашиты в сгенерированный код
+const bufYAMLTemplate = `version: v2
+modules:
+  - path: api/proto
+{{- if .HasGoogleAPIs}}
+deps:
+  - buf.build/googleapis/googleapis
+{{- end}}
+lint:
+  use:
+    - STANDARD
+  except:
+    - PACKAGE_DIRECTORY_MATCH
+    - PACKAGE_VERSION_SUFFIX
+    - RPC_REQUEST_RESPONSE_UNIQUE
+    - RPC_RESPONSE_STANDARD_NAME
+breaking:
+  use:
+    - FILE
+`
+
+const bufGenYAMLTemplate = `version: v2
+{{- define "opt"}}
+{{- if .Proto}}
+    opt:
+      - module={{.Name}}/internal/delivery/grpc
+{{- range .Proto.Paths}}
+      - M{{.}}={{$.Name}}/internal/delivery/grpc;grpc
+{{- end}}
+{{- else}}
+    opt: paths=source_relative
+{{- end}}
+{{- end}}
+plugins:
+  - remote: buf.build/protocolbuffers/go:v1.36.5
+    out: internal/delivery/grpc
+{{- template "opt" .}}
+  - remote: buf.build/grpc/go:v1.3.0
+    out: internal/delivery/grpc
+{{- template "opt" .}}
+{{- if .HasGateway}}
+  - remote: buf.build/grpc-ecosystem/gateway:v2.19.1
+    out: internal/delivery/grpc
+    opt: paths=source_relative
+{{- end}}
+`
+
+// grpcGatewayStubTemplate повторяет вывод protoc-gen-grpc-gateway v2 для
+// unary RPC с google.api.http правилами. Выполняется над []gatewayService
+const grpcGatewayStubTemplate = `// Code generated by Golang Initializr from {{.File.Desc.Path}}. DO NOT EDIT.
+// Regenerate with: make proto
+
+package {{.File.GoPackageName}}
+
+// Suppress "imported and not used" errors
+var _ {{ident "google.golang.org/grpc/codes" "Code"}}
+var _ {{ident "io" "Reader"}}
+var _ {{ident "google.golang.org/grpc/status" "Status"}}
+var _ = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "String"}}
+var _ = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/utilities" "NewDoubleArray"}}
+var _ = {{ident "google.golang.org/grpc/metadata" "Join"}}
+{{- define "request"}}
+	var protoReq {{qualified .Method.Input.GoIdent}}
+	var metadata {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ServerMetadata"}}
+{{- if eq .Body "*"}}
+
+	if err := marshaler.NewDecoder(req.Body).Decode(&protoReq); err != nil && err != {{ident "io" "EOF"}} {
+		return nil, metadata, {{ident "google.golang.org/grpc/status" "Errorf"}}({{ident "google.golang.org/grpc/codes" "InvalidArgument"}}, "%v", err)
+	}
+{{- end}}
+{{- if .PathParams}}
+
+	var (
+		val string
+		ok  bool
+		err error
+		_   = err
+	)
+{{- range .PathParams}}
+
+	val, ok = pathParams["{{.Name}}"]
+	if !ok {
+		return nil, metadata, {{ident "google.golang.org/grpc/status" "Errorf"}}({{ident "google.golang.org/grpc/codes" "InvalidArgument"}}, "missing parameter %s", "{{.Name}}")
+	}
+
+	protoReq.{{.GoName}}, err = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" .Convert}}(val)
+	if err != nil {
+		return nil, metadata, {{ident "google.golang.org/grpc/status" "Errorf"}}({{ident "google.golang.org/grpc/codes" "InvalidArgument"}}, "type mismatch, parameter: %s, error: %v", "{{.Name}}", err)
+	}
+{{- end}}
+{{- end}}
+{{- if ne .Body "*"}}
+
+	if err := req.ParseForm(); err != nil {
+		return nil, metadata, {{ident "google.golang.org/grpc/status" "Errorf"}}({{ident "google.golang.org/grpc/codes" "InvalidArgument"}}, "%v", err)
+	}
+	if err := {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "PopulateQueryParameters"}}(&protoReq, req.Form, filter_{{.Service.GoName}}_{{.Method.GoName}}_0); err != nil {
+		return nil, metadata, {{ident "google.golang.org/grpc/status" "Errorf"}}({{ident "google.golang.org/grpc/codes" "InvalidArgument"}}, "%v", err)
+	}
+{{- end}}
+{{- end}}
+{{- range $service := .Services}}
+{{- range .Methods}}
+{{- if ne .Body "*"}}
+
+var (
+	filter_{{$service.GoName}}_{{.Method.GoName}}_0 = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/utilities" "NewDoubleArray"}}([][]string{ {{- range .PathParams}}{"{{.Name}}"}, {{end -}} })
+)
+{{- end}}
+
+func request_{{$service.GoName}}_{{.Method.GoName}}_0(ctx {{ident "context" "Context"}}, marshaler {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "Marshaler"}}, client {{$service.GoName}}Client, req *{{ident "net/http" "Request"}}, pathParams map[string]string) ({{ident "google.golang.org/protobuf/proto" "Message"}}, {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ServerMetadata"}}, error) {
+{{- template "request" .}}
+
+	msg, err := client.{{.Method.GoName}}(ctx, &protoReq, {{ident "google.golang.org/grpc" "Header"}}(&metadata.HeaderMD), {{ident "google.golang.org/grpc" "Trailer"}}(&metadata.TrailerMD))
+	return msg, metadata, err
+}
+
+func local_request_{{$service.GoName}}_{{.Method.GoName}}_0(ctx {{ident "context" "Context"}}, marshaler {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "Marshaler"}}, server {{$service.GoName}}Server, req *{{ident "net/http" "Request"}}, pathParams map[string]string) ({{ident "google.golang.org/protobuf/proto" "Message"}}, {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ServerMetadata"}}, error) {
+{{- template "request" .}}
+
+	msg, err := server.{{.Method.GoName}}(ctx, &protoReq)
+	return msg, metadata, err
+}
+{{- end}}
+
+// Register{{.GoName}}HandlerServer registers the http handlers for service {{.GoName}} to "mux".
+// UnaryRPC     :call {{.GoName}}Server directly.
+// StreamingRPC :currently unsupported pending https://github.com/grpc/grpc-go/issues/906.
+// Note that using this registration option will cause many gRPC library features to stop working. Consider using Register{{.GoName}}HandlerFromEndpoint instead.
+func Register{{.GoName}}HandlerServer(ctx {{ident "context" "Context"}}, mux *{{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ServeMux"}}, server {{.GoName}}Server) error {
+{{- range .Methods}}
+
+	mux.Handle("{{.HTTPMethod}}", pattern_{{$service.GoName}}_{{.Method.GoName}}_0, func(w {{ident "net/http" "ResponseWriter"}}, req *{{ident "net/http" "Request"}}, pathParams map[string]string) {
+		ctx, cancel := {{ident "context" "WithCancel"}}(req.Context())
+		defer cancel()
+		var stream {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ServerTransportStream"}}
+		ctx = {{ident "google.golang.org/grpc" "NewContextWithServerTransportStream"}}(ctx, &stream)
+		inboundMarshaler, outboundMarshaler := {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "MarshalerForRequest"}}(mux, req)
+		var err error
+		var annotatedContext {{ident "context" "Context"}}
+		annotatedContext, err = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "AnnotateIncomingContext"}}(ctx, mux, req, "/{{$service.Desc.FullName}}/{{.Method.Desc.Name}}", {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "WithHTTPPathPattern"}}("{{.Path}}"))
+		if err != nil {
+			{{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "HTTPError"}}(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		resp, md, err := local_request_{{$service.GoName}}_{{.Method.GoName}}_0(annotatedContext, inboundMarshaler, server, req, pathParams)
+		md.HeaderMD, md.TrailerMD = {{ident "google.golang.org/grpc/metadata" "Join"}}(md.HeaderMD, stream.Header()), {{ident "google.golang.org/grpc/metadata" "Join"}}(md.TrailerMD, stream.Trailer())
+		annotatedContext = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "NewServerMetadataContext"}}(annotatedContext, md)
+		if err != nil {
+			{{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "HTTPError"}}(annotatedContext, mux, outboundMarshaler, w, req, err)
+			return
+		}
+
+		forward_{{$service.GoName}}_{{.Method.GoName}}_0(annotatedContext, mux, outboundMarshaler, w, req, resp, mux.GetForwardResponseOptions()...)
+	})
+{{- end}}
+
+	return nil
+}
+
+// Register{{.GoName}}HandlerFromEndpoint is same as Register{{.GoName}}Handler but
+// automatically dials to "endpoint" and closes the connection when "ctx" gets done.
+func Register{{.GoName}}HandlerFromEndpoint(ctx {{ident "context" "Context"}}, mux *{{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ServeMux"}}, endpoint string, opts []{{ident "google.golang.org/grpc" "DialOption"}}) (err error) {
+	conn, err := {{ident "google.golang.org/grpc" "DialContext"}}(ctx, endpoint, opts...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if cerr := conn.Close(); cerr != nil {
+				{{ident "google.golang.org/grpc/grpclog" "Infof"}}("Failed to close conn to %s: %v", endpoint, cerr)
+			}
+			return
+		}
+		go func() {
+			<-ctx.Done()
+			if cerr := conn.Close(); cerr != nil {
+				{{ident "google.golang.org/grpc/grpclog" "Infof"}}("Failed to close conn to %s: %v", endpoint, cerr)
+			}
+		}()
+	}()
+
+	return Register{{.GoName}}Handler(ctx, mux, conn)
+}
+
+// Register{{.GoName}}Handler registers the http handlers for service {{.GoName}} to "mux".
+// The handlers forward requests to the grpc endpoint over "conn".
+func Register{{.GoName}}Handler(ctx {{ident "context" "Context"}}, mux *{{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ServeMux"}}, conn *{{ident "google.golang.org/grpc" "ClientConn"}}) error {
+	return Register{{.GoName}}HandlerClient(ctx, mux, New{{.GoName}}Client(conn))
+}
+
+// Register{{.GoName}}HandlerClient registers the http handlers for service {{.GoName}}
+// to "mux". The handlers forward requests to the grpc endpoint over the given implementation of "{{.GoName}}Client".
+func Register{{.GoName}}HandlerClient(ctx {{ident "context" "Context"}}, mux *{{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ServeMux"}}, client {{.GoName}}Client) error {
+{{- range .Methods}}
+
+	mux.Handle("{{.HTTPMethod}}", pattern_{{$service.GoName}}_{{.Method.GoName}}_0, func(w {{ident "net/http" "ResponseWriter"}}, req *{{ident "net/http" "Request"}}, pathParams map[string]string) {
+		ctx, cancel := {{ident "context" "WithCancel"}}(req.Context())
+		defer cancel()
+		inboundMarshaler, outboundMarshaler := {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "MarshalerForRequest"}}(mux, req)
+		var err error
+		var annotatedContext {{ident "context" "Context"}}
+		annotatedContext, err = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "AnnotateContext"}}(ctx, mux, req, "/{{$service.Desc.FullName}}/{{.Method.Desc.Name}}", {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "WithHTTPPathPattern"}}("{{.Path}}"))
+		if err != nil {
+			{{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "HTTPError"}}(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		resp, md, err := request_{{$service.GoName}}_{{.Method.GoName}}_0(annotatedContext, inboundMarshaler, client, req, pathParams)
+		annotatedContext = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "NewServerMetadataContext"}}(annotatedContext, md)
+		if err != nil {
+			{{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "HTTPError"}}(annotatedContext, mux, outboundMarshaler, w, req, err)
+			return
+		}
+
+		forward_{{$service.GoName}}_{{.Method.GoName}}_0(annotatedContext, mux, outboundMarshaler, w, req, resp, mux.GetForwardResponseOptions()...)
+	})
+{{- end}}
+
+	return nil
+}
+
+var (
+{{- range .Methods}}
+	pattern_{{$service.GoName}}_{{.Method.GoName}}_0 = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "MustPattern"}}({{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "NewPattern"}}(1, {{printf "%#v" .Ops}}, {{printf "%#v" .Pool}}, ""))
+{{- end}}
+)
+
+var (
+{{- range .Methods}}
+	forward_{{$service.GoName}}_{{.Method.GoName}}_0 = {{ident "github.com/grpc-ecosystem/grpc-gateway/v2/runtime" "ForwardResponseMessage"}}
+{{- end}}
+)
+{{- end}}
+`
+
+const grpcInterceptorTemplate = `package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"runtime/debug"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+
+// RequestIDKey is the metadata key used to receive and return request IDs
+const RequestIDKey = "x-request-id"
+
+
+// ServerOptions returns the interceptor chain. Request IDs come first so
+// every log line carries one, recovery comes after logging so panics are
+// logged as Internal errors. Deadlines are enforced for unary calls only,
+// streams are expected to be long-lived
+func ServerOptions(logger *zap.Logger, defaultTimeout, maxTimeout time.Duration) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			UnaryRequestID(),
+			UnaryLogging(logger),
+			UnaryRecovery(logger),
+			UnaryDeadline(defaultTimeout, maxTimeout),
+		),
+		grpc.ChainStreamInterceptor(
+			StreamRequestID(),
+			StreamLogging(logger),
+			StreamRecovery(logger),
+		),
+	}
+}
+
+
+type requestIDKey struct{}
+
+
+// RequestIDFromContext returns the ID assigned to the current call
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+
+// withRequestID reuses the caller's request ID or generates a new one and
+// sends it back in the response header
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = newRequestID()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id))
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+
+func UnaryRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+
+func StreamRequestID() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+
+func UnaryLogging(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+
+func StreamLogging(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+
+func logCall(ctx context.Context, logger *zap.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("request_id", RequestIDFromContext(ctx)),
+	}
+
+	switch code {
+	case codes.OK:
+		logger.Info("gRPC call", fields...)
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		logger.Error("gRPC call failed", append(fields, zap.Error(err))...)
+	default:
+		logger.Warn("gRPC call failed", append(fields, zap.Error(err))...)
+	}
+}
+
+
+func UnaryRecovery(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+
+func StreamRecovery(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+
+func recovered(ctx context.Context, logger *zap.Logger, method string, r any) error {
+	logger.Error("gRPC handler panicked",
+		zap.String("method", method),
+		zap.String("request_id", RequestIDFromContext(ctx)),
+		zap.Any("panic", r),
+		zap.ByteString("stack", debug.Stack()),
+	)
+	return status.Error(codes.Internal, "internal error")
+}
+
+
+// UnaryDeadline gives calls without a deadline defaultTimeout and caps
+// client deadlines at maxTimeout, so a handler never runs unbounded
+func UnaryDeadline(defaultTimeout, maxTimeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		timeout := defaultTimeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = min(time.Until(deadline), maxTimeout)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
+
+
+// wrappedStream replaces the stream context with one carrying the request ID
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
+`
+
+const grpcInterceptorTestTemplate = `package interceptor
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+
+const (
+	testDefaultTimeout = time.Second
+	testMaxTimeout     = 2 * time.Second
+)
+
+
+// testHealthServer runs handle inside Check and Watch, so tests can observe
+// the context the interceptors hand to a real service
+type testHealthServer struct {
+	healthpb.UnimplementedHealthServer
+	handle func(ctx context.Context) error
+}
+
+
+func (s *testHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if err := s.handle(ctx); err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+
+func (s *testHealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if err := s.handle(stream.Context()); err != nil {
+		return err
+	}
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+
+func newTestClient(t *testing.T, handle func(ctx context.Context) error) healthpb.HealthClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(ServerOptions(zap.NewNop(), testDefaultTimeout, testMaxTimeout)...)
+	healthpb.RegisterHealthServer(server, &testHealthServer{handle: handle})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+
+func TestUnaryRecoveryReturnsInternal(t *testing.T) {
+	client := newTestClient(t, func(context.Context) error {
+		panic("boom")
+	})
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if code := status.Code(err); code != codes.Internal {
+		t.Fatalf("code = %v, want %v", code, codes.Internal)
+	}
+}
+
+
+func TestStreamRecoveryReturnsInternal(t *testing.T) {
+	client := newTestClient(t, func(context.Context) error {
+		panic("boom")
+	})
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	_, err = stream.Recv()
+	if code := status.Code(err); code != codes.Internal {
+		t.Fatalf("code = %v, want %v", code, codes.Internal)
+	}
+}
+
+
+func TestRequestIDIsPropagated(t *testing.T) {
+	var got string
+	client := newTestClient(t, func(ctx context.Context) error {
+		got = RequestIDFromContext(ctx)
+		return nil
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDKey, "req-42")
+	var header metadata.MD
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if got != "req-42" {
+		t.Errorf("handler saw request ID %q, want %q", got, "req-42")
+	}
+	if values := header.Get(RequestIDKey); len(values) != 1 || values[0] != "req-42" {
+		t.Errorf("response header %s = %v, want [req-42]", RequestIDKey, values)
+	}
+}
+
+
+func TestRequestIDIsGenerated(t *testing.T) {
+	var got string
+	client := newTestClient(t, func(ctx context.Context) error {
+		got = RequestIDFromContext(ctx)
+		return nil
+	})
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatalf("Header() error = %v", err)
+	}
+
+	if got == "" {
+		t.Fatal("handler saw an empty request ID")
+	}
+	if values := header.Get(RequestIDKey); len(values) != 1 || values[0] != got {
+		t.Errorf("response header %s = %v, want [%s]", RequestIDKey, values, got)
+	}
+}
+
+
+func TestDeadlineDefaultsWhenMissing(t *testing.T) {
+	var remaining time.Duration
+	client := newTestClient(t, func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return status.Error(codes.FailedPrecondition, "no deadline")
+		}
+		remaining = time.Until(deadline)
+		return nil
+	})
+
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if remaining <= 0 || remaining > testDefaultTimeout {
+		t.Fatalf("remaining = %v, want within (0, %v]", remaining, testDefaultTimeout)
+	}
+}
+
+
+func TestDeadlineIsCapped(t *testing.T) {
+	var remaining time.Duration
+	client := newTestClient(t, func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		remaining = time.Until(deadline)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if remaining > testMaxTimeout {
+		t.Fatalf("remaining = %v, want at most %v", remaining, testMaxTimeout)
+	}
+}
 `
@@ -0,0 +1,387 @@
+package project_templates
+
+// Шаблоны для health подсистемы: liveness и readiness
+
+const configHealthTemplate = `package config
+
+import "time"
+
+
+// HealthConfig limits how long a single dependency check may take
+type HealthConfig struct {
+	CheckTimeout time.Duration
+}
+
+
+func NewHealthConfig() HealthConfig {
+	return HealthConfig{
+		CheckTimeout: time.Duration(getEnvAsInt("HEALTH_CHECK_TIMEOUT_MS", 2000)) * time.Millisecond,
+	}
+}`
+
+const healthModuleTemplate = `package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"{{.Name}}/internal/config"
+)
+
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+
+// Module aggregates the checkers registered by bootstrap providers
+{{- if .HasHTTP}}
+// and serves /healthz and /readyz on the HTTP server
+var Module = fx.Options(
+	fx.Provide(NewService),
+	fx.Provide(NewHandler),
+	fx.Invoke(RegisterRoutes),
+)
+{{- else}}
+// for the gRPC health service
+var Module = fx.Options(
+	fx.Provide(NewService),
+)
+{{- end}}
+
+
+// Checker reports whether a dependency the service needs is reachable.
+// Providers register checkers in the "health_checkers" value group
+type Checker struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+
+type Params struct {
+	fx.In
+
+	Config   *config.Config
+	Checkers []Checker ` + "`group:\"health_checkers\"`" + `
+}
+
+
+// CheckResult is the outcome of a single checker
+type CheckResult struct {
+	Status   string ` + "`json:\"status\"`" + `
+	Error    string ` + "`json:\"error,omitempty\"`" + `
+	Duration string ` + "`json:\"duration\"`" + `
+}
+
+
+// Report is the aggregated readiness of the service
+type Report struct {
+	Status string                 ` + "`json:\"status\"`" + `
+	Checks map[string]CheckResult ` + "`json:\"checks\"`" + `
+}
+
+
+func (r Report) Healthy() bool {
+	return r.Status == StatusUp
+}
+
+
+// Failed returns the names of the checks that did not pass, sorted
+func (r Report) Failed() []string {
+	var names []string
+	for name, result := range r.Checks {
+		if result.Status != StatusUp {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+
+type Service struct {
+	checkers []Checker
+	timeout  time.Duration
+}
+
+
+func NewService(p Params) *Service {
+	return New(p.Config.Health.CheckTimeout, p.Checkers...)
+}
+
+
+func New(timeout time.Duration, checkers ...Checker) *Service {
+	return &Service{
+		checkers: checkers,
+		timeout:  timeout,
+	}
+}
+
+
+// Check runs all checkers concurrently, each bounded by the configured timeout,
+// so one hanging dependency cannot stall the probe
+func (s *Service) Check(ctx context.Context) Report {
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(s.checkers)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range s.checkers {
+		wg.Add(1)
+		go func(c Checker) {
+			defer wg.Done()
+
+			result := s.run(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.Name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+
+func (s *Service) run(ctx context.Context, c Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Check(ctx)
+	if err == nil {
+		// A checker that ignores ctx may return nil after the deadline
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:   StatusUp,
+		Duration: time.Since(start).Round(time.Microsecond).String(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+`
+
+const healthHandlerTemplate = `package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+
+type Handler struct {
+	service *Service
+}
+
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+
+// Liveness answers as long as the process can serve requests, dependencies
+// are not checked: restarting the service would not bring a database back
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusUp})
+}
+
+
+// Readiness is 503 while any registered dependency is unavailable,
+// so the load balancer stops sending traffic to this instance
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.service.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+`
+
+const healthRoutesTemplate = `package health
+{{if eq .HTTPFramework "chi"}}
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes mounts the liveness and readiness probes
+func RegisterRoutes(server *chi.Mux, h *Handler) {
+	server.Get("/healthz", h.Liveness)
+	server.Get("/readyz", h.Readiness)
+}
+{{else if eq .HTTPFramework "gin"}}
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes mounts the liveness and readiness probes
+func RegisterRoutes(server *gin.Engine, h *Handler) {
+	server.GET("/healthz", gin.WrapF(h.Liveness))
+	server.GET("/readyz", gin.WrapF(h.Readiness))
+}
+{{else if eq .HTTPFramework "fiber"}}
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// RegisterRoutes mounts the liveness and readiness probes
+func RegisterRoutes(server *fiber.App, h *Handler) {
+	server.Get("/healthz", adaptor.HTTPHandlerFunc(h.Liveness))
+	server.Get("/readyz", adaptor.HTTPHandlerFunc(h.Readiness))
+}
+{{else if eq .HTTPFramework "servemux"}}
+import (
+	"net/http"
+)
+
+// RegisterRoutes mounts the liveness and readiness probes
+func RegisterRoutes(server *http.ServeMux, h *Handler) {
+	server.HandleFunc("GET /healthz", h.Liveness)
+	server.HandleFunc("GET /readyz", h.Readiness)
+}
+{{else}}
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterRoutes mounts the liveness and readiness probes
+func RegisterRoutes(server *echo.Echo, h *Handler) {
+	server.GET("/healthz", echo.WrapHandler(http.HandlerFunc(h.Liveness)))
+	server.GET("/readyz", echo.WrapHandler(http.HandlerFunc(h.Readiness)))
+}
+{{end}}`
+
+const healthTestTemplate = `package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+
+func TestCheckAllUp(t *testing.T) {
+	service := New(time.Second,
+		Checker{Name: "db", Check: func(context.Context) error { return nil }},
+		Checker{Name: "cache", Check: func(context.Context) error { return nil }},
+	)
+
+	report := service.Check(context.Background())
+
+	if !report.Healthy() {
+		t.Fatalf("expected healthy report, got %+v", report)
+	}
+	if len(report.Checks) != 2 {
+		t.Fatalf("expected 2 checks, got %d", len(report.Checks))
+	}
+}
+
+
+func TestCheckFailureAndTimeout(t *testing.T) {
+	service := New(50*time.Millisecond,
+		Checker{Name: "db", Check: func(context.Context) error { return nil }},
+		Checker{Name: "broker", Check: func(context.Context) error { return errors.New("connection refused") }},
+		Checker{Name: "slow", Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	start := time.Now()
+	report := service.Check(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("checks did not respect the timeout, took %s", elapsed)
+	}
+	if report.Healthy() {
+		t.Fatal("expected unhealthy report")
+	}
+
+	failed := report.Failed()
+	if len(failed) != 2 || failed[0] != "broker" || failed[1] != "slow" {
+		t.Fatalf("unexpected failed checks: %v", failed)
+	}
+	if report.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected deadline error, got %q", report.Checks["slow"].Error)
+	}
+}
+
+
+func TestReadinessStatusCode(t *testing.T) {
+	down := true
+	handler := NewHandler(New(time.Second, Checker{Name: "db", Check: func(context.Context) error {
+		if down {
+			return errors.New("down")
+		}
+		return nil
+	}}))
+
+	rec := httptest.NewRecorder()
+	handler.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Checks["db"].Status != StatusDown {
+		t.Fatalf("expected db down, got %+v", report.Checks["db"])
+	}
+
+	down = false
+	rec = httptest.NewRecorder()
+	handler.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}
+
+
+func TestLiveness(t *testing.T) {
+	handler := NewHandler(New(time.Second, Checker{Name: "db", Check: func(context.Context) error {
+		return errors.New("down")
+	}}))
+
+	rec := httptest.NewRecorder()
+	handler.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("liveness must not depend on checks, got %d", rec.Code)
+	}
+}
+`
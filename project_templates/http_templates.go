@@ -4,36 +4,87 @@ const httpHandlerTemplate = `package http
 
 import (
 	"go.uber.org/fx"
-	"go.uber.org/zap"
 	
 	"github.com/labstack/echo/v4"
-	"{{.Name}}/internal/config"
+{{- if not (or .OpenAPI .HasGateway)}}
+	"go.uber.org/zap"
+{{- end}}
+{{- if .OpenAPI}}
+	"{{.Name}}/internal/delivery/http/openapi"
+{{- end}}
+{{- if .HasGateway}}
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+{{- end}}
+{{- if .HasDependency "auth"}}
+	
+	"{{.Name}}/internal/auth"
+{{- end}}
 )
 
 
 var Module = fx.Options(
-	fx.Provide(NewUserHandler),
+{{- if .OpenAPI}}
+	fx.Provide(NewAPIHandler),
+{{- else if .HasGateway}}
+	fx.Provide(NewGatewayMux),
+{{- else}}
+	fx.Provide(
+{{- range .Entities}}
+		New{{.GoName}}Handler,
+{{- end}}
+	),
+{{- end}}
 )
 
-
-func RegisterRoutes(server *echo.Echo, userHandler *UserHandler) {
+{{if .OpenAPI}}
+func RegisterRoutes(server *echo.Echo, apiHandler *APIHandler{{if .HasDependency "auth"}}, authenticator *auth.Authenticator{{end}}) {
+{{- if .HasDependency "auth"}}
+	// Every operation of the spec requires a bearer token
+	openapi.RegisterHandlers(server.Group("", authenticator.Middleware()), apiHandler)
+{{- else}}
+	openapi.RegisterHandlers(server, apiHandler)
+{{- end}}
+	registerDocs(server)
+}
+{{- else if .HasGateway}}
+// RegisterRoutes serves the REST API through grpc-gateway: routes come from
+// the google.api.http rules in api/proto
+func RegisterRoutes(server *echo.Echo, gateway *runtime.ServeMux{{if .HasDependency "auth"}}, authenticator *auth.Authenticator{{end}}) {
+	server.Any("/api/*", echo.WrapHandler(gateway){{if .HasDependency "auth"}}, authenticator.Middleware(){{end}})
+	
+	registerDocs(server)
+}
+{{- else}}
+func RegisterRoutes(server *echo.Echo, logger *zap.Logger{{if .HasDependency "auth"}}, authenticator *auth.Authenticator{{end}}{{- range .Entities}}, {{.VarName}}Handler *{{.GoName}}Handler{{end}}) {
+	server.HTTPErrorHandler = ErrorHandler(logger)
+	
+{{- if .HasDependency "auth"}}
+	
+	// The principal of the bearer token reaches the use cases through the request context
+	api := server.Group("/api", authenticator.Middleware())
+{{- else}}
+	
 	api := server.Group("/api")
+{{- end}}
+	
+{{- range .Entities}}
 	
+	{{.PluralVarName}} := api.Group("/{{.Route}}")
+	{{.PluralVarName}}.POST("", {{.VarName}}Handler.Create)
+	{{.PluralVarName}}.GET("/:id", {{.VarName}}Handler.GetByID)
+	{{.PluralVarName}}.GET("", {{.VarName}}Handler.List)
+	{{.PluralVarName}}.PUT("/:id", {{.VarName}}Handler.Update)
+	{{.PluralVarName}}.DELETE("/:id", {{.VarName}}Handler.Delete)
+{{- end}}
 	
-	users := api.Group("/users")
-	users.POST("", userHandler.Create)
-	users.GET("/:id", userHandler.GetByID)
-	users.GET("", userHandler.List)
-	users.PUT("/:id", userHandler.Update)
-	users.DELETE("/:id", userHandler.Delete)
+	registerDocs(server)
 }
+{{- end}}
 `
 
 const httpServerTemplate = `package http
 
 import (
-	"net/http"
-	
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
@@ -53,104 +104,852 @@ func NewServer(e *echo.Echo, logger *zap.Logger) *Server {
 }
 `
 
-const httpUserHandlerTemplate = `package http
+// httpQueryTemplate разбирает параметры списков. Общий для всех HTTP
+// фреймворков: каждый обработчик передает сюда url.Values запроса
+const httpQueryTemplate = `package http
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+	
+	"{{.Name}}/internal/domain"
+)
+
+// List endpoints accept ?limit=20&offset=40 or ?page_token=... from the
+// previous page, ?sort=-created_at and filters by field value, e.g. ?email=...
+
+func parsePagination(query url.Values) (domain.Pagination, error) {
+	var pagination domain.Pagination
+	var err error
+	if pagination.Limit, err = queryInt(query, "limit"); err != nil {
+		return pagination, err
+	}
+	if pagination.Offset, err = queryInt(query, "offset"); err != nil {
+		return pagination, err
+	}
+	pagination.PageToken = query.Get("page_token")
+	return pagination, nil
+}
+
+
+func queryInt(query url.Values, name string) (int, error) {
+	if !query.Has(name) {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(query.Get(name))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer", domain.ErrInvalidListQuery, name)
+	}
+	return n, nil
+}
+
+
+// filterString, filterInt and filterBool return nil for an absent parameter,
+// which does not restrict the list
+func filterString[T ~string](query url.Values, name string) *T {
+	if !query.Has(name) {
+		return nil
+	}
+	value := T(query.Get(name))
+	return &value
+}
+
+{{- if .Entities.HasField "int"}}
+
+
+func filterInt(query url.Values, name string) (*int64, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+	value, err := strconv.ParseInt(query.Get(name), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be an integer", domain.ErrInvalidListQuery, name)
+	}
+	return &value, nil
+}
+{{- end}}
+{{- if .Entities.HasField "bool"}}
+
+
+func filterBool(query url.Values, name string) (*bool, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(query.Get(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be true or false", domain.ErrInvalidListQuery, name)
+	}
+	return &value, nil
+}
+{{- end}}
+{{- range .Entities}}
+
+
+func parse{{.GoName}}ListQuery(query url.Values) (domain.{{.GoName}}ListQuery, error) {
+	pagination, err := parsePagination(query)
+	if err != nil {
+		return domain.{{.GoName}}ListQuery{}, err
+	}
+	
+	listQuery := domain.{{.GoName}}ListQuery{
+		Sort:       domain.ParseSortOrder(query.Get("sort")),
+		Pagination: pagination,
+	}
+{{- range .FilterFields}}
+{{- if eq .Type "int"}}
+	if listQuery.Filter.{{.GoName}}, err = filterInt(query, "{{.JSONName}}"); err != nil {
+		return listQuery, err
+	}
+{{- else if eq .Type "bool"}}
+	if listQuery.Filter.{{.GoName}}, err = filterBool(query, "{{.JSONName}}"); err != nil {
+		return listQuery, err
+	}
+{{- else}}
+	listQuery.Filter.{{.GoName}} = filterString[{{.DomainType}}](query, "{{.JSONName}}")
+{{- end}}
+{{- end}}
+	return listQuery, nil
+}
+{{- end}}
+`
+
+const httpErrorsTemplate = `package http
+
+import (
+{{- if eq .HTTPFramework "chi" "servemux"}}
+	"encoding/json"
+{{- end}}
+	"errors"
+{{- if eq .HTTPFramework "echo"}}
+	"fmt"
+{{- end}}
 	"net/http"
 	
+{{- if eq .HTTPFramework "echo"}}
 	"github.com/labstack/echo/v4"
+{{- else if eq .HTTPFramework "gin"}}
+	"github.com/gin-gonic/gin"
+{{- else if eq .HTTPFramework "fiber"}}
+	"github.com/gofiber/fiber/v2"
+{{- end}}
 	"go.uber.org/zap"
 	
 	"{{.Name}}/internal/domain"
 )
 
+// problemContentType is the media type of RFC 7807 error responses
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error response. Fields lists the offending fields
+// of a validation error or a conflict
+type Problem struct {
+	Type     string              ` + "`json:\"type\"`" + `
+	Title    string              ` + "`json:\"title\"`" + `
+	Status   int                 ` + "`json:\"status\"`" + `
+	Detail   string              ` + "`json:\"detail,omitempty\"`" + `
+	Instance string              ` + "`json:\"instance,omitempty\"`" + `
+	Fields   []domain.FieldError ` + "`json:\"fields,omitempty\"`" + `
+}
+
+// newProblem maps an error of the use cases onto a problem by its domain
+// kind. Any other error is a 500 without details, its text stays in the log
+func newProblem(err error, instance string) Problem {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrInvalidArgument):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		status = http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, domain.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		status = http.StatusConflict
+	}
+	
+	problem := Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Instance: instance}
+	if status == http.StatusInternalServerError {
+		return problem
+	}
+	problem.Detail = err.Error()
+	
+	var validationErr *domain.ValidationError
+	var conflictErr *domain.ConflictError
+	switch {
+	case errors.As(err, &validationErr):
+		problem.Fields = validationErr.Fields
+	case errors.As(err, &conflictErr):
+		field := domain.FieldError{Field: conflictErr.Field, Message: "is already taken"}
+		problem.Fields = []domain.FieldError{field}
+	}
+	return problem
+}
+
+
+// logProblem keeps the cause of 5xx responses, clients only see the status
+func logProblem(logger *zap.Logger, method, path string, problem Problem, err error) {
+	if problem.Status >= http.StatusInternalServerError {
+		logger.Error("Request failed",
+			zap.String("method", method),
+			zap.String("path", path),
+			zap.Error(err))
+	}
+}
+{{- if eq .HTTPFramework "echo"}}
+
+
+// ErrorHandler answers every error returned by a handler with a problem.
+// Errors raised by echo itself, like an unknown route or a malformed body,
+// keep their status
+func ErrorHandler(logger *zap.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+		
+		path := c.Request().URL.Path
+		problem := newProblem(err, path)
+		var httpErr *echo.HTTPError
+		if problem.Status == http.StatusInternalServerError && errors.As(err, &httpErr) {
+			problem = Problem{Type: "about:blank", Title: http.StatusText(httpErr.Code), Status: httpErr.Code, Instance: path}
+			if httpErr.Code < http.StatusInternalServerError {
+				problem.Detail = fmt.Sprint(httpErr.Message)
+			}
+		}
+		logProblem(logger, c.Request().Method, path, problem, err)
+		
+		c.Response().Header().Set(echo.HeaderContentType, problemContentType)
+		if err := c.JSON(problem.Status, problem); err != nil {
+			logger.Error("Failed to write error response", zap.Error(err))
+		}
+	}
+}
+{{- else}}
+
+
+// invalidBody reports a request body that could not be decoded
+func invalidBody(err error) error {
+	return domain.Errorf(domain.ErrInvalidArgument, "invalid request body: %v", err)
+}
+{{- end}}
+{{- if eq .HTTPFramework "chi" "servemux"}}
+
+
+// writeProblem answers with the problem for err
+func writeProblem(w http.ResponseWriter, r *http.Request, logger *zap.Logger, err error) {
+	problem := newProblem(err, r.URL.Path)
+	logProblem(logger, r.Method, r.URL.Path, problem, err)
+	
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+{{- else if eq .HTTPFramework "gin"}}
+
+
+// abortWithProblem answers with the problem for err
+func abortWithProblem(c *gin.Context, logger *zap.Logger, err error) {
+	problem := newProblem(err, c.Request.URL.Path)
+	logProblem(logger, c.Request.Method, c.Request.URL.Path, problem, err)
+	
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+{{- else if eq .HTTPFramework "fiber"}}
+
+
+// sendProblem answers with the problem for err
+func sendProblem(c *fiber.Ctx, logger *zap.Logger, err error) error {
+	problem := newProblem(err, c.Path())
+	logProblem(logger, c.Method(), c.Path(), problem, err)
+	
+	return c.Status(problem.Status).JSON(problem, problemContentType)
+}
+{{- end}}
+`
+
+const echoEntityHandlerTemplate = `package http
+
+import (
+	"net/http"
+	
+	"github.com/labstack/echo/v4"
+	
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
+
+
+// {{.GoName}}Handler returns use case errors as is, ErrorHandler turns them
+// into problem responses
+type {{.GoName}}Handler struct {
+	useCase domain.{{.GoName}}UseCase
+}
+
+
+func New{{.GoName}}Handler(useCase domain.{{.GoName}}UseCase) *{{.GoName}}Handler {
+	return &{{.GoName}}Handler{
+		useCase: useCase,
+	}
+}
+
+
+func (h *{{.GoName}}Handler) Create(c echo.Context) error {
+	{{.VarName}} := new(domain.{{.GoName}})
+	if err := c.Bind({{.VarName}}); err != nil {
+		return err
+	}
+	
+	if err := h.useCase.Create(c.Request().Context(), {{.VarName}}); err != nil {
+		return err
+	}
+	
+	return c.JSON(http.StatusCreated, {{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) GetByID(c echo.Context) error {
+	{{.VarName}}, err := h.useCase.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	
+	return c.JSON(http.StatusOK, {{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) List(c echo.Context) error {
+	query, err := parse{{.GoName}}ListQuery(c.QueryParams())
+	if err != nil {
+		return err
+	}
+	
+	result, err := h.useCase.List(c.Request().Context(), query)
+	if err != nil {
+		return err
+	}
+	
+	return c.JSON(http.StatusOK, result)
+}
+
+
+func (h *{{.GoName}}Handler) Update(c echo.Context) error {
+	{{.VarName}} := new(domain.{{.GoName}})
+	if err := c.Bind({{.VarName}}); err != nil {
+		return err
+	}
+	
+	{{.VarName}}.ID = c.Param("id")
+	
+	if err := h.useCase.Update(c.Request().Context(), {{.VarName}}); err != nil {
+		return err
+	}
+	
+	return c.JSON(http.StatusOK, {{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) Delete(c echo.Context) error {
+	if err := h.useCase.Delete(c.Request().Context(), c.Param("id")); err != nil {
+		return err
+	}
+	
+	return c.NoContent(http.StatusNoContent)
+}
+{{- end}}
+`
+
+const chiHandlerTemplate = `package http
+
+import (
+	"encoding/json"
+	"net/http"
+	
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(
+{{- range .Entities}}
+		New{{.GoName}}Handler,
+{{- end}}
+	),
+)
+
+
+func RegisterRoutes(router *chi.Mux{{- range .Entities}}, {{.VarName}}Handler *{{.GoName}}Handler{{end}}) {
+	router.Route("/api", func(api chi.Router) {
+{{- range .Entities}}
+		api.Route("/{{.Route}}", func({{.PluralVarName}} chi.Router) {
+			{{.PluralVarName}}.Post("/", {{.VarName}}Handler.Create)
+			{{.PluralVarName}}.Get("/{id}", {{.VarName}}Handler.GetByID)
+			{{.PluralVarName}}.Get("/", {{.VarName}}Handler.List)
+			{{.PluralVarName}}.Put("/{id}", {{.VarName}}Handler.Update)
+			{{.PluralVarName}}.Delete("/{id}", {{.VarName}}Handler.Delete)
+		})
+{{- end}}
+	})
+}
+
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+
+func pathID(r *http.Request) string {
+	return chi.URLParam(r, "id")
+}
+`
+
+const serveMuxHandlerTemplate = `package http
+
+import (
+	"encoding/json"
+	"net/http"
+	
+	"go.uber.org/fx"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(
+{{- range .Entities}}
+		New{{.GoName}}Handler,
+{{- end}}
+	),
+)
+
 
-type UserHandler struct {
-	useCase domain.UserUseCase
+func RegisterRoutes(mux *http.ServeMux{{- range .Entities}}, {{.VarName}}Handler *{{.GoName}}Handler{{end}}) {
+{{- range .Entities}}
+	mux.HandleFunc("POST /api/{{.Route}}", {{.VarName}}Handler.Create)
+	mux.HandleFunc("GET /api/{{.Route}}/{id}", {{.VarName}}Handler.GetByID)
+	mux.HandleFunc("GET /api/{{.Route}}", {{.VarName}}Handler.List)
+	mux.HandleFunc("PUT /api/{{.Route}}/{id}", {{.VarName}}Handler.Update)
+	mux.HandleFunc("DELETE /api/{{.Route}}/{id}", {{.VarName}}Handler.Delete)
+{{- end}}
+}
+
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+
+func pathID(r *http.Request) string {
+	return r.PathValue("id")
+}
+`
+
+// netHTTPEntityHandlerTemplate используется для chi и ServeMux: оба работают
+// с обычными http.HandlerFunc и отличаются только извлечением параметров пути,
+// writeJSON и pathID объявлены рядом с маршрутами
+const netHTTPEntityHandlerTemplate = `package http
+
+import (
+	"encoding/json"
+	"net/http"
+	
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
+
+
+type {{.GoName}}Handler struct {
+	useCase domain.{{.GoName}}UseCase
 	logger  *zap.Logger
 }
 
 
-func NewUserHandler(useCase domain.UserUseCase, logger *zap.Logger) *UserHandler {
-	return &UserHandler{
+func New{{.GoName}}Handler(useCase domain.{{.GoName}}UseCase, logger *zap.Logger) *{{.GoName}}Handler {
+	return &{{.GoName}}Handler{
 		useCase: useCase,
 		logger:  logger,
 	}
 }
 
 
-func (h *UserHandler) Create(c echo.Context) error {
-	user := new(domain.User)
-	if err := c.Bind(user); err != nil {
-		h.logger.Error("Failed to bind request", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+func (h *{{.GoName}}Handler) Create(w http.ResponseWriter, r *http.Request) {
+	{{.VarName}} := new(domain.{{.GoName}})
+	if err := json.NewDecoder(r.Body).Decode({{.VarName}}); err != nil {
+		writeProblem(w, r, h.logger, invalidBody(err))
+		return
 	}
 	
-	if err := h.useCase.Create(user); err != nil {
-		h.logger.Error("Failed to create user", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create user"})
+	if err := h.useCase.Create(r.Context(), {{.VarName}}); err != nil {
+		writeProblem(w, r, h.logger, err)
+		return
+	}
+	
+	writeJSON(w, http.StatusCreated, {{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	{{.VarName}}, err := h.useCase.GetByID(r.Context(), pathID(r))
+	if err != nil {
+		writeProblem(w, r, h.logger, err)
+		return
 	}
 	
-	return c.JSON(http.StatusCreated, user)
+	writeJSON(w, http.StatusOK, {{.VarName}})
 }
 
 
-func (h *UserHandler) GetByID(c echo.Context) error {
-	id := c.Param("id")
+func (h *{{.GoName}}Handler) List(w http.ResponseWriter, r *http.Request) {
+	query, err := parse{{.GoName}}ListQuery(r.URL.Query())
+	if err != nil {
+		writeProblem(w, r, h.logger, err)
+		return
+	}
 	
-	user, err := h.useCase.GetByID(id)
+	result, err := h.useCase.List(r.Context(), query)
 	if err != nil {
-		h.logger.Error("Failed to get user", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+		writeProblem(w, r, h.logger, err)
+		return
+	}
+	
+	writeJSON(w, http.StatusOK, result)
+}
+
+
+func (h *{{.GoName}}Handler) Update(w http.ResponseWriter, r *http.Request) {
+	{{.VarName}} := new(domain.{{.GoName}})
+	if err := json.NewDecoder(r.Body).Decode({{.VarName}}); err != nil {
+		writeProblem(w, r, h.logger, invalidBody(err))
+		return
+	}
+	
+	{{.VarName}}.ID = pathID(r)
+	
+	if err := h.useCase.Update(r.Context(), {{.VarName}}); err != nil {
+		writeProblem(w, r, h.logger, err)
+		return
+	}
+	
+	writeJSON(w, http.StatusOK, {{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.useCase.Delete(r.Context(), pathID(r)); err != nil {
+		writeProblem(w, r, h.logger, err)
+		return
+	}
+	
+	w.WriteHeader(http.StatusNoContent)
+}
+{{- end}}
+`
+
+const ginHandlerTemplate = `package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(
+{{- range .Entities}}
+		New{{.GoName}}Handler,
+{{- end}}
+	),
+)
+
+
+func RegisterRoutes(server *gin.Engine{{- range .Entities}}, {{.VarName}}Handler *{{.GoName}}Handler{{end}}) {
+	api := server.Group("/api")
+	
+{{- range .Entities}}
+	
+	{{.PluralVarName}} := api.Group("/{{.Route}}")
+	{{.PluralVarName}}.POST("", {{.VarName}}Handler.Create)
+	{{.PluralVarName}}.GET("/:id", {{.VarName}}Handler.GetByID)
+	{{.PluralVarName}}.GET("", {{.VarName}}Handler.List)
+	{{.PluralVarName}}.PUT("/:id", {{.VarName}}Handler.Update)
+	{{.PluralVarName}}.DELETE("/:id", {{.VarName}}Handler.Delete)
+{{- end}}
+}
+`
+
+const ginEntityHandlerTemplate = `package http
+
+import (
+	"net/http"
+	
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
+
+
+type {{.GoName}}Handler struct {
+	useCase domain.{{.GoName}}UseCase
+	logger  *zap.Logger
+}
+
+
+func New{{.GoName}}Handler(useCase domain.{{.GoName}}UseCase, logger *zap.Logger) *{{.GoName}}Handler {
+	return &{{.GoName}}Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+
+func (h *{{.GoName}}Handler) Create(c *gin.Context) {
+	{{.VarName}} := new(domain.{{.GoName}})
+	if err := c.ShouldBindJSON({{.VarName}}); err != nil {
+		abortWithProblem(c, h.logger, invalidBody(err))
+		return
 	}
 	
-	if user == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	if err := h.useCase.Create(c.Request.Context(), {{.VarName}}); err != nil {
+		abortWithProblem(c, h.logger, err)
+		return
 	}
 	
-	return c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusCreated, {{.VarName}})
 }
 
 
-func (h *UserHandler) List(c echo.Context) error {
-	users, err := h.useCase.List()
+func (h *{{.GoName}}Handler) GetByID(c *gin.Context) {
+	{{.VarName}}, err := h.useCase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, h.logger, err)
+		return
+	}
+	
+	c.JSON(http.StatusOK, {{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) List(c *gin.Context) {
+	query, err := parse{{.GoName}}ListQuery(c.Request.URL.Query())
+	if err != nil {
+		abortWithProblem(c, h.logger, err)
+		return
+	}
+	
+	result, err := h.useCase.List(c.Request.Context(), query)
 	if err != nil {
-		h.logger.Error("Failed to list users", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list users"})
+		abortWithProblem(c, h.logger, err)
+		return
+	}
+	
+	c.JSON(http.StatusOK, result)
+}
+
+
+func (h *{{.GoName}}Handler) Update(c *gin.Context) {
+	{{.VarName}} := new(domain.{{.GoName}})
+	if err := c.ShouldBindJSON({{.VarName}}); err != nil {
+		abortWithProblem(c, h.logger, invalidBody(err))
+		return
+	}
+	
+	{{.VarName}}.ID = c.Param("id")
+	
+	if err := h.useCase.Update(c.Request.Context(), {{.VarName}}); err != nil {
+		abortWithProblem(c, h.logger, err)
+		return
+	}
+	
+	c.JSON(http.StatusOK, {{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) Delete(c *gin.Context) {
+	if err := h.useCase.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		abortWithProblem(c, h.logger, err)
+		return
+	}
+	
+	c.Status(http.StatusNoContent)
+}
+{{- end}}
+`
+
+const fiberHandlerTemplate = `package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(
+{{- range .Entities}}
+		New{{.GoName}}Handler,
+{{- end}}
+	),
+)
+
+
+func RegisterRoutes(server *fiber.App{{- range .Entities}}, {{.VarName}}Handler *{{.GoName}}Handler{{end}}) {
+	api := server.Group("/api")
+	
+{{- range .Entities}}
+	
+	{{.PluralVarName}} := api.Group("/{{.Route}}")
+	{{.PluralVarName}}.Post("", {{.VarName}}Handler.Create)
+	{{.PluralVarName}}.Get("/:id", {{.VarName}}Handler.GetByID)
+	{{.PluralVarName}}.Get("", {{.VarName}}Handler.List)
+	{{.PluralVarName}}.Put("/:id", {{.VarName}}Handler.Update)
+	{{.PluralVarName}}.Delete("/:id", {{.VarName}}Handler.Delete)
+{{- end}}
+}
+`
+
+const fiberEntityHandlerTemplate = `package http
+
+import (
+	"net/url"
+	
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
+
+
+type {{.GoName}}Handler struct {
+	useCase domain.{{.GoName}}UseCase
+	logger  *zap.Logger
+}
+
+
+func New{{.GoName}}Handler(useCase domain.{{.GoName}}UseCase, logger *zap.Logger) *{{.GoName}}Handler {
+	return &{{.GoName}}Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+
+func (h *{{.GoName}}Handler) Create(c *fiber.Ctx) error {
+	{{.VarName}} := new(domain.{{.GoName}})
+	if err := c.BodyParser({{.VarName}}); err != nil {
+		return sendProblem(c, h.logger, invalidBody(err))
 	}
 	
-	return c.JSON(http.StatusOK, users)
+	if err := h.useCase.Create(c.UserContext(), {{.VarName}}); err != nil {
+		return sendProblem(c, h.logger, err)
+	}
+	
+	return c.Status(fiber.StatusCreated).JSON({{.VarName}})
 }
 
 
-func (h *UserHandler) Update(c echo.Context) error {
-	id := c.Param("id")
+func (h *{{.GoName}}Handler) GetByID(c *fiber.Ctx) error {
+	{{.VarName}}, err := h.useCase.GetByID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return sendProblem(c, h.logger, err)
+	}
 	
-	user := new(domain.User)
-	if err := c.Bind(user); err != nil {
-		h.logger.Error("Failed to bind request", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	return c.JSON({{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) List(c *fiber.Ctx) error {
+	values, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return sendProblem(c, h.logger, domain.Errorf(domain.ErrInvalidArgument, "invalid query string: %v", err))
 	}
 	
-	user.ID = id
+	query, err := parse{{.GoName}}ListQuery(values)
+	if err != nil {
+		return sendProblem(c, h.logger, err)
+	}
 	
-	if err := h.useCase.Update(user); err != nil {
-		h.logger.Error("Failed to update user", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update user"})
+	result, err := h.useCase.List(c.UserContext(), query)
+	if err != nil {
+		return sendProblem(c, h.logger, err)
 	}
 	
-	return c.JSON(http.StatusOK, user)
+	return c.JSON(result)
 }
 
 
-func (h *UserHandler) Delete(c echo.Context) error {
-	id := c.Param("id")
+func (h *{{.GoName}}Handler) Update(c *fiber.Ctx) error {
+	{{.VarName}} := new(domain.{{.GoName}})
+	if err := c.BodyParser({{.VarName}}); err != nil {
+		return sendProblem(c, h.logger, invalidBody(err))
+	}
+	
+	{{.VarName}}.ID = c.Params("id")
 	
-	if err := h.useCase.Delete(id); err != nil {
-		h.logger.Error("Failed to delete user", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete user"})
+	if err := h.useCase.Update(c.UserContext(), {{.VarName}}); err != nil {
+		return sendProblem(c, h.logger, err)
 	}
 	
-	return c.NoContent(http.StatusNoContent)
+	return c.JSON({{.VarName}})
+}
+
+
+func (h *{{.GoName}}Handler) Delete(c *fiber.Ctx) error {
+	if err := h.useCase.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return sendProblem(c, h.logger, err)
+	}
+	
+	return c.SendStatus(fiber.StatusNoContent)
+}
+{{- end}}
+`
+
+const gatewayTemplate = `package http
+
+import (
+	"context"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	grpcdelivery "{{.Name}}/internal/delivery/grpc"
+)
+
+
+// NewGatewayMux translates REST calls into calls of the gRPC services.
+// Handlers are invoked in-process, so gRPC interceptors do not run for
+// requests coming through the gateway
+func NewGatewayMux({{range $i, $e := .Entities}}{{if $i}}, {{end}}{{.VarName}}Service *grpcdelivery.{{.GoName}}Service{{end}}) (*runtime.ServeMux, error) {
+	mux := runtime.NewServeMux(
+		// snake_case поля, как в api/openapi.yaml
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+	)
+
+{{- range .Entities}}
+
+	if err := grpcdelivery.Register{{.GoName}}ServiceHandlerServer(context.Background(), mux, {{.VarName}}Service); err != nil {
+		return nil, err
+	}
+{{- end}}
+
+	return mux, nil
 }
 `
@@ -2,7 +2,7 @@ package project_templates
 
 const makefileTemplate = `# Makefile for {{.GetProjectName}}
 
-.PHONY: all build run test clean lint mock proto docker docker-compose
+.PHONY: all build run test clean lint mock proto graphql auth-dev docker docker-compose
 
 # Go parameters
 GOCMD=go
@@ -18,13 +18,13 @@ BINARY_NAME={{.GetProjectName}}
 
 all: test build
 
-build:
+build:{{if .HasDependency "graphql"}} graphql{{end}}
 	$(GOBUILD) -o $(BINARY_NAME) -v
 
-run:
+run:{{if .HasDependency "graphql"}} graphql{{end}}
 	$(GORUN) main.go
 
-test:
+test:{{if .HasDependency "graphql"}} graphql{{end}}
 	$(GOTEST) -v ./...
 
 clean:
@@ -42,10 +42,26 @@ update:
 	$(GOGET) -u ./...
 
 {{- if .HasDependency "grpc"}}
+
 proto:
-	protoc --go_out=. --go_opt=paths=source_relative \
-		--go-grpc_out=. --go-grpc_opt=paths=source_relative \
-		api/proto/*.proto
+{{- if .HasGoogleAPIs}}
+	buf dep update
+{{- end}}
+	buf lint
+	buf generate
+{{- end}}
+
+{{- if .HasDependency "graphql"}}
+
+graphql:
+	$(GOCMD) tool gqlgen generate
+{{- end}}
+
+{{- if .HasDependency "auth"}}
+
+# Local stand-in identity provider: JWKS and RS256 tokens on :8090
+auth-dev:
+	$(GORUN) ./cmd/authdev
 {{- end}}
 
 mock:
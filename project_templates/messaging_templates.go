@@ -5,199 +5,998 @@ const kafkaTemplate = `package kafka
 import (
 	"context"
 	
-	"github.com/segmentio/kafka-go"
 	"go.uber.org/fx"
+)
+
+
+// Module wires the Kafka publisher and the event consumer.
+// Writer and reader come from bootstrap; consumed events are handed to
+// everything registered in the "event_subscribers" group
+var Module = fx.Options(
+	fx.Provide(
+		fx.Annotate(
+			NewEventConsumer,
+			fx.ParamTags("", "", "", ` + "`group:\"event_subscribers\"`" + `),
+		),
+{{- if not .HasOutbox}}
+		fx.Annotate(
+			NewEventPublisher,
+			fx.ResultTags(` + "`group:\"event_publishers\"`" + `),
+		),
+{{- end}}
+	),
+	fx.Invoke(RegisterEventConsumer),
+)
+
+
+func RegisterEventConsumer(lc fx.Lifecycle, consumer *EventConsumer) {
+	ctx, cancel := context.WithCancel(context.Background())
+	
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			consumer.Start(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+`
+
+const kafkaEventsTemplate = `package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	
+	"github.com/segmentio/kafka-go"
+{{- if .HasDependency "otel"}}
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+{{- end}}
 	"go.uber.org/zap"
 	
 	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/domain"
 )
 
 
-type KafkaConfig struct {
-	Brokers []string
-	Topic   string
+// EventPublisher writes domain events to Kafka, keyed by the aggregate ID
+// so that all changes of one entity stay ordered within a partition
+type EventPublisher struct {
+	writer *kafka.Writer
+	logger *zap.Logger
+}
+
+
+func NewEventPublisher(writer *kafka.Writer, logger *zap.Logger) domain.EventPublisher {
+	return &EventPublisher{
+		writer: writer,
+		logger: logger,
+	}
+}
+
+
+func (p *EventPublisher) Publish(ctx context.Context, event domain.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	
+	msg := kafka.Message{
+		Key:   []byte(event.ID),
+		Value: data,
+	}
+{{- if .HasDependency "otel"}}
+	
+	ctx, span := tracer.Start(ctx, "kafka.publish", 
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "publish"),
+			attribute.String("event.type", string(event.Type)),
+		))
+	defer span.End()
+	
+	// Consumers continue the trace from the message headers
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{&msg})
+{{- end}}
+	
+	err = p.writer.WriteMessages(ctx, msg)
+	if err != nil {
+{{- if .HasDependency "otel"}}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+{{- end}}
+		p.logger.Error("Failed to publish event", 
+			zap.String("type", string(event.Type)),
+			zap.String("id", event.ID),
+			zap.Error(err))
+		return err
+	}
+	
+	p.logger.Info("Published event", 
+		zap.String("type", string(event.Type)),
+		zap.String("id", event.ID))
+	
+	return nil
+}
+
+
+type EventConsumer struct {
+	reader      *kafka.Reader
+	subscribers []domain.EventPublisher
+	timeout     time.Duration
+	logger      *zap.Logger
+}
+
+
+func NewEventConsumer(reader *kafka.Reader, cfg *config.Config, logger *zap.Logger, subscribers []domain.EventPublisher) *EventConsumer {
+	return &EventConsumer{
+		reader:      reader,
+		subscribers: subscribers,
+		// Each message is handled like a request and gets the same deadline
+		timeout:     cfg.Server.RequestTimeout,
+		logger:      logger,
+	}
+}
+
+
+func (c *EventConsumer) Start(ctx context.Context) {
+	go func() {
+		c.logger.Info("Starting event consumer")
+		
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				msg, err := c.reader.ReadMessage(ctx)
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					c.logger.Error("Failed to read message", zap.Error(err))
+					continue
+				}
+				
+				c.handle(ctx, msg)
+			}
+		}
+	}()
+}
+
+
+func (c *EventConsumer) handle(ctx context.Context, msg kafka.Message) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	
+{{- if .HasDependency "otel"}}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{&msg})
+	ctx, span := tracer.Start(ctx, "kafka.consume", 
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		))
+	defer span.End()
+	
+{{- end}}
+	var event domain.Event
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		c.logger.Error("Failed to unmarshal event", zap.Error(err))
+{{- if .HasDependency "otel"}}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid event")
+{{- end}}
+		return
+	}
+	
+	c.logger.Info("Received event", 
+		zap.String("type", string(event.Type)),
+		zap.String("id", event.ID))
+	
+	c.dispatch(ctx, event)
 }
 
 
-func NewKafkaConfig(cfg *config.Config) *KafkaConfig {
-	return &KafkaConfig{
-		Brokers: []string{cfg.GetEnv("KAFKA_BROKER", "localhost:9092")},
-		Topic:   cfg.GetEnv("KAFKA_TOPIC", "users"),
+func (c *EventConsumer) dispatch(ctx context.Context, event domain.Event) {
+	for _, subscriber := range c.subscribers {
+		if err := subscriber.Publish(ctx, event); err != nil {
+			c.logger.Error("Failed to deliver event", 
+				zap.String("type", string(event.Type)),
+				zap.Error(err))
+		}
 	}
 }
+`
 
+const natsTemplate = `package nats
 
-func NewKafkaWriter(lc fx.Lifecycle, cfg *KafkaConfig, logger *zap.Logger) *kafka.Writer {
-	writer := kafka.NewWriter(kafka.WriterConfig{
-		Brokers: cfg.Brokers,
-		Topic:   cfg.Topic,
+import (
+	"context"
+	"time"
+	
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/config"
+)
+
+
+// Module wires the JetStream publisher and the event consumer. Consumed
+// events are handed to everything registered in the "event_subscribers" group
+var Module = fx.Options(
+	fx.Provide(
+		NewEventStream,
+		fx.Annotate(
+			NewEventConsumer,
+			fx.ParamTags("", "", "", ` + "`group:\"event_subscribers\"`" + `),
+		),
+		fx.Annotate(
+			NewEventPublisher,
+			fx.ResultTags(` + "`group:\"event_publishers\"`" + `),
+		),
+	),
+	fx.Invoke(RegisterEventConsumer),
+)
+
+
+func NewEventStream(js jetstream.JetStream, cfg *config.Config, logger *zap.Logger) (jetstream.Stream, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.NATS.Stream,
+		Subjects: []string{cfg.NATS.Subject + ".>"},
+		Storage:  jetstream.FileStorage,
 	})
+	if err != nil {
+		return nil, err
+	}
 	
+	logger.Info("JetStream stream is ready", 
+		zap.String("stream", cfg.NATS.Stream),
+		zap.String("subjects", cfg.NATS.Subject+".>"))
+	
+	return stream, nil
+}
+
+
+func RegisterEventConsumer(lc fx.Lifecycle, consumer *EventConsumer) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			logger.Info("Initializing Kafka writer", 
-				zap.Strings("brokers", cfg.Brokers), 
-				zap.String("topic", cfg.Topic))
-			return nil
+			return consumer.Start(ctx)
 		},
 		OnStop: func(ctx context.Context) error {
-			logger.Info("Closing Kafka writer")
-			return writer.Close()
+			consumer.Stop()
+			return nil
 		},
 	})
+}
+`
+
+const natsEventsTemplate = `package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/domain"
+)
+
+
+// EventPublisher publishes domain events to "<subject>.<event type>"
+type EventPublisher struct {
+	js      jetstream.JetStream
+	subject string
+	logger  *zap.Logger
+}
+
+
+func NewEventPublisher(js jetstream.JetStream, cfg *config.Config, logger *zap.Logger) domain.EventPublisher {
+	return &EventPublisher{
+		js:      js,
+		subject: cfg.NATS.Subject,
+		logger:  logger,
+	}
+}
+
+
+func (p *EventPublisher) Publish(ctx context.Context, event domain.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	
+	subject := p.subject + "." + string(event.Type)
+	if _, err := p.js.Publish(ctx, subject, data); err != nil {
+		p.logger.Error("Failed to publish event", 
+			zap.String("type", string(event.Type)),
+			zap.String("id", event.ID),
+			zap.Error(err))
+		return err
+	}
+	
+	p.logger.Info("Published event", 
+		zap.String("type", string(event.Type)),
+		zap.String("id", event.ID))
 	
-	return writer
+	return nil
+}
+
+
+// EventConsumer acks an event once every subscriber has handled it. A failed
+// subscriber naks the message, so JetStream delivers it again
+type EventConsumer struct {
+	stream      jetstream.Stream
+	durable     string
+	subject     string
+	subscribers []domain.EventPublisher
+	timeout     time.Duration
+	logger      *zap.Logger
+	consume     jetstream.ConsumeContext
 }
 
 
-func NewKafkaReader(lc fx.Lifecycle, cfg *KafkaConfig, logger *zap.Logger) *kafka.Reader {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: cfg.Brokers,
-		Topic:   cfg.Topic,
-		GroupID: "app-consumer",
+func NewEventConsumer(stream jetstream.Stream, cfg *config.Config, logger *zap.Logger, subscribers []domain.EventPublisher) *EventConsumer {
+	return &EventConsumer{
+		stream:      stream,
+		durable:     cfg.NATS.Durable,
+		subject:     cfg.NATS.Subject + ".>",
+		subscribers: subscribers,
+		// Each message is handled like a request and gets the same deadline
+		timeout:     cfg.Server.RequestTimeout,
+		logger:      logger,
+	}
+}
+
+
+func (c *EventConsumer) Start(ctx context.Context) error {
+	consumer, err := c.stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       c.durable,
+		FilterSubject: c.subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
 	})
+	if err != nil {
+		return err
+	}
 	
+	c.logger.Info("Starting event consumer", zap.String("durable", c.durable))
+	
+	c.consume, err = consumer.Consume(c.handle)
+	return err
+}
+
+
+func (c *EventConsumer) Stop() {
+	if c.consume != nil {
+		c.consume.Stop()
+	}
+}
+
+
+func (c *EventConsumer) handle(msg jetstream.Msg) {
+	var event domain.Event
+	if err := json.Unmarshal(msg.Data(), &event); err != nil {
+		c.logger.Error("Failed to unmarshal event", zap.Error(err))
+		msg.Term()
+		return
+	}
+	
+	c.logger.Info("Received event", 
+		zap.String("type", string(event.Type)),
+		zap.String("id", event.ID))
+	
+	if err := c.dispatch(event); err != nil {
+		if err := msg.Nak(); err != nil {
+			c.logger.Error("Failed to nak event", zap.Error(err))
+		}
+		return
+	}
+	
+	if err := msg.Ack(); err != nil {
+		c.logger.Error("Failed to ack event", zap.Error(err))
+	}
+}
+
+
+func (c *EventConsumer) dispatch(event domain.Event) error {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	
+	var errs []error
+	for _, subscriber := range c.subscribers {
+		if err := subscriber.Publish(ctx, event); err != nil {
+			c.logger.Error("Failed to deliver event", 
+				zap.String("type", string(event.Type)),
+				zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+`
+
+const rabbitmqTemplate = `package rabbitmq
+
+import (
+	"context"
+	
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/bootstrap"
+	"{{.Name}}/internal/config"
+)
+
+
+// Module wires the RabbitMQ publisher and the event consumer. Consumed
+// events are handed to everything registered in the "event_subscribers" group
+var Module = fx.Options(
+	fx.Provide(
+		fx.Annotate(
+			NewEventConsumer,
+			fx.ParamTags("", "", "", ` + "`group:\"event_subscribers\"`" + `),
+		),
+		fx.Annotate(
+			NewEventPublisher,
+			fx.ResultTags(` + "`group:\"event_publishers\"`" + `),
+		),
+	),
+	fx.Invoke(
+		DeclareEventTopology,
+		RegisterEventConsumer,
+	),
+)
+
+
+func deadLetterExchange(cfg config.RabbitMQConfig) string {
+	return cfg.Exchange + ".dlx"
+}
+
+
+func deadLetterQueue(cfg config.RabbitMQConfig) string {
+	return cfg.Queue + ".dlq"
+}
+
+
+// declareTopology is idempotent, so it is safe to call after every reconnect
+func declareTopology(ch *amqp.Channel, cfg config.RabbitMQConfig) error {
+	if err := ch.ExchangeDeclare(cfg.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		return err
+	}
+	
+	if err := ch.ExchangeDeclare(deadLetterExchange(cfg), amqp.ExchangeFanout, true, false, false, false, nil); err != nil {
+		return err
+	}
+	
+	if _, err := ch.QueueDeclare(deadLetterQueue(cfg), true, false, false, false, nil); err != nil {
+		return err
+	}
+	
+	if err := ch.QueueBind(deadLetterQueue(cfg), "", deadLetterExchange(cfg), false, nil); err != nil {
+		return err
+	}
+	
+	if _, err := ch.QueueDeclare(cfg.Queue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange": deadLetterExchange(cfg),
+	}); err != nil {
+		return err
+	}
+	
+	return ch.QueueBind(cfg.Queue, "#", cfg.Exchange, false, nil)
+}
+
+
+func DeclareEventTopology(lc fx.Lifecycle, conn *bootstrap.RabbitMQConnection, cfg *config.Config, logger *zap.Logger) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			logger.Info("Initializing Kafka reader", 
-				zap.Strings("brokers", cfg.Brokers), 
-				zap.String("topic", cfg.Topic))
+			ch, err := conn.Channel()
+			if err != nil {
+				return err
+			}
+			defer ch.Close()
+			
+			logger.Info("Declaring RabbitMQ topology", 
+				zap.String("exchange", cfg.RabbitMQ.Exchange),
+				zap.String("queue", cfg.RabbitMQ.Queue))
+			
+			return declareTopology(ch, cfg.RabbitMQ)
+		},
+	})
+}
+
+
+func RegisterEventConsumer(lc fx.Lifecycle, consumer *EventConsumer) {
+	ctx, cancel := context.WithCancel(context.Background())
+	
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			consumer.Start(ctx)
 			return nil
 		},
-		OnStop: func(ctx context.Context) error {
-			logger.Info("Closing Kafka reader")
-			return reader.Close()
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
 		},
 	})
-	
-	return reader
 }
 `
 
-const kafkaUserEventsTemplate = `package kafka
+const rabbitmqEventsTemplate = `package rabbitmq
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sync"
+	"time"
 	
-	"github.com/segmentio/kafka-go"
+	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 	
+	"{{.Name}}/internal/bootstrap"
+	"{{.Name}}/internal/config"
 	"{{.Name}}/internal/domain"
 )
 
 
-type UserEventType string
-
-const (
-	UserCreated UserEventType = "user_created"
-	UserUpdated UserEventType = "user_updated"
-	UserDeleted UserEventType = "user_deleted"
-)
+var ErrPublishNacked = errors.New("rabbitmq: broker did not confirm the message")
 
 
-type UserEvent struct {
-	Type UserEventType ` + "`json:\"type\"`" + `
-	User *domain.User  ` + "`json:\"user\"`" + `
+// EventPublisher publishes domain events to the exchange with the event
+// type as the routing key and waits for the broker to confirm each one
+type EventPublisher struct {
+	conn     *bootstrap.RabbitMQConnection
+	exchange string
+	logger   *zap.Logger
+	
+	mu sync.Mutex
+	ch *amqp.Channel
 }
 
 
-type UserEventPublisher struct {
-	writer *kafka.Writer
-	logger *zap.Logger
+func NewEventPublisher(conn *bootstrap.RabbitMQConnection, cfg *config.Config, logger *zap.Logger) domain.EventPublisher {
+	return &EventPublisher{
+		conn:     conn,
+		exchange: cfg.RabbitMQ.Exchange,
+		logger:   logger,
+	}
 }
 
 
-func NewUserEventPublisher(writer *kafka.Writer, logger *zap.Logger) *UserEventPublisher {
-	return &UserEventPublisher{
-		writer: writer,
-		logger: logger,
+// channel returns a channel in confirm mode, reopening it after a reconnect
+func (p *EventPublisher) channel() (*amqp.Channel, error) {
+	if p.ch != nil && !p.ch.IsClosed() {
+		return p.ch, nil
+	}
+	
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
 	}
+	
+	p.ch = ch
+	return ch, nil
 }
 
 
-func (p *UserEventPublisher) Publish(ctx context.Context, eventType UserEventType, user *domain.User) error {
-	event := UserEvent{
-		Type: eventType,
-		User: user,
-	}
-	
+func (p *EventPublisher) Publish(ctx context.Context, event domain.Event) error {
 	data, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 	
-	err = p.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(user.ID),
-		Value: data,
-	})
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	
+	err = p.publish(ctx, event, data)
 	if err != nil {
-		p.logger.Error("Failed to publish user event", 
-			zap.String("type", string(eventType)),
-			zap.String("user_id", user.ID),
+		p.logger.Error("Failed to publish event", 
+			zap.String("type", string(event.Type)),
+			zap.String("id", event.ID),
 			zap.Error(err))
 		return err
 	}
 	
-	p.logger.Info("Published user event", 
-		zap.String("type", string(eventType)),
-		zap.String("user_id", user.ID))
+	p.logger.Info("Published event", 
+		zap.String("type", string(event.Type)),
+		zap.String("id", event.ID))
 	
 	return nil
 }
 
 
-type UserEventConsumer struct {
-	reader *kafka.Reader
-	logger *zap.Logger
+func (p *EventPublisher) publish(ctx context.Context, event domain.Event, data []byte) error {
+	ch, err := p.channel()
+	if err != nil {
+		return err
+	}
+	
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, p.exchange, string(event.Type), false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID,
+		Timestamp:    time.Now(),
+		Type:         string(event.Type),
+		Body:         data,
+	})
+	if err != nil {
+		return err
+	}
+	
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return ErrPublishNacked
+	}
+	
+	return nil
 }
 
 
-func NewUserEventConsumer(reader *kafka.Reader, logger *zap.Logger) *UserEventConsumer {
-	return &UserEventConsumer{
-		reader: reader,
-		logger: logger,
+// EventConsumer acks an event once every subscriber has handled it. A failed
+// subscriber rejects the message without requeue, so it lands in the
+// dead-letter queue
+type EventConsumer struct {
+	conn        *bootstrap.RabbitMQConnection
+	cfg         config.RabbitMQConfig
+	subscribers []domain.EventPublisher
+	timeout     time.Duration
+	logger      *zap.Logger
+}
+
+
+func NewEventConsumer(conn *bootstrap.RabbitMQConnection, cfg *config.Config, logger *zap.Logger, subscribers []domain.EventPublisher) *EventConsumer {
+	return &EventConsumer{
+		conn:        conn,
+		cfg:         cfg.RabbitMQ,
+		subscribers: subscribers,
+		// Each message is handled like a request and gets the same deadline
+		timeout:     cfg.Server.RequestTimeout,
+		logger:      logger,
 	}
 }
 
 
-func (c *UserEventConsumer) Start(ctx context.Context) {
+func (c *EventConsumer) Start(ctx context.Context) {
 	go func() {
-		c.logger.Info("Starting user event consumer")
+		c.logger.Info("Starting event consumer")
 		
 		for {
+			if err := c.consume(ctx); err != nil {
+				c.logger.Warn("Event consumer interrupted", zap.Error(err))
+			}
+			
 			select {
 			case <-ctx.Done():
 				return
-			default:
-				msg, err := c.reader.ReadMessage(ctx)
-				if err != nil {
-					c.logger.Error("Failed to read message", zap.Error(err))
-					continue
-				}
-				
-				var event UserEvent
-				if err := json.Unmarshal(msg.Value, &event); err != nil {
-					c.logger.Error("Failed to unmarshal user event", zap.Error(err))
-					continue
-				}
-				
-				c.logger.Info("Received user event", 
-					zap.String("type", string(event.Type)),
-					zap.String("user_id", event.User.ID))
-				
-				
-				switch event.Type {
-				case UserCreated:
-					
-				case UserUpdated:
-					
-				case UserDeleted:
-					
-				}
+			case <-time.After(time.Second):
 			}
 		}
 	}()
 }
+
+
+// consume runs until the channel is closed or ctx is cancelled
+func (c *EventConsumer) consume(ctx context.Context) error {
+	ch, err := c.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+	
+	if err := declareTopology(ch, c.cfg); err != nil {
+		return err
+	}
+	
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		return err
+	}
+	
+	deliveries, err := ch.ConsumeWithContext(ctx, c.cfg.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	
+	for msg := range deliveries {
+		c.handle(msg)
+	}
+	
+	return ctx.Err()
+}
+
+
+func (c *EventConsumer) handle(msg amqp.Delivery) {
+	var event domain.Event
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		c.logger.Error("Failed to unmarshal event, moving to dead-letter queue", zap.Error(err))
+		msg.Nack(false, false)
+		return
+	}
+	
+	c.logger.Info("Received event", 
+		zap.String("type", string(event.Type)),
+		zap.String("id", event.ID))
+	
+	if err := c.dispatch(event); err != nil {
+		c.logger.Error("Failed to handle event, moving to dead-letter queue", 
+			zap.String("type", string(event.Type)),
+			zap.String("id", event.ID))
+		if err := msg.Nack(false, false); err != nil {
+			c.logger.Error("Failed to nack event", zap.Error(err))
+		}
+		return
+	}
+	
+	if err := msg.Ack(false); err != nil {
+		c.logger.Error("Failed to ack event", zap.Error(err))
+	}
+}
+
+
+// dispatch does not take the consumer context: a shutdown must not fail
+// the event and move it to the dead-letter queue
+func (c *EventConsumer) dispatch(event domain.Event) error {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	
+	var errs []error
+	for _, subscriber := range c.subscribers {
+		if err := subscriber.Publish(ctx, event); err != nil {
+			c.logger.Error("Failed to deliver event", 
+				zap.String("type", string(event.Type)),
+				zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+`
+
+const messagingTemplate = `package messaging
+
+import (
+	"context"
+	"errors"
+	
+	"go.uber.org/fx"
+	
+	"{{.Name}}/internal/domain"
+)
+
+
+// Module combines every publisher registered in the "event_publishers" group
+// into the single domain.EventPublisher used by the use cases
+var Module = fx.Options(
+	fx.Provide(
+		fx.Annotate(
+			NewEventPublisher,
+			fx.ParamTags(` + "`group:\"event_publishers\"`" + `),
+		),
+	),
+)
+
+
+func NewEventPublisher(publishers []domain.EventPublisher) domain.EventPublisher {
+	if len(publishers) == 1 {
+		return publishers[0]
+	}
+	return multiPublisher(publishers)
+}
+
+
+type multiPublisher []domain.EventPublisher
+
+
+func (m multiPublisher) Publish(ctx context.Context, event domain.Event) error {
+	var errs []error
+	for _, publisher := range m {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+`
+
+const memoryEventPublisherTemplate = `package memory
+
+import (
+	"context"
+	
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/domain"
+)
+
+
+// Module wires the in-process event bus, the default until a broker is
+// selected: published events go straight to the "event_subscribers" group
+var Module = fx.Options(
+	fx.Provide(
+		fx.Annotate(
+			NewEventPublisher,
+			fx.ParamTags("", ` + "`group:\"event_subscribers\"`" + `),
+			fx.ResultTags(` + "`group:\"event_publishers\"`" + `),
+		),
+	),
+)
+
+
+// EventPublisher delivers events to the subscribers synchronously, within
+// the publishing request. Nothing is kept, so events published while the
+// service is down or before a subscriber is registered are lost
+type EventPublisher struct {
+	subscribers []domain.EventPublisher
+	logger      *zap.Logger
+}
+
+
+func NewEventPublisher(logger *zap.Logger, subscribers []domain.EventPublisher) domain.EventPublisher {
+	return &EventPublisher{
+		subscribers: subscribers,
+		logger:      logger,
+	}
+}
+
+
+func (p *EventPublisher) Publish(ctx context.Context, event domain.Event) error {
+	p.logger.Info("Published event", 
+		zap.String("type", string(event.Type)),
+		zap.String("id", event.ID))
+	
+	// As with a broker, a failed subscriber does not fail the publisher
+	for _, subscriber := range p.subscribers {
+		if err := subscriber.Publish(ctx, event); err != nil {
+			p.logger.Error("Failed to deliver event", 
+				zap.String("type", string(event.Type)),
+				zap.Error(err))
+		}
+	}
+	
+	return nil
+}
+`
+
+const memoryEventPublisherTestTemplate = `package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	
+	"go.uber.org/zap"
+	
+	"{{.Name}}/internal/domain"
+	"{{.Name}}/internal/messaging/messagingtest"
+)
+
+
+type failingSubscriber struct{}
+
+
+func (failingSubscriber) Publish(ctx context.Context, event domain.Event) error {
+	return errors.New("subscriber is down")
+}
+
+
+func TestEventPublisherDeliversToSubscribers(t *testing.T) {
+	recorder := messagingtest.NewRecorder()
+	publisher := NewEventPublisher(zap.NewNop(), []domain.EventPublisher{failingSubscriber{}, recorder})
+	
+	if err := publisher.Publish(context.Background(), domain.Event{ID: "id-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	
+	if events := recorder.Events(); len(events) != 1 || events[0].ID != "id-1" {
+		t.Fatalf("expected the event to reach the subscriber after a failed one, got %v", events)
+	}
+}
+`
+
+// messagingRecorderTemplate - publisher для тестов, в граф fx не попадает
+const messagingRecorderTemplate = `package messagingtest
+
+import (
+	"context"
+	"sync"
+	
+	"{{.Name}}/internal/domain"
+)
+
+
+// Recorder is a domain.EventPublisher for tests that keeps every published
+// event. It grows without bound, so it must not be wired into the application
+type Recorder struct {
+	mu     sync.RWMutex
+	events []domain.Event
+}
+
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+
+func (r *Recorder) Publish(ctx context.Context, event domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+
+// Events returns a copy of the events published so far
+func (r *Recorder) Events() []domain.Event {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	events := make([]domain.Event, len(r.events))
+	copy(events, r.events)
+	return events
+}
+`
+
+const messagingTestTemplate = `package messaging
+
+import (
+	"context"
+	"testing"
+	
+	"{{.Name}}/internal/domain"
+	"{{.Name}}/internal/messaging/messagingtest"
+)
+
+
+func TestEventPublisherFansOut(t *testing.T) {
+	first, second := messagingtest.NewRecorder(), messagingtest.NewRecorder()
+	publisher := NewEventPublisher([]domain.EventPublisher{first, second})
+	
+	if err := publisher.Publish(context.Background(), domain.Event{ID: "id-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	
+	for _, recorder := range []*messagingtest.Recorder{first, second} {
+		if events := recorder.Events(); len(events) != 1 || events[0].ID != "id-1" {
+			t.Fatalf("expected the event to reach every publisher, got %v", events)
+		}
+	}
+}
 `
@@ -0,0 +1,493 @@
+package project_templates
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPISpec описывает загруженный OpenAPI документ в виде,
+// удобном для генерации серверного кода в стиле oapi-codegen
+type OpenAPISpec struct {
+	Title      string
+	Document   []byte
+	Schemas    []OpenAPISchema
+	Operations []OpenAPIOperation
+	UsesTime   bool
+}
+
+type OpenAPISchema struct {
+	Name   string
+	GoName string
+	Fields []OpenAPIField
+	// Type is set for schemas that are not objects, e.g. "[]Pet"
+	Type string
+}
+
+type OpenAPIField struct {
+	GoName string
+	GoType string
+	Tag    string
+}
+
+type OpenAPIOperation struct {
+	GoName      string
+	Method      string
+	Path        string
+	EchoPath    string
+	Summary     string
+	PathParams  []OpenAPIParam
+	QueryParams []OpenAPIParam
+	BodyType    string
+}
+
+type OpenAPIParam struct {
+	Name     string
+	GoName   string
+	VarName  string
+	GoType   string
+	Binder   string
+	Required bool
+	Tag      string
+}
+
+type oaDocument struct {
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Paths      map[string]oaPathItem `yaml:"paths"`
+	Components struct {
+		Schemas       map[string]*oaSchema      `yaml:"schemas"`
+		Parameters    map[string]*oaParameter   `yaml:"parameters"`
+		RequestBodies map[string]*oaRequestBody `yaml:"requestBodies"`
+	} `yaml:"components"`
+}
+
+type oaPathItem struct {
+	Parameters []*oaParameter `yaml:"parameters"`
+	Get        *oaOperation   `yaml:"get"`
+	Put        *oaOperation   `yaml:"put"`
+	Post       *oaOperation   `yaml:"post"`
+	Delete     *oaOperation   `yaml:"delete"`
+	Patch      *oaOperation   `yaml:"patch"`
+	Head       *oaOperation   `yaml:"head"`
+	Options    *oaOperation   `yaml:"options"`
+}
+
+type oaOperation struct {
+	OperationID string         `yaml:"operationId"`
+	Summary     string         `yaml:"summary"`
+	Parameters  []*oaParameter `yaml:"parameters"`
+	RequestBody *oaRequestBody `yaml:"requestBody"`
+}
+
+type oaParameter struct {
+	Ref      string    `yaml:"$ref"`
+	Name     string    `yaml:"name"`
+	In       string    `yaml:"in"`
+	Required bool      `yaml:"required"`
+	Schema   *oaSchema `yaml:"schema"`
+}
+
+type oaRequestBody struct {
+	Ref     string `yaml:"$ref"`
+	Content map[string]struct {
+		Schema *oaSchema `yaml:"schema"`
+	} `yaml:"content"`
+}
+
+type oaSchema struct {
+	Ref                  string               `yaml:"$ref"`
+	Type                 oaType               `yaml:"type"`
+	Format               string               `yaml:"format"`
+	Items                *oaSchema            `yaml:"items"`
+	Properties           map[string]*oaSchema `yaml:"properties"`
+	Required             []string             `yaml:"required"`
+	AdditionalProperties yaml.Node            `yaml:"additionalProperties"`
+}
+
+// oaType принимает как "type: string" (3.0), так и "type: [string, 'null']" (3.1)
+type oaType string
+
+func (t *oaType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if item.Value != "null" {
+				*t = oaType(item.Value)
+				return nil
+			}
+		}
+		return nil
+	}
+	*t = oaType(node.Value)
+	return nil
+}
+
+// ParseOpenAPI разбирает OpenAPI 3 документ в формате YAML или JSON
+func ParseOpenAPI(data []byte) (*OpenAPISpec, error) {
+	var doc oaDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, errors.New("only OpenAPI 3 documents are supported")
+	}
+
+	spec := &OpenAPISpec{
+		Title:    doc.Info.Title,
+		Document: data,
+	}
+
+	schemaNames := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		schemaNames = append(schemaNames, name)
+	}
+	sort.Strings(schemaNames)
+
+	for _, name := range schemaNames {
+		schema, err := spec.schema(name, doc.Components.Schemas[name])
+		if err != nil {
+			return nil, err
+		}
+		spec.Schemas = append(spec.Schemas, schema)
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := doc.Paths[path]
+		methods := []struct {
+			name string
+			op   *oaOperation
+		}{
+			{"GET", item.Get}, {"POST", item.Post}, {"PUT", item.Put}, {"PATCH", item.Patch},
+			{"DELETE", item.Delete}, {"HEAD", item.Head}, {"OPTIONS", item.Options},
+		}
+
+		for _, m := range methods {
+			if m.op == nil {
+				continue
+			}
+			op, err := spec.operation(&doc, path, m.name, item.Parameters, m.op)
+			if err != nil {
+				return nil, err
+			}
+			spec.Operations = append(spec.Operations, op)
+		}
+	}
+
+	if len(spec.Operations) == 0 {
+		return nil, errors.New("OpenAPI document has no operations")
+	}
+
+	return spec, nil
+}
+
+// HasParams reports whether any operation binds path or query parameters
+func (s *OpenAPISpec) HasParams() bool {
+	for _, op := range s.Operations {
+		if len(op.PathParams) > 0 || len(op.QueryParams) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *OpenAPISpec) schema(name string, schema *oaSchema) (OpenAPISchema, error) {
+	result := OpenAPISchema{Name: name, GoName: goIdentifier(name)}
+
+	if schema == nil || schema.Ref != "" || len(schema.Properties) == 0 {
+		goType, err := s.goType(schema, true)
+		if err != nil {
+			return result, fmt.Errorf("schema %q: %w", name, err)
+		}
+		result.Type = goType
+		return result, nil
+	}
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, prop := range schema.Required {
+		required[prop] = true
+	}
+
+	props := make([]string, 0, len(schema.Properties))
+	for prop := range schema.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+
+	for _, prop := range props {
+		goType, err := s.goType(schema.Properties[prop], required[prop])
+		if err != nil {
+			return result, fmt.Errorf("schema %q, property %q: %w", name, prop, err)
+		}
+
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+
+		result.Fields = append(result.Fields, OpenAPIField{
+			GoName: goIdentifier(prop),
+			GoType: goType,
+			Tag:    fmt.Sprintf("`json:%q`", tag),
+		})
+	}
+
+	return result, nil
+}
+
+func (s *OpenAPISpec) operation(doc *oaDocument, path, method string, shared []*oaParameter, op *oaOperation) (OpenAPIOperation, error) {
+	name := op.OperationID
+	if name == "" {
+		name = strings.ToLower(method) + " " + path
+	}
+
+	result := OpenAPIOperation{
+		GoName:   goIdentifier(name),
+		Method:   method,
+		Path:     path,
+		EchoPath: echoPath(path),
+		Summary:  op.Summary,
+	}
+
+	params := append(append([]*oaParameter{}, shared...), op.Parameters...)
+	for _, param := range params {
+		param, err := resolveParameter(doc, param)
+		if err != nil {
+			return result, fmt.Errorf("%s %s: %w", method, path, err)
+		}
+
+		goType, binder, err := paramType(param.Schema)
+		if err != nil {
+			return result, fmt.Errorf("%s %s, parameter %q: %w", method, path, param.Name, err)
+		}
+
+		p := OpenAPIParam{
+			Name:     param.Name,
+			GoName:   goIdentifier(param.Name),
+			VarName:  goVariable(param.Name),
+			GoType:   goType,
+			Binder:   binder,
+			Required: param.Required || param.In == "path",
+		}
+
+		switch param.In {
+		case "path":
+			result.PathParams = append(result.PathParams, p)
+		case "query":
+			p.Tag = fmt.Sprintf("`query:%q json:\"%s,omitempty\"`", param.Name, param.Name)
+			result.QueryParams = append(result.QueryParams, p)
+		}
+	}
+
+	body, err := resolveRequestBody(doc, op.RequestBody)
+	if err != nil {
+		return result, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+
+	if body != nil {
+		media, ok := body.Content["application/json"]
+		if ok && media.Schema != nil {
+			if media.Schema.Ref != "" {
+				result.BodyType, err = s.goType(media.Schema, true)
+				if err != nil {
+					return result, fmt.Errorf("%s %s, request body: %w", method, path, err)
+				}
+			} else {
+				inline, err := s.schema(result.GoName+"JSONBody", media.Schema)
+				if err != nil {
+					return result, err
+				}
+				s.Schemas = append(s.Schemas, inline)
+				result.BodyType = inline.GoName
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (s *OpenAPISpec) goType(schema *oaSchema, required bool) (string, error) {
+	if schema == nil {
+		return "interface{}", nil
+	}
+
+	var goType string
+	switch {
+	case schema.Ref != "":
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		if !ok {
+			return "", fmt.Errorf("unsupported $ref %q", schema.Ref)
+		}
+		goType = goIdentifier(name)
+	case schema.Type == "string" && schema.Format == "date-time":
+		s.UsesTime = true
+		goType = "time.Time"
+	case schema.Type == "string" && schema.Format == "binary":
+		return "[]byte", nil
+	case schema.Type == "string":
+		goType = "string"
+	case schema.Type == "integer" && schema.Format == "int32":
+		goType = "int32"
+	case schema.Type == "integer" && schema.Format == "int64":
+		goType = "int64"
+	case schema.Type == "integer":
+		goType = "int"
+	case schema.Type == "number" && schema.Format == "float":
+		goType = "float32"
+	case schema.Type == "number":
+		goType = "float64"
+	case schema.Type == "boolean":
+		goType = "bool"
+	case schema.Type == "array":
+		items, err := s.goType(schema.Items, true)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + items, nil
+	default:
+		valueType := "interface{}"
+		if schema.AdditionalProperties.Kind == yaml.MappingNode {
+			var values oaSchema
+			if err := schema.AdditionalProperties.Decode(&values); err != nil {
+				return "", err
+			}
+			var err error
+			if valueType, err = s.goType(&values, true); err != nil {
+				return "", err
+			}
+		}
+		return "map[string]" + valueType, nil
+	}
+
+	if !required {
+		return "*" + goType, nil
+	}
+	return goType, nil
+}
+
+// paramType возвращает Go тип параметра и метод echo.ValueBinder для него
+func paramType(schema *oaSchema) (string, string, error) {
+	if schema == nil {
+		return "string", "String", nil
+	}
+
+	switch {
+	case schema.Type == "string":
+		return "string", "String", nil
+	case schema.Type == "integer" && schema.Format == "int32":
+		return "int32", "Int32", nil
+	case schema.Type == "integer" && schema.Format == "int64":
+		return "int64", "Int64", nil
+	case schema.Type == "integer":
+		return "int", "Int", nil
+	case schema.Type == "number" && schema.Format == "float":
+		return "float32", "Float32", nil
+	case schema.Type == "number":
+		return "float64", "Float64", nil
+	case schema.Type == "boolean":
+		return "bool", "Bool", nil
+	case schema.Type == "array" && schema.Items != nil:
+		goType, binder, err := paramType(schema.Items)
+		if err != nil || strings.HasPrefix(goType, "[]") {
+			break
+		}
+		return "[]" + goType, binder + "s", nil
+	}
+
+	return "", "", errors.New("only primitive parameters and arrays of primitives are supported")
+}
+
+func resolveParameter(doc *oaDocument, param *oaParameter) (*oaParameter, error) {
+	if param.Ref == "" {
+		return param, nil
+	}
+
+	name, ok := strings.CutPrefix(param.Ref, "#/components/parameters/")
+	if resolved := doc.Components.Parameters[name]; ok && resolved != nil {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("unresolved parameter $ref %q", param.Ref)
+}
+
+func resolveRequestBody(doc *oaDocument, body *oaRequestBody) (*oaRequestBody, error) {
+	if body == nil || body.Ref == "" {
+		return body, nil
+	}
+
+	name, ok := strings.CutPrefix(body.Ref, "#/components/requestBodies/")
+	if resolved := doc.Components.RequestBodies[name]; ok && resolved != nil {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("unresolved requestBody $ref %q", body.Ref)
+}
+
+// echoPath переводит "/users/{id}" в "/users/:id"
+func echoPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + strings.Trim(segment, "{}")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+var goInitialisms = map[string]bool{
+	"API": true, "HTTP": true, "ID": true, "JSON": true, "URL": true, "UUID": true,
+}
+
+// goIdentifier превращает "user-id", "user_id" или "userId" в "UserID"
+func goIdentifier(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, part := range parts {
+		if goInitialisms[strings.ToUpper(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	name := b.String()
+	if name == "" {
+		return "Unnamed"
+	}
+	if unicode.IsDigit(rune(name[0])) {
+		name = "N" + name
+	}
+	return name
+}
+
+func goVariable(s string) string {
+	name := goIdentifier(s)
+	if goInitialisms[name] {
+		name = strings.ToLower(name)
+	} else {
+		runes := []rune(name)
+		runes[0] = unicode.ToLower(runes[0])
+		name = string(runes)
+	}
+
+	if token.IsKeyword(name) {
+		name += "Param"
+	}
+	return name
+}
@@ -0,0 +1,552 @@
+package project_templates
+
+// Шаблоны для OpenAPI спецификации, Swagger UI и spec-first генерации
+
+const openapiSpecTemplate = `openapi: 3.0.3
+info:
+  title: {{.GetProjectName}} API
+  version: 1.0.0
+{{- if .HasDependency "auth"}}
+security:
+  - bearerAuth: []
+{{- end}}
+paths:
+{{- range .Entities}}
+  /api/{{.Route}}:
+    get:
+      operationId: list{{.PluralGoName}}
+      summary: List {{.PluralLabel}}
+      tags: [{{.Table}}]
+      parameters:
+        - $ref: "#/components/parameters/Limit"
+        - $ref: "#/components/parameters/Offset"
+        - $ref: "#/components/parameters/PageToken"
+        - name: sort
+          in: query
+          description: Field to sort by, "-" prefix sorts in descending order
+          schema:
+            type: string
+            enum: [{{range $i, $f := .SortFields}}{{if $i}}, {{end}}{{$f.Name}}, -{{$f.Name}}{{end}}]
+{{- range .FilterFields}}
+        - name: {{.JSONName}}
+          in: query
+          description: Filter by {{.JSONName}}
+          schema:
+            type: {{.OpenAPIType}}
+{{- with .OpenAPIFormat}}
+            format: {{.}}
+{{- end}}
+{{- if .Values}}
+            enum: [{{range $i, $v := .Values}}{{if $i}}, {{end}}{{$v}}{{end}}]
+{{- end}}
+{{- end}}
+      responses:
+        "200":
+          description: A page of {{.PluralLabel}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.GoName}}List"
+        "400":
+          $ref: "#/components/responses/Error"
+{{- if $.HasDependency "auth"}}
+        "401":
+          $ref: "#/components/responses/Error"
+{{- end}}
+{{- if $.HasDependency "rbac"}}
+        "403":
+          $ref: "#/components/responses/Error"
+{{- end}}
+        "500":
+          $ref: "#/components/responses/Error"
+    post:
+      operationId: create{{.GoName}}
+      summary: Create a {{.Label}}
+      tags: [{{.Table}}]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/{{.GoName}}Input"
+      responses:
+        "201":
+          description: Created {{.Label}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.GoName}}"
+        "400":
+          $ref: "#/components/responses/Error"
+{{- if $.HasDependency "auth"}}
+        "401":
+          $ref: "#/components/responses/Error"
+{{- end}}
+{{- if $.HasDependency "rbac"}}
+        "403":
+          $ref: "#/components/responses/Error"
+{{- end}}
+        "409":
+          $ref: "#/components/responses/Error"
+        "500":
+          $ref: "#/components/responses/Error"
+  /api/{{.Route}}/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: get{{.GoName}}
+      summary: Get a {{.Label}} by ID
+      tags: [{{.Table}}]
+      responses:
+        "200":
+          description: {{.GoName}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.GoName}}"
+{{- if $.HasDependency "auth"}}
+        "401":
+          $ref: "#/components/responses/Error"
+{{- end}}
+{{- if $.HasDependency "rbac"}}
+        "403":
+          $ref: "#/components/responses/Error"
+{{- end}}
+        "404":
+          $ref: "#/components/responses/Error"
+        "500":
+          $ref: "#/components/responses/Error"
+    put:
+      operationId: update{{.GoName}}
+      summary: Update a {{.Label}}
+      tags: [{{.Table}}]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/{{.GoName}}Input"
+      responses:
+        "200":
+          description: Updated {{.Label}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.GoName}}"
+        "400":
+          $ref: "#/components/responses/Error"
+{{- if $.HasDependency "auth"}}
+        "401":
+          $ref: "#/components/responses/Error"
+{{- end}}
+{{- if $.HasDependency "rbac"}}
+        "403":
+          $ref: "#/components/responses/Error"
+{{- end}}
+        "404":
+          $ref: "#/components/responses/Error"
+        "409":
+          $ref: "#/components/responses/Error"
+        "500":
+          $ref: "#/components/responses/Error"
+    delete:
+      operationId: delete{{.GoName}}
+      summary: Delete a {{.Label}}
+      tags: [{{.Table}}]
+      responses:
+        "204":
+          description: {{.Title}} deleted
+{{- if $.HasDependency "auth"}}
+        "401":
+          $ref: "#/components/responses/Error"
+{{- end}}
+{{- if $.HasDependency "rbac"}}
+        "403":
+          $ref: "#/components/responses/Error"
+{{- end}}
+        "404":
+          $ref: "#/components/responses/Error"
+        "500":
+          $ref: "#/components/responses/Error"
+{{- end}}
+components:
+  schemas:
+{{- range .Entities}}
+    {{.GoName}}:
+      type: object
+      required: [id{{range .Fields}}, {{.JSONName}}{{end}}{{if .HasTimestamps}}, created_at, updated_at{{end}}]
+      properties:
+        id:
+          type: string
+{{- range .Fields}}
+        {{.JSONName}}:
+          type: {{.OpenAPIType}}
+{{- with .OpenAPIFormat}}
+          format: {{.}}
+{{- end}}
+{{- if .Values}}
+          enum: [{{range $i, $v := .Values}}{{if $i}}, {{end}}{{$v}}{{end}}]
+{{- end}}
+{{- end}}
+{{- if .HasTimestamps}}
+        created_at:
+          type: string
+          format: date-time
+        updated_at:
+          type: string
+          format: date-time
+{{- end}}
+    {{.GoName}}List:
+      type: object
+      required: [items]
+      properties:
+        items:
+          type: array
+          items:
+            $ref: "#/components/schemas/{{.GoName}}"
+        next_page_token:
+          type: string
+          description: Token of the next page, absent on the last page
+    {{.GoName}}Input:
+      type: object
+{{- with .Required}}
+      required: [{{range $i, $f := .}}{{if $i}}, {{end}}{{$f.JSONName}}{{end}}]
+{{- end}}
+      properties:
+{{- range .Fields}}
+        {{.JSONName}}:
+          type: {{.OpenAPIType}}
+{{- with .OpenAPIFormat}}
+          format: {{.}}
+{{- end}}
+{{- if .Values}}
+          enum: [{{range $i, $v := .Values}}{{if $i}}, {{end}}{{$v}}{{end}}]
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .HasGateway}}
+    Error:
+      type: object
+      description: google.rpc.Status of the failed call
+      properties:
+        code:
+          type: integer
+        message:
+          type: string
+        details:
+          type: array
+          items:
+            type: object
+{{- else}}
+    Error:
+      type: object
+      description: RFC 7807 problem details
+      required: [type, title, status]
+      properties:
+        type:
+          type: string
+        title:
+          type: string
+        status:
+          type: integer
+        detail:
+          type: string
+        instance:
+          type: string
+        fields:
+          type: array
+          description: Rejected fields of a validation error or a conflict
+          items:
+            $ref: "#/components/schemas/FieldError"
+    FieldError:
+      type: object
+      required: [field, message]
+      properties:
+        field:
+          type: string
+        message:
+          type: string
+{{- end}}
+  parameters:
+    Limit:
+      name: limit
+      in: query
+      description: Page size
+      schema:
+        type: integer
+        minimum: 0
+        maximum: 100
+        default: 20
+    Offset:
+      name: offset
+      in: query
+      description: Number of items to skip, cannot be combined with page_token
+      schema:
+        type: integer
+        minimum: 0
+    PageToken:
+      name: page_token
+      in: query
+      description: next_page_token of the previous page, valid for the same sort order
+      schema:
+        type: string
+  responses:
+    Error:
+      description: Error
+      content:
+{{- if .HasGateway}}
+        application/json:
+{{- else}}
+        application/problem+json:
+{{- end}}
+          schema:
+            $ref: "#/components/schemas/Error"
+{{- if .HasDependency "auth"}}
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+      bearerFormat: JWT
+{{- end}}
+`
+
+const openapiEmbedTemplate = `package api
+
+import (
+	_ "embed"
+)
+
+// Spec is the OpenAPI description of the HTTP API
+//
+//go:embed openapi.yaml
+var Spec []byte
+`
+
+const echoDocsTemplate = `package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"{{.Name}}/api"
+)
+
+
+const swaggerUIPage = ` + "`" + `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<title>{{.GetProjectName}} API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: "/openapi.yaml", dom_id: "#swagger-ui" });
+		};
+	</script>
+</body>
+</html>` + "`" + `
+
+
+// registerDocs serves the OpenAPI spec and a Swagger UI for it
+func registerDocs(server *echo.Echo) {
+	server.GET("/openapi.yaml", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "application/yaml", api.Spec)
+	})
+
+	server.GET("/docs", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, swaggerUIPage)
+	})
+}
+`
+
+const openapiTypesTemplate = `// Package openapi provides primitives to interact with the OpenAPI HTTP API.
+//
+// Code generated by Golang Initializr from api/openapi.yaml. DO NOT EDIT.
+package openapi
+{{- if .OpenAPI.UsesTime}}
+
+import (
+	"time"
+)
+{{- end}}
+{{- range .OpenAPI.Schemas}}
+
+// {{.GoName}} defines model for {{.Name}}.
+{{- if .Type}}
+type {{.GoName}} {{.Type}}
+{{- else}}
+type {{.GoName}} struct {
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} {{.Tag}}
+{{- end}}
+}
+{{- end}}
+{{- end}}
+{{- range .OpenAPI.Operations}}
+{{- if .QueryParams}}
+
+// {{.GoName}}Params defines parameters for {{.GoName}}.
+type {{.GoName}}Params struct {
+{{- range .QueryParams}}
+	{{.GoName}} {{.GoType}} {{.Tag}}
+{{- end}}
+}
+{{- end}}
+{{- if .BodyType}}
+
+// {{.GoName}}JSONRequestBody defines body for {{.GoName}} for application/json ContentType.
+type {{.GoName}}JSONRequestBody = {{.BodyType}}
+{{- end}}
+{{- end}}
+`
+
+const openapiServerTemplate = `// Package openapi provides primitives to interact with the OpenAPI HTTP API.
+//
+// Code generated by Golang Initializr from api/openapi.yaml. DO NOT EDIT.
+package openapi
+
+import (
+{{- if .OpenAPI.HasParams}}
+	"net/http"
+{{end}}
+	"github.com/labstack/echo/v4"
+)
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+{{- range .OpenAPI.Operations}}
+{{- if .Summary}}
+	// {{.Summary}}
+{{- end}}
+	// ({{.Method}} {{.Path}})
+	{{.GoName}}(ctx echo.Context{{range .PathParams}}, {{.VarName}} {{.GoType}}{{end}}{{if .QueryParams}}, params {{.GoName}}Params{{end}}) error
+{{- end}}
+}
+
+// ServerInterfaceWrapper converts echo contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler ServerInterface
+}
+{{- range .OpenAPI.Operations}}
+
+// {{.GoName}} converts echo context to params.
+func (w *ServerInterfaceWrapper) {{.GoName}}(ctx echo.Context) error {
+{{- range .PathParams}}
+	var {{.VarName}} {{.GoType}}
+{{- end}}
+{{- if .PathParams}}
+	if err := echo.PathParamsBinder(ctx).
+{{- range .PathParams}}
+		Must{{.Binder}}("{{.Name}}", &{{.VarName}}).
+{{- end}}
+		BindError(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+{{- end}}
+{{- if .QueryParams}}
+
+	var params {{.GoName}}Params
+	if err := echo.QueryParamsBinder(ctx).
+{{- range .QueryParams}}
+		{{if .Required}}Must{{end}}{{.Binder}}("{{.Name}}", &params.{{.GoName}}).
+{{- end}}
+		BindError(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+{{- end}}
+
+	return w.Handler.{{.GoName}}(ctx{{range .PathParams}}, {{.VarName}}{{end}}{{if .QueryParams}}, params{{end}})
+}
+{{- end}}
+
+// EchoRouter is an interface shared by echo.Echo and echo.Group.
+type EchoRouter interface {
+	CONNECT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	DELETE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	HEAD(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	OPTIONS(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	PATCH(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	PUT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	TRACE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+}
+
+// RegisterHandlers adds each server route to the EchoRouter.
+func RegisterHandlers(router EchoRouter, si ServerInterface) {
+	RegisterHandlersWithBaseURL(router, si, "")
+}
+
+// RegisterHandlersWithBaseURL registers handlers, and prepends BaseURL to the paths, so that the paths
+// can be served under a prefix.
+func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL string) {
+	wrapper := ServerInterfaceWrapper{
+		Handler: si,
+	}
+{{range .OpenAPI.Operations}}
+	router.{{.Method}}(baseURL+"{{.EchoPath}}", wrapper.{{.GoName}})
+{{- end}}
+}
+`
+
+const openapiHandlerTemplate = `package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/delivery/http/openapi"
+	"{{.Name}}/internal/domain"
+)
+
+
+// APIHandler implements the server generated from api/openapi.yaml.
+// Every operation is a stub: map the request onto the use cases and
+// replace the 501 response with the real one.
+type APIHandler struct {
+{{- range .Entities}}
+	{{.VarName}}UseCase domain.{{.GoName}}UseCase
+{{- end}}
+	logger *zap.Logger
+}
+
+
+var _ openapi.ServerInterface = (*APIHandler)(nil)
+
+
+func NewAPIHandler({{range .Entities}}{{.VarName}}UseCase domain.{{.GoName}}UseCase, {{end}}logger *zap.Logger) *APIHandler {
+	return &APIHandler{
+{{- range .Entities}}
+		{{.VarName}}UseCase: {{.VarName}}UseCase,
+{{- end}}
+		logger: logger,
+	}
+}
+{{- range .OpenAPI.Operations}}
+
+
+// {{.GoName}} handles {{.Method}} {{.Path}}
+func (h *APIHandler) {{.GoName}}(c echo.Context{{range .PathParams}}, {{.VarName}} {{.GoType}}{{end}}{{if .QueryParams}}, params openapi.{{.GoName}}Params{{end}}) error {
+{{- if .BodyType}}
+	var body openapi.{{.GoName}}JSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	{{end}}
+	// TODO: call the use case and map the result onto the response
+	return c.JSON(http.StatusNotImplemented, map[string]string{"error": "{{.GoName}} is not implemented"})
+}
+{{- end}}
+`
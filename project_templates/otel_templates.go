@@ -0,0 +1,326 @@
+package project_templates
+
+// Шаблоны для OpenTelemetry: трейсы и метрики
+
+const configOtelTemplate = `package config
+
+import "time"
+
+
+// OtelConfig selects the telemetry exporter. OTLP endpoint, headers and sampling
+// use the standard OTEL_EXPORTER_OTLP_* and OTEL_TRACES_SAMPLER* variables
+type OtelConfig struct {
+	ServiceName    string
+	Exporter       string
+	MetricInterval time.Duration
+}
+
+
+func NewOtelConfig() OtelConfig {
+	return OtelConfig{
+		ServiceName:    getEnv("OTEL_SERVICE_NAME", getEnv("APP_NAME", "app")),
+		Exporter:       getEnv("OTEL_EXPORTER", "otlp"),
+		MetricInterval: time.Duration(getEnvAsInt("OTEL_METRIC_INTERVAL_MS", 15000)) * time.Millisecond,
+	}
+}`
+
+const bootstrapOtelTemplate = `package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+)
+
+func NewTelemetryResource(cfg *config.Config) (*resource.Resource, error) {
+	return resource.New(context.Background(),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(
+			semconv.ServiceName(cfg.Otel.ServiceName),
+			semconv.DeploymentEnvironment(cfg.App.Environment),
+		),
+	)
+}
+
+// NewTracerProvider installs the global tracer provider and propagator,
+// instrumented HTTP, gRPC, database and broker clients pick them up from otel
+func NewTracerProvider(lc fx.Lifecycle, cfg *config.Config, res *resource.Resource, logger *zap.Logger) (*sdktrace.TracerProvider, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Otel.Exporter {
+	case "otlp":
+		exporter, err = otlptracegrpc.New(context.Background())
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown OTEL_EXPORTER %q, expected otlp or stdout", cfg.Otel.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Tracing enabled", zap.String("exporter", cfg.Otel.Exporter))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			// Flushes spans that are still buffered in the batcher
+			return provider.Shutdown(ctx)
+		},
+	})
+
+	return provider, nil
+}
+
+func NewMeterProvider(lc fx.Lifecycle, cfg *config.Config, res *resource.Resource) (*sdkmetric.MeterProvider, error) {
+	var (
+		exporter sdkmetric.Exporter
+		err      error
+	)
+	switch cfg.Otel.Exporter {
+	case "otlp":
+		exporter, err = otlpmetricgrpc.New(context.Background())
+	case "stdout":
+		exporter, err = stdoutmetric.New()
+	default:
+		return nil, fmt.Errorf("unknown OTEL_EXPORTER %q, expected otlp or stdout", cfg.Otel.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(cfg.Otel.MetricInterval))),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetMeterProvider(provider)
+
+	// Go runtime metrics: goroutines, GC, memory
+	if err := runtime.Start(runtime.WithMeterProvider(provider)); err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return provider.Shutdown(ctx)
+		},
+	})
+
+	return provider, nil
+}
+
+// RegisterTelemetry makes sure the global providers are installed before
+// any other component is constructed
+func RegisterTelemetry(*sdktrace.TracerProvider, *sdkmetric.MeterProvider) {}
+`
+
+const usecaseTracingTemplate = `package usecase
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+
+var tracer = otel.Tracer("{{.Name}}/internal/usecase")
+
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+`
+
+const entityTracingTemplate = `package usecase
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"{{.Name}}/internal/domain"
+)
+{{- with .Entity}}
+
+
+// traced{{.GoName}}UseCase wraps every call of the decorated use case in a span
+type traced{{.GoName}}UseCase struct {
+	next domain.{{.GoName}}UseCase
+}
+
+
+func NewTraced{{.GoName}}UseCase(next domain.{{.GoName}}UseCase) domain.{{.GoName}}UseCase {
+	return &traced{{.GoName}}UseCase{next: next}
+}
+
+
+func (t *traced{{.GoName}}UseCase) Create(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	ctx, span := tracer.Start(ctx, "{{.GoName}}UseCase.Create")
+	err := t.next.Create(ctx, {{.VarName}})
+	if err == nil {
+		span.SetAttributes(attribute.String("{{.Snake}}.id", {{.VarName}}.ID))
+	}
+	endSpan(span, err)
+	return err
+}
+
+
+func (t *traced{{.GoName}}UseCase) GetByID(ctx context.Context, id string) (*domain.{{.GoName}}, error) {
+	ctx, span := tracer.Start(ctx, "{{.GoName}}UseCase.GetByID", trace.WithAttributes(attribute.String("{{.Snake}}.id", id)))
+	{{.VarName}}, err := t.next.GetByID(ctx, id)
+	endSpan(span, err)
+	return {{.VarName}}, err
+}
+
+
+func (t *traced{{.GoName}}UseCase) List(ctx context.Context, query domain.{{.GoName}}ListQuery) (*domain.{{.GoName}}List, error) {
+	ctx, span := tracer.Start(ctx, "{{.GoName}}UseCase.List", trace.WithAttributes(
+		attribute.String("list.sort", query.Sort.String()),
+		attribute.Int("list.limit", query.Limit),
+		attribute.Int("list.offset", query.Offset),
+		attribute.Bool("list.page_token", query.PageToken != "")))
+	result, err := t.next.List(ctx, query)
+	if err == nil {
+		span.SetAttributes(attribute.Int("{{.Table}}.count", len(result.Items)))
+	}
+	endSpan(span, err)
+	return result, err
+}
+
+
+func (t *traced{{.GoName}}UseCase) Update(ctx context.Context, {{.VarName}} *domain.{{.GoName}}) error {
+	ctx, span := tracer.Start(ctx, "{{.GoName}}UseCase.Update", trace.WithAttributes(attribute.String("{{.Snake}}.id", {{.VarName}}.ID)))
+	err := t.next.Update(ctx, {{.VarName}})
+	endSpan(span, err)
+	return err
+}
+
+
+func (t *traced{{.GoName}}UseCase) Delete(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "{{.GoName}}UseCase.Delete", trace.WithAttributes(attribute.String("{{.Snake}}.id", id)))
+	err := t.next.Delete(ctx, id)
+	endSpan(span, err)
+	return err
+}
+{{- end}}
+`
+
+const kafkaTracingTemplate = `package kafka
+
+import (
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+
+var tracer = otel.Tracer("{{.Name}}/internal/messaging/kafka")
+
+
+// headerCarrier lets the OpenTelemetry propagator read and write trace
+// context in Kafka message headers, kafka-go has no instrumentation of its own
+type headerCarrier struct {
+	msg *kafka.Message
+}
+
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if h.Key == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+`
+
+const otelCollectorConfigTemplate = `receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+      http:
+        endpoint: 0.0.0.0:4318
+
+processors:
+  batch:
+
+exporters:
+  otlp/jaeger:
+    endpoint: jaeger:4317
+    tls:
+      insecure: true
+  debug:
+    verbosity: basic
+
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlp/jaeger]
+    metrics:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [debug]
+`
@@ -0,0 +1,596 @@
+package project_templates
+
+// Шаблоны для transactional outbox (PostgreSQL + Kafka)
+
+const outboxMigrationUpTemplate = `CREATE TABLE IF NOT EXISTS outbox (
+    id           BIGSERIAL PRIMARY KEY,
+    aggregate_id TEXT        NOT NULL,
+    event_type   TEXT        NOT NULL,
+    payload      JSONB       NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_published_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
+`
+
+const outboxMigrationDownTemplate = `DROP TABLE IF EXISTS outbox;
+`
+
+const postgresOutboxTemplate = `package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"{{.Name}}/internal/domain"
+)
+
+
+const insertOutboxQuery = "INSERT INTO outbox (aggregate_id, event_type, payload) VALUES ($1, $2, $3)"
+
+
+// withOutbox runs write and stores event in the outbox table in the same
+// transaction, so the event exists if and only if the write is committed.
+// The event is encoded after write, which may fill in a key generated by
+// the database
+func withOutbox(ctx context.Context, pool *pgxpool.Pool, event *domain.Event, write func(tx pgx.Tx) error) error {
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, insertOutboxQuery, event.ID, string(event.Type), payload)
+		return err
+	})
+}
+`
+
+const outboxRelayTemplate = `package outbox
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(
+		NewPostgresStore,
+		NewKafkaPublisher,
+		NewRelay,
+	),
+	fx.Invoke(RegisterRelay),
+)
+
+
+type Message struct {
+	ID          int64
+	AggregateID string
+	EventType   string
+	Payload     []byte
+}
+
+
+type Store interface {
+	// Claim locks up to limit unpublished messages and hands them to publish.
+	// The messages are marked as published only if publish succeeds.
+	Claim(ctx context.Context, limit int, publish func(ctx context.Context, messages []Message) error) (int, error)
+	// Purge deletes up to limit messages published before the given time
+	Purge(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+
+type Publisher interface {
+	Publish(ctx context.Context, messages []Message) error
+}
+
+
+// Relay periodically moves messages from the outbox to the broker and
+// deletes the published ones once they are older than the retention period.
+// Delivery is at-least-once: consumers must tolerate duplicates.
+type Relay struct {
+	store         Store
+	publisher     Publisher
+	interval      time.Duration
+	batchSize     int
+	retention     time.Duration
+	purgeInterval time.Duration
+	lastPurge     time.Time
+	logger        *zap.Logger
+}
+
+
+func NewRelay(store Store, publisher Publisher, cfg *config.Config, logger *zap.Logger) *Relay {
+	return &Relay{
+		store:         store,
+		publisher:     publisher,
+		interval:      cfg.Outbox.PollInterval,
+		batchSize:     cfg.Outbox.BatchSize,
+		retention:     cfg.Outbox.Retention,
+		purgeInterval: cfg.Outbox.PurgeInterval,
+		logger:        logger,
+	}
+}
+
+
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("Failed to relay outbox messages", zap.Error(err))
+		}
+
+		if time.Since(r.lastPurge) >= r.purgeInterval {
+			r.lastPurge = time.Now()
+			if err := r.Purge(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Error("Failed to purge outbox messages", zap.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+
+// Drain publishes batches until the outbox has no pending messages left
+func (r *Relay) Drain(ctx context.Context) error {
+	for {
+		n, err := r.store.Claim(ctx, r.batchSize, r.publisher.Publish)
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			r.logger.Debug("Relayed outbox messages", zap.Int("count", n))
+		}
+
+		if n < r.batchSize {
+			return nil
+		}
+	}
+}
+
+
+// Purge deletes, in batches, the messages published more than the retention
+// period ago, so the outbox does not grow with every write
+func (r *Relay) Purge(ctx context.Context) error {
+	before := time.Now().Add(-r.retention)
+	for {
+		n, err := r.store.Purge(ctx, before, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			r.logger.Debug("Purged outbox messages", zap.Int("count", n))
+		}
+
+		if n < r.batchSize {
+			return nil
+		}
+	}
+}
+
+
+func RegisterRelay(lc fx.Lifecycle, relay *Relay, logger *zap.Logger) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			logger.Info("Starting outbox relay")
+
+			go func() {
+				defer close(done)
+				relay.Run(ctx)
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			logger.Info("Stopping outbox relay")
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+`
+
+const outboxPostgresStoreTemplate = `package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// SKIP LOCKED lets several instances run the relay at once without
+// publishing the same message twice or blocking each other
+const claimOutboxQuery = "SELECT id, aggregate_id, event_type, payload FROM outbox " +
+	"WHERE published_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED"
+
+const markPublishedQuery = "UPDATE outbox SET published_at = now() WHERE id = ANY($1)"
+
+// purgeOutboxQuery deletes in batches to keep the transactions short
+const purgeOutboxQuery = "DELETE FROM outbox WHERE id IN (SELECT id FROM outbox " +
+	"WHERE published_at < $1 ORDER BY published_at LIMIT $2)"
+
+
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+
+func NewPostgresStore(pool *pgxpool.Pool) Store {
+	return &PostgresStore{pool: pool}
+}
+
+
+func (s *PostgresStore) Claim(ctx context.Context, limit int, publish func(ctx context.Context, messages []Message) error) (int, error) {
+	var claimed int
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, claimOutboxQuery, limit)
+		if err != nil {
+			return err
+		}
+
+		messages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Message, error) {
+			var msg Message
+			err := row.Scan(&msg.ID, &msg.AggregateID, &msg.EventType, &msg.Payload)
+			return msg, err
+		})
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		if err := publish(ctx, messages); err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(messages))
+		for _, msg := range messages {
+			ids = append(ids, msg.ID)
+		}
+
+		if _, err := tx.Exec(ctx, markPublishedQuery, ids); err != nil {
+			return err
+		}
+
+		claimed = len(messages)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return claimed, nil
+}
+
+
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time, limit int) (int, error) {
+	tag, err := s.pool.Exec(ctx, purgeOutboxQuery, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+`
+
+const outboxKafkaPublisherTemplate = `package outbox
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+
+func NewKafkaPublisher(writer *kafka.Writer) Publisher {
+	return &KafkaPublisher{writer: writer}
+}
+
+
+func (p *KafkaPublisher) Publish(ctx context.Context, messages []Message) error {
+	batch := make([]kafka.Message, 0, len(messages))
+	for _, msg := range messages {
+		batch = append(batch, kafka.Message{
+			Key:   []byte(msg.AggregateID),
+			Value: msg.Payload,
+			Headers: []kafka.Header{
+				{Key: "event_type", Value: []byte(msg.EventType)},
+			},
+		})
+	}
+
+	return p.writer.WriteMessages(ctx, batch...)
+}
+`
+
+const outboxRelayTestTemplate = `package outbox
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+)
+
+
+// memoryStore stands in for PostgresStore with the same claim and purge semantics
+type memoryStore struct {
+	mu        sync.Mutex
+	messages  []Message
+	published map[int64]time.Time
+}
+
+
+func newMemoryStore(n int) *memoryStore {
+	store := &memoryStore{published: make(map[int64]time.Time)}
+	for i := 1; i <= n; i++ {
+		store.messages = append(store.messages, Message{
+			ID:          int64(i),
+			AggregateID: "user-" + strconv.Itoa(i),
+			EventType:   "user_created",
+			Payload:     []byte("{}"),
+		})
+	}
+	return store
+}
+
+
+func (s *memoryStore) Claim(ctx context.Context, limit int, publish func(ctx context.Context, messages []Message) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var batch []Message
+	for _, msg := range s.messages {
+		if len(batch) == limit {
+			break
+		}
+		if _, ok := s.published[msg.ID]; !ok {
+			batch = append(batch, msg)
+		}
+	}
+
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(ctx, batch); err != nil {
+		return 0, err
+	}
+
+	for _, msg := range batch {
+		s.published[msg.ID] = time.Now()
+	}
+	return len(batch), nil
+}
+
+
+func (s *memoryStore) Purge(ctx context.Context, before time.Time, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int
+	kept := s.messages[:0]
+	for _, msg := range s.messages {
+		publishedAt, ok := s.published[msg.ID]
+		if ok && publishedAt.Before(before) && purged < limit {
+			delete(s.published, msg.ID)
+			purged++
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	s.messages = kept
+	return purged, nil
+}
+
+
+func (s *memoryStore) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.messages)
+}
+
+
+// age moves the publish time of every published message back by d
+func (s *memoryStore) age(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, publishedAt := range s.published {
+		s.published[id] = publishedAt.Add(-d)
+	}
+}
+
+
+func (s *memoryStore) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.messages) - len(s.published)
+}
+
+
+type recordingPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+
+func (p *recordingPublisher) Publish(ctx context.Context, messages []Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+
+func newTestRelay(store Store, publisher Publisher) *Relay {
+	cfg := &config.Config{
+		Outbox: config.OutboxConfig{
+			PollInterval:  time.Millisecond,
+			BatchSize:     2,
+			Retention:     time.Hour,
+			PurgeInterval: time.Minute,
+		},
+	}
+	return NewRelay(store, publisher, cfg, zap.NewNop())
+}
+
+
+func TestRelayDrainPublishesAllPendingMessages(t *testing.T) {
+	store := newMemoryStore(5)
+	publisher := &recordingPublisher{}
+
+	if err := newTestRelay(store, publisher).Drain(context.Background()); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	if got := store.pending(); got != 0 {
+		t.Fatalf("pending messages = %d, want 0", got)
+	}
+
+	if len(publisher.messages) != 5 {
+		t.Fatalf("published %d messages, want 5", len(publisher.messages))
+	}
+
+	for i, msg := range publisher.messages {
+		if msg.ID != int64(i+1) {
+			t.Errorf("message %d has ID %d, want %d", i, msg.ID, i+1)
+		}
+	}
+}
+
+
+func TestRelayDrainDoesNotRepublish(t *testing.T) {
+	store := newMemoryStore(3)
+	publisher := &recordingPublisher{}
+	relay := newTestRelay(store, publisher)
+
+	for i := 0; i < 2; i++ {
+		if err := relay.Drain(context.Background()); err != nil {
+			t.Fatalf("Drain() error = %v", err)
+		}
+	}
+
+	if len(publisher.messages) != 3 {
+		t.Fatalf("published %d messages, want 3", len(publisher.messages))
+	}
+}
+
+
+func TestRelayDrainKeepsMessagesWhenPublishFails(t *testing.T) {
+	store := newMemoryStore(3)
+	publisher := &recordingPublisher{err: errors.New("broker unavailable")}
+
+	err := newTestRelay(store, publisher).Drain(context.Background())
+	if !errors.Is(err, publisher.err) {
+		t.Fatalf("Drain() error = %v, want %v", err, publisher.err)
+	}
+
+	if got := store.pending(); got != 3 {
+		t.Fatalf("pending messages = %d, want 3", got)
+	}
+}
+
+
+func TestRelayPurgeDeletesExpiredMessages(t *testing.T) {
+	store := newMemoryStore(5)
+	relay := newTestRelay(store, &recordingPublisher{})
+	ctx := context.Background()
+
+	if err := relay.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	// Свежие опубликованные сообщения хранятся до конца периода
+	if err := relay.Purge(ctx); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if got := store.size(); got != 5 {
+		t.Fatalf("outbox size = %d, want 5 within the retention period", got)
+	}
+
+	store.age(2 * time.Hour)
+	store.messages = append(store.messages, Message{ID: 6, AggregateID: "user-6", EventType: "user_created", Payload: []byte("{}")})
+
+	if err := relay.Purge(ctx); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if got := store.size(); got != 1 {
+		t.Fatalf("outbox size = %d, want only the unpublished message left", got)
+	}
+	if got := store.pending(); got != 1 {
+		t.Fatalf("pending messages = %d, want 1", got)
+	}
+}
+
+
+func TestRelayRunStopsOnCancel(t *testing.T) {
+	store := newMemoryStore(1)
+	publisher := &recordingPublisher{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		newTestRelay(store, publisher).Run(ctx)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after cancel")
+	}
+}
+`
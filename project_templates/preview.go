@@ -0,0 +1,43 @@
+package project_templates
+
+import (
+	"go/format"
+)
+
+// EntityPreview - то, что дизайнер сущностей показывает для одной сущности
+type EntityPreview struct {
+	Name   string
+	Domain string
+	DDL    string
+}
+
+// PreviewEntities рендерит доменную структуру и DDL миграции тех же шаблонов,
+// что и GenerateProject, чтобы превью не расходилось со сгенерированным проектом
+func PreviewEntities(entities Entities) ([]EntityPreview, error) {
+	if len(entities) == 0 {
+		entities = DefaultEntities()
+	}
+	if err := entities.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &ProjectConfig{Name: "example.com/project", Entities: entities.link()}
+
+	previews := make([]EntityPreview, 0, len(p.Entities))
+	for _, entity := range p.Entities {
+		domain := p.renderEntity("domain", entityDomainTemplate, entity)
+		// Шаблоны не форматируются, в превью код выравниваем как gofmt
+		if formatted, err := format.Source([]byte(domain)); err == nil {
+			domain = string(formatted)
+		}
+		previews = append(previews, EntityPreview{
+			Name:   entity.GoName(),
+			Domain: domain,
+			DDL:    p.renderEntity("migrationup", entityMigrationUpTemplate, entity),
+		})
+	}
+	if p.renderErr != nil {
+		return nil, p.renderErr
+	}
+	return previews, nil
+}
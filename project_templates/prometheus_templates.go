@@ -0,0 +1,691 @@
+package project_templates
+
+// Шаблоны для Prometheus метрик, admin сервера и дашборда Grafana
+
+const configMetricsTemplate = `package config
+
+
+// MetricsConfig is the admin listener serving /metrics, kept off the public port
+type MetricsConfig struct {
+	Host string
+	Port int
+}
+
+
+func NewMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		Host: getEnv("METRICS_HOST", "0.0.0.0"),
+		Port: getEnvAsInt("METRICS_PORT", 8081),
+	}
+}`
+
+const metricsModuleTemplate = `package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(
+		NewRegistry,
+{{- if .HasHTTP}}
+		NewHTTPMetrics,
+{{- end}}
+{{- if .HasDependency "grpc"}}
+		NewGRPCMetrics,
+{{- end}}
+	),
+	fx.Invoke(
+		RegisterAdminServer,
+{{- if .HasDependency "postgres"}}
+		RegisterPgxPoolCollector,
+{{- end}}
+{{- if .HasDependency "redis"}}
+		RegisterRedisPoolCollector,
+{{- end}}
+{{- if .HasDependency "kafka"}}
+		RegisterKafkaLagCollector,
+{{- end}}
+	),
+)
+
+
+// NewRegistry uses a dedicated registry instead of the global one,
+// so only metrics registered here end up on /metrics
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(collectors.WithGoCollectorRuntimeMetrics(
+			collectors.MetricsGC,
+			collectors.MetricsMemory,
+			collectors.MetricsScheduler,
+		)),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+
+func RegisterAdminServer(lc fx.Lifecycle, cfg *config.Config, registry *prometheus.Registry, logger *zap.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry}))
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Metrics.Host, cfg.Metrics.Port),
+		Handler: mux,
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Starting admin server", zap.String("addr", server.Addr))
+
+			go func() {
+				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logger.Error("Failed to start admin server", zap.Error(err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Stopping admin server")
+			return server.Shutdown(ctx)
+		},
+	})
+}
+`
+
+const metricsHTTPTemplate = `package metrics
+
+import (
+{{- if eq .HTTPFramework "fiber"}}
+	"errors"
+{{- end}}
+{{- if or (eq .HTTPFramework "chi") (eq .HTTPFramework "servemux")}}
+	"net/http"
+{{- end}}
+	"strconv"
+	"time"
+
+{{- if eq .HTTPFramework "chi"}}
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+{{- else if eq .HTTPFramework "gin"}}
+	"github.com/gin-gonic/gin"
+{{- else if eq .HTTPFramework "fiber"}}
+	"github.com/gofiber/fiber/v2"
+{{- else if eq .HTTPFramework "echo"}}
+	"github.com/labstack/echo/v4"
+{{- end}}
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+
+// HTTPMetrics records RED metrics: the histogram count is the request rate,
+// the status label gives the error ratio and the buckets the latency
+type HTTPMetrics struct {
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+
+func NewHTTPMetrics(registry *prometheus.Registry) *HTTPMetrics {
+	m := &HTTPMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_server_request_duration_seconds",
+			Help:    "Duration of HTTP requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_server_requests_in_flight",
+			Help: "Number of HTTP requests being served.",
+		}),
+	}
+	registry.MustRegister(m.duration, m.inFlight)
+	return m
+}
+
+
+// observe uses the route pattern rather than the raw path to keep label cardinality bounded
+func (m *HTTPMetrics) observe(method, route string, status int, start time.Time) {
+	if route == "" {
+		route = "unmatched"
+	}
+	m.duration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+}
+{{- if eq .HTTPFramework "echo"}}
+
+
+func (m *HTTPMetrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			m.inFlight.Inc()
+			defer m.inFlight.Dec()
+
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				// The error handler writes the response after the middleware returns
+				status = 500
+				if he, ok := err.(*echo.HTTPError); ok {
+					status = he.Code
+				}
+			}
+			m.observe(c.Request().Method, c.Path(), status, start)
+
+			return err
+		}
+	}
+}
+{{- else if eq .HTTPFramework "chi"}}
+
+
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.observe(r.Method, chi.RouteContext(r.Context()).RoutePattern(), status, start)
+	})
+}
+{{- else if eq .HTTPFramework "gin"}}
+
+
+func (m *HTTPMetrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		c.Next()
+
+		m.observe(c.Request.Method, c.FullPath(), c.Writer.Status(), start)
+	}
+}
+{{- else if eq .HTTPFramework "fiber"}}
+
+
+func (m *HTTPMetrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				status = fe.Code
+			}
+		}
+		m.observe(c.Method(), c.Route().Path, status, start)
+
+		return err
+	}
+}
+{{- else}}
+
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+
+// Middleware must wrap the ServeMux itself: the mux fills r.Pattern while routing
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		m.observe(r.Method, r.Pattern, rec.status, start)
+	})
+}
+{{- end}}
+`
+
+const metricsHTTPTestTemplate = `package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+
+func TestHTTPMetricsObserve(t *testing.T) {
+	m := NewHTTPMetrics(prometheus.NewRegistry())
+
+	m.observe("GET", "/api/users/:id", 200, time.Now())
+	m.observe("GET", "/api/users/:id", 200, time.Now())
+	m.observe("GET", "", 404, time.Now())
+
+	if got := testutil.CollectAndCount(m.duration); got != 2 {
+		t.Fatalf("expected 2 series, got %d", got)
+	}
+
+	for _, labels := range [][]string{{"GET", "/api/users/:id", "200"}, {"GET", "unmatched", "404"}} {
+		if _, err := m.duration.GetMetricWithLabelValues(labels...); err != nil {
+			t.Fatalf("missing series %v: %v", labels, err)
+		}
+	}
+}
+`
+
+const metricsGRPCTemplate = `package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+
+type GRPCMetrics struct {
+	duration *prometheus.HistogramVec
+}
+
+
+func NewGRPCMetrics(registry *prometheus.Registry) *GRPCMetrics {
+	m := &GRPCMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Duration of gRPC calls until completion.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"grpc_service", "grpc_method", "grpc_type", "grpc_code"}),
+	}
+	registry.MustRegister(m.duration)
+	return m
+}
+
+
+func (m *GRPCMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observe(info.FullMethod, "unary", err, start)
+		return resp, err
+	}
+}
+
+
+func (m *GRPCMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observe(info.FullMethod, "stream", err, start)
+		return err
+	}
+}
+
+
+func (m *GRPCMetrics) observe(fullMethod, kind string, err error, start time.Time) {
+	service, method := splitMethod(fullMethod)
+	code := status.Code(err).String()
+	m.duration.WithLabelValues(service, method, kind, code).Observe(time.Since(start).Seconds())
+}
+
+
+// splitMethod turns "/package.Service/Method" into its service and method parts
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+`
+
+const metricsPostgresTemplate = `package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+
+// pgxPoolCollector reads pool statistics on every scrape
+type pgxPoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+}
+
+
+func RegisterPgxPoolCollector(registry *prometheus.Registry, pool *pgxpool.Pool) error {
+	return registry.Register(newPgxPoolCollector(pool))
+}
+
+
+func newPgxPoolCollector(pool *pgxpool.Pool) *pgxPoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("pgxpool_"+name, help, nil, nil)
+	}
+	return &pgxPoolCollector{
+		pool:              pool,
+		acquiredConns:     desc("acquired_conns", "Number of currently acquired connections."),
+		idleConns:         desc("idle_conns", "Number of idle connections."),
+		constructingConns: desc("constructing_conns", "Number of connections being established."),
+		totalConns:        desc("total_conns", "Total number of connections in the pool."),
+		maxConns:          desc("max_conns", "Maximum size of the pool."),
+		acquireCount:      desc("acquire_count_total", "Number of successful acquires."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Total time spent waiting for a connection."),
+		emptyAcquireCount: desc("empty_acquire_count_total", "Acquires that had to wait because the pool was empty."),
+		canceledAcquires:  desc("canceled_acquire_count_total", "Acquires cancelled by their context."),
+	}
+}
+
+
+func (c *pgxPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+
+func (c *pgxPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
+`
+
+const metricsRedisTemplate = `package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+
+type redisPoolCollector struct {
+	client *redis.Client
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+
+func RegisterRedisPoolCollector(registry *prometheus.Registry, client *redis.Client) error {
+	return registry.Register(newRedisPoolCollector(client))
+}
+
+
+func newRedisPoolCollector(client *redis.Client) *redisPoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("redis_pool_"+name, help, nil, nil)
+	}
+	return &redisPoolCollector{
+		client:     client,
+		hits:       desc("hits_total", "Times a free connection was found in the pool."),
+		misses:     desc("misses_total", "Times a free connection was not found in the pool."),
+		timeouts:   desc("timeouts_total", "Times a wait for a connection timed out."),
+		totalConns: desc("total_conns", "Number of connections in the pool."),
+		idleConns:  desc("idle_conns", "Number of idle connections in the pool."),
+		staleConns: desc("stale_conns_total", "Number of stale connections removed from the pool."),
+	}
+}
+
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
+`
+
+const metricsKafkaTemplate = `package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+
+	"{{.Name}}/internal/config"
+)
+
+
+// RegisterKafkaLagCollector exposes how many messages the event consumer is behind.
+// Reader.Stats resets the reader's counters, nothing else in the service reads them
+func RegisterKafkaLagCollector(registry *prometheus.Registry, reader *kafka.Reader, cfg *config.Config) error {
+	return registry.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Number of messages between the committed offset and the end of the partition.",
+		ConstLabels: prometheus.Labels{
+			"topic": cfg.Kafka.Topic,
+			"group": cfg.Kafka.GroupID,
+		},
+	}, func() float64 {
+		return float64(reader.Stats().Lag)
+	}))
+}
+`
+
+const prometheusConfigTemplate = `global:
+  scrape_interval: 15s
+  evaluation_interval: 15s
+
+scrape_configs:
+  - job_name: {{.GetProjectName}}
+    static_configs:
+      - targets: ["app:8081"]
+`
+
+const grafanaDatasourceTemplate = `apiVersion: 1
+
+datasources:
+  - name: Prometheus
+    uid: prometheus
+    type: prometheus
+    access: proxy
+    url: http://prometheus:9090
+    isDefault: true
+`
+
+const grafanaDashboardProviderTemplate = `apiVersion: 1
+
+providers:
+  - name: default
+    folder: ""
+    type: file
+    options:
+      path: /var/lib/grafana/dashboards
+`
+
+const grafanaDashboardTemplate = `{
+  "title": "{{.GetProjectName}}",
+  "uid": "{{.GetProjectName}}-service",
+  "schemaVersion": 39,
+  "version": 1,
+  "refresh": "10s",
+  "time": {"from": "now-1h", "to": "now"},
+  "tags": ["{{.GetProjectName}}"],
+  "panels": [
+    {
+      "id": 1,
+      "type": "timeseries",
+      "title": "HTTP request rate",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 8, "x": 0, "y": 0},
+      "fieldConfig": {"defaults": {"unit": "reqps"}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum by (route) (rate(http_server_request_duration_seconds_count[$__rate_interval]))", "legendFormat": "{{"{{"}}route{{"}}"}}"}
+      ]
+    },
+    {
+      "id": 2,
+      "type": "timeseries",
+      "title": "HTTP error ratio (5xx)",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 8, "x": 8, "y": 0},
+      "fieldConfig": {"defaults": {"unit": "percentunit"}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum(rate(http_server_request_duration_seconds_count{status=~\"5..\"}[$__rate_interval])) / sum(rate(http_server_request_duration_seconds_count[$__rate_interval]))", "legendFormat": "errors"}
+      ]
+    },
+    {
+      "id": 3,
+      "type": "timeseries",
+      "title": "HTTP latency p95",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 8, "x": 16, "y": 0},
+      "fieldConfig": {"defaults": {"unit": "s"}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "histogram_quantile(0.95, sum by (le, route) (rate(http_server_request_duration_seconds_bucket[$__rate_interval])))", "legendFormat": "{{"{{"}}route{{"}}"}}"}
+      ]
+    },
+{{- if .HasDependency "grpc"}}
+    {
+      "id": 4,
+      "type": "timeseries",
+      "title": "gRPC calls by code",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 12, "x": 0, "y": 8},
+      "fieldConfig": {"defaults": {"unit": "reqps"}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "sum by (grpc_method, grpc_code) (rate(grpc_server_handling_seconds_count[$__rate_interval]))", "legendFormat": "{{"{{"}}grpc_method{{"}}"}} {{"{{"}}grpc_code{{"}}"}}"}
+      ]
+    },
+    {
+      "id": 5,
+      "type": "timeseries",
+      "title": "gRPC latency p95",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 12, "x": 12, "y": 8},
+      "fieldConfig": {"defaults": {"unit": "s"}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "histogram_quantile(0.95, sum by (le, grpc_method) (rate(grpc_server_handling_seconds_bucket[$__rate_interval])))", "legendFormat": "{{"{{"}}grpc_method{{"}}"}}"}
+      ]
+    },
+{{- end}}
+{{- if .HasDependency "postgres"}}
+    {
+      "id": 6,
+      "type": "timeseries",
+      "title": "PostgreSQL pool",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 8, "x": 0, "y": 16},
+      "targets": [
+        {"refId": "A", "expr": "pgxpool_acquired_conns", "legendFormat": "acquired"},
+        {"refId": "B", "expr": "pgxpool_idle_conns", "legendFormat": "idle"},
+        {"refId": "C", "expr": "pgxpool_max_conns", "legendFormat": "max"}
+      ]
+    },
+{{- end}}
+{{- if .HasDependency "redis"}}
+    {
+      "id": 7,
+      "type": "timeseries",
+      "title": "Redis pool",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 8, "x": 8, "y": 16},
+      "targets": [
+        {"refId": "A", "expr": "redis_pool_total_conns", "legendFormat": "total"},
+        {"refId": "B", "expr": "redis_pool_idle_conns", "legendFormat": "idle"},
+        {"refId": "C", "expr": "rate(redis_pool_timeouts_total[$__rate_interval])", "legendFormat": "timeouts/s"}
+      ]
+    },
+{{- end}}
+{{- if .HasDependency "kafka"}}
+    {
+      "id": 8,
+      "type": "timeseries",
+      "title": "Kafka consumer lag",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 8, "x": 16, "y": 16},
+      "targets": [
+        {"refId": "A", "expr": "kafka_consumer_lag", "legendFormat": "{{"{{"}}topic{{"}}"}}"}
+      ]
+    },
+{{- end}}
+    {
+      "id": 9,
+      "type": "timeseries",
+      "title": "Goroutines",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 12, "x": 0, "y": 24},
+      "targets": [
+        {"refId": "A", "expr": "go_goroutines", "legendFormat": "goroutines"}
+      ]
+    },
+    {
+      "id": 10,
+      "type": "timeseries",
+      "title": "Heap in use",
+      "datasource": {"type": "prometheus", "uid": "prometheus"},
+      "gridPos": {"h": 8, "w": 12, "x": 12, "y": 24},
+      "fieldConfig": {"defaults": {"unit": "bytes"}, "overrides": []},
+      "targets": [
+        {"refId": "A", "expr": "go_memstats_heap_inuse_bytes", "legendFormat": "heap"}
+      ]
+    }
+  ]
+}
+`
@@ -0,0 +1,392 @@
+package project_templates
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	gengo "google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// compileProto компилирует .proto файлы из sources и генерирует для них
+// *.pb.go (protoc-gen-go) и *_grpc.pb.go, как это сделал бы buf generate
+// с paths=source_relative. Ключи результата относительны outDir
+func compileProto(outDir string, sources map[string]string) (map[string]string, error) {
+	plugin, err := newProtoPlugin(sources, "paths=source_relative")
+	if err != nil {
+		return nil, err
+	}
+	return generateProto(outDir, plugin, true)
+}
+
+// compileProtoPackage генерирует код загруженных .proto файлов в один Go пакет
+// goPackage независимо от их go_package: файлы получают M параметры, а
+// module= кладет их в корень outDir. grpc-gateway для них не генерируется
+func compileProtoPackage(outDir string, sources map[string]string, goPackage string) (map[string]string, error) {
+	plugin, err := newProtoPlugin(sources, protoPackageParameter(sources, goPackage))
+	if err != nil {
+		return nil, err
+	}
+	return generateProto(outDir, plugin, false)
+}
+
+func protoPackageParameter(sources map[string]string, goPackage string) string {
+	params := []string{"module=" + goPackage}
+	for _, name := range sortedKeys(sources) {
+		params = append(params, "M"+name+"="+goPackage+";"+path.Base(goPackage))
+	}
+	return strings.Join(params, ",")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newProtoPlugin компилирует sources и готовит protogen плагин так, как его
+// запустил бы protoc с параметром parameter
+func newProtoPlugin(sources map[string]string, parameter string) (*protogen.Plugin, error) {
+	names := sortedKeys(sources)
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.CompositeResolver{
+			protocompile.WithStandardImports(&protocompile.SourceResolver{
+				Accessor: protocompile.SourceAccessorFromMap(sources),
+			}),
+			// google/api/annotations.proto и другие файлы, уже слинкованные в бинарник
+			protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+				fd, err := protoregistry.GlobalFiles.FindFileByPath(path)
+				if err != nil {
+					return protocompile.SearchResult{}, err
+				}
+				return protocompile.SearchResult{Desc: fd}, nil
+			}),
+		},
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+
+	compiled, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: names,
+		Parameter:      ptr(parameter),
+	}
+	seen := make(map[string]bool)
+	for _, file := range compiled {
+		req.ProtoFile = appendProtoFile(req.ProtoFile, file, seen)
+	}
+
+	plugin, err := protogen.Options{}.New(req)
+	if err != nil {
+		return nil, err
+	}
+	plugin.SupportedFeatures = gengo.SupportedFeatures
+	plugin.SupportedEditionsMinimum = gengo.SupportedEditionsMinimum
+	plugin.SupportedEditionsMaximum = gengo.SupportedEditionsMaximum
+	return plugin, nil
+}
+
+// generateProto запускает protoc-gen-go, gRPC и, если gateway, gateway
+// генераторы над файлами плагина. Ключи результата относительны outDir
+func generateProto(outDir string, plugin *protogen.Plugin, gateway bool) (files map[string]string, err error) {
+	// .proto файлы приходят из запроса: паника генератора на неожиданном
+	// входе должна стать ошибкой, а не уронить обработчик
+	defer func() {
+		if r := recover(); r != nil {
+			files, err = nil, fmt.Errorf("protoc-gen-go: %v", r)
+		}
+	}()
+
+	for _, file := range plugin.Files {
+		if !file.Generate {
+			continue
+		}
+		gengo.GenerateFile(plugin, file)
+		if err := generateGRPCFile(plugin, file); err != nil {
+			return nil, err
+		}
+		if !gateway {
+			continue
+		}
+		if err := generateGatewayFile(plugin, file); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := plugin.Response()
+	if resp.Error != nil {
+		return nil, fmt.Errorf("protoc-gen-go: %s", resp.GetError())
+	}
+
+	files = make(map[string]string, len(resp.File))
+	for _, file := range resp.File {
+		files[path.Join(outDir, file.GetName())] = file.GetContent()
+	}
+	return files, nil
+}
+
+// appendProtoFile добавляет file после всех его зависимостей, как того
+// требует CodeGeneratorRequest
+func appendProtoFile(files []*descriptorpb.FileDescriptorProto, file protoreflect.FileDescriptor, seen map[string]bool) []*descriptorpb.FileDescriptorProto {
+	if seen[file.Path()] {
+		return files
+	}
+	seen[file.Path()] = true
+
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		files = appendProtoFile(files, imports.Get(i).FileDescriptor, seen)
+	}
+	return append(files, protodesc.ToFileDescriptorProto(file))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func generateGRPCFile(plugin *protogen.Plugin, file *protogen.File) error {
+	if len(file.Services) == 0 {
+		return nil
+	}
+
+	for _, service := range file.Services {
+		for _, method := range service.Methods {
+			if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+				return fmt.Errorf("%s: streaming RPCs are not supported", method.Desc.FullName())
+			}
+		}
+	}
+
+	g := plugin.NewGeneratedFile(file.GeneratedFilenamePrefix+"_grpc.pb.go", file.GoImportPath)
+
+	tmpl := template.Must(template.New("grpc").Funcs(template.FuncMap{
+		"ident": func(importPath, name string) string {
+			return g.QualifiedGoIdent(protogen.GoIdent{GoName: name, GoImportPath: protogen.GoImportPath(importPath)})
+		},
+		"qualified": g.QualifiedGoIdent,
+		"comments": func(c protogen.Comments) string {
+			return strings.TrimSuffix(c.String(), "\n")
+		},
+		"unexport": unexport,
+	}).Parse(grpcStubTemplate))
+
+	return tmpl.Execute(g, file)
+}
+
+func unexport(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+type gatewayService struct {
+	*protogen.Service
+	Methods []gatewayMethod
+}
+
+type gatewayMethod struct {
+	Service    *protogen.Service
+	Method     *protogen.Method
+	HTTPMethod string
+	Path       string
+	Body       string
+	PathParams []gatewayParam
+	// Ops и Pool - скомпилированный шаблон пути для runtime.NewPattern
+	Ops  []int
+	Pool []string
+}
+
+type gatewayParam struct {
+	Name    string
+	GoName  string
+	Convert string
+}
+
+// gatewayConverters maps scalar kinds to grpc-gateway runtime parsers
+var gatewayConverters = map[protoreflect.Kind]string{
+	protoreflect.StringKind:   "String",
+	protoreflect.BoolKind:     "Bool",
+	protoreflect.BytesKind:    "Bytes",
+	protoreflect.Int32Kind:    "Int32",
+	protoreflect.Sint32Kind:   "Int32",
+	protoreflect.Sfixed32Kind: "Int32",
+	protoreflect.Int64Kind:    "Int64",
+	protoreflect.Sint64Kind:   "Int64",
+	protoreflect.Sfixed64Kind: "Int64",
+	protoreflect.Uint32Kind:   "Uint32",
+	protoreflect.Fixed32Kind:  "Uint32",
+	protoreflect.Uint64Kind:   "Uint64",
+	protoreflect.Fixed64Kind:  "Uint64",
+	protoreflect.FloatKind:    "Float32",
+	protoreflect.DoubleKind:   "Float64",
+}
+
+// generateGatewayFile генерирует *.pb.gw.go для методов с google.api.http
+// правилами. Поддерживаются unary RPC, body "*" или без body и пути из
+// литералов и простых переменных вида {id}
+func generateGatewayFile(plugin *protogen.Plugin, file *protogen.File) error {
+	var services []gatewayService
+	for _, service := range file.Services {
+		gs := gatewayService{Service: service}
+		for _, method := range service.Methods {
+			rule, err := httpRule(method)
+			if err != nil {
+				return err
+			}
+			if rule == nil {
+				continue
+			}
+
+			gm, err := newGatewayMethod(service, method, rule)
+			if err != nil {
+				return fmt.Errorf("%s: %w", method.Desc.FullName(), err)
+			}
+			gs.Methods = append(gs.Methods, gm)
+		}
+		if len(gs.Methods) > 0 {
+			services = append(services, gs)
+		}
+	}
+	if len(services) == 0 {
+		return nil
+	}
+
+	g := plugin.NewGeneratedFile(file.GeneratedFilenamePrefix+".pb.gw.go", file.GoImportPath)
+
+	tmpl := template.Must(template.New("gateway").Funcs(template.FuncMap{
+		"ident": func(importPath, name string) string {
+			return g.QualifiedGoIdent(protogen.GoIdent{GoName: name, GoImportPath: protogen.GoImportPath(importPath)})
+		},
+		"qualified": g.QualifiedGoIdent,
+	}).Parse(grpcGatewayStubTemplate))
+
+	return tmpl.Execute(g, struct {
+		File     *protogen.File
+		Services []gatewayService
+	}{file, services})
+}
+
+// httpRule возвращает google.api.http правило метода или nil. Опции
+// пересобираются через Marshal, чтобы расширение было распознано
+// независимо от того, как его сохранил компилятор
+func httpRule(method *protogen.Method) (*annotations.HttpRule, error) {
+	opts, ok := method.Desc.Options().(proto.Message)
+	if !ok || opts == nil {
+		return nil, nil
+	}
+
+	data, err := proto.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	var parsed descriptorpb.MethodOptions
+	if err := proto.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	if !proto.HasExtension(&parsed, annotations.E_Http) {
+		return nil, nil
+	}
+	return proto.GetExtension(&parsed, annotations.E_Http).(*annotations.HttpRule), nil
+}
+
+func newGatewayMethod(service *protogen.Service, method *protogen.Method, rule *annotations.HttpRule) (gatewayMethod, error) {
+	gm := gatewayMethod{Service: service, Method: method, Body: rule.GetBody()}
+
+	if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+		return gm, fmt.Errorf("streaming RPCs are not supported by the gateway")
+	}
+	if len(rule.GetAdditionalBindings()) > 0 {
+		return gm, fmt.Errorf("additional_bindings are not supported")
+	}
+	if gm.Body != "" && gm.Body != "*" {
+		return gm, fmt.Errorf("body %q is not supported, use \"*\" or omit it", gm.Body)
+	}
+
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		gm.HTTPMethod, gm.Path = "GET", pattern.Get
+	case *annotations.HttpRule_Put:
+		gm.HTTPMethod, gm.Path = "PUT", pattern.Put
+	case *annotations.HttpRule_Post:
+		gm.HTTPMethod, gm.Path = "POST", pattern.Post
+	case *annotations.HttpRule_Delete:
+		gm.HTTPMethod, gm.Path = "DELETE", pattern.Delete
+	case *annotations.HttpRule_Patch:
+		gm.HTTPMethod, gm.Path = "PATCH", pattern.Patch
+	case *annotations.HttpRule_Custom:
+		gm.HTTPMethod, gm.Path = pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	default:
+		return gm, fmt.Errorf("http rule has no pattern")
+	}
+
+	if !strings.HasPrefix(gm.Path, "/") || strings.Contains(gm.Path, ":") {
+		return gm, fmt.Errorf("unsupported path template %q", gm.Path)
+	}
+
+	// Коды операций из grpc-gateway/v2/internal/utilities: OpLitPush = 2,
+	// OpPush = 1, OpConcatN = 4, OpCapture = 5
+	pool := make(map[string]int)
+	intern := func(s string) int {
+		if idx, ok := pool[s]; ok {
+			return idx
+		}
+		pool[s] = len(gm.Pool)
+		gm.Pool = append(gm.Pool, s)
+		return pool[s]
+	}
+
+	for _, segment := range strings.Split(strings.TrimPrefix(gm.Path, "/"), "/") {
+		if !strings.HasPrefix(segment, "{") {
+			if segment == "" || strings.ContainsAny(segment, "{}*") {
+				return gm, fmt.Errorf("unsupported path template %q", gm.Path)
+			}
+			gm.Ops = append(gm.Ops, 2, intern(segment))
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		field := findField(method.Input, name)
+		if field == nil {
+			return gm, fmt.Errorf("path variable %q does not match a field of %s", name, method.Input.Desc.Name())
+		}
+		convert, ok := gatewayConverters[field.Desc.Kind()]
+		if !ok || field.Desc.IsList() {
+			return gm, fmt.Errorf("path variable %q must be a scalar field", name)
+		}
+
+		gm.PathParams = append(gm.PathParams, gatewayParam{Name: name, GoName: field.GoName, Convert: convert})
+		gm.Ops = append(gm.Ops, 1, 0, 4, 1, 5, intern(name))
+	}
+
+	return gm, nil
+}
+
+func findField(message *protogen.Message, name string) *protogen.Field {
+	for _, field := range message.Fields {
+		if string(field.Desc.Name()) == name {
+			return field
+		}
+	}
+	return nil
+}
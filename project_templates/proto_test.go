@@ -0,0 +1,83 @@
+package project_templates
+
+import (
+	"flag"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestCompileProtoGolden pins the stubs generated for the built-in entity
+// protos. protoc-gen-go is used through internal_gengo, which is not a
+// supported API, so a protobuf upgrade that changes its output shows up here.
+// Review the diff and run go test -update to accept it
+func TestCompileProtoGolden(t *testing.T) {
+	p := &ProjectConfig{Name: "example.com/demo", Dependencies: []string{"http", "grpc", "grpc-gateway"}}
+	files, err := p.GenerateProject()
+	if err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+
+	var stubs int
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".pb.go") && !strings.HasSuffix(name, ".pb.gw.go") {
+			continue
+		}
+		stubs++
+
+		golden := filepath.Join("testdata", "proto", path.Base(name)+".golden")
+		if *update {
+			if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(golden, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("%s: %v, run go test -update to create it", name, err)
+		}
+		if content != string(want) {
+			t.Errorf("%s differs from %s", name, golden)
+		}
+	}
+	if stubs == 0 {
+		t.Fatal("expected gRPC stubs to be generated")
+	}
+}
+
+func TestGenerateProjectReturnsProtoErrors(t *testing.T) {
+	spec, err := ParseProto(map[string]string{"greeter.proto": `syntax = "proto3";
+
+package greeter.v1;
+
+message HelloRequest {
+  string name = 1;
+}
+
+message HelloReply {
+  string message = 1;
+}
+
+service Greeter {
+  rpc SayHello(HelloRequest) returns (HelloReply);
+}
+`})
+	if err != nil {
+		t.Fatalf("ParseProto() error = %v", err)
+	}
+	// Исходник, который не компилируется, не должен ронять генерацию
+	spec.Sources["greeter.proto"] = `syntax = "proto3"; message {`
+
+	p := &ProjectConfig{Name: "example.com/demo", Dependencies: []string{"http", "grpc"}, Proto: spec}
+	if _, err := p.GenerateProject(); err == nil || !strings.Contains(err.Error(), "compile proto") {
+		t.Fatalf("expected the proto compile error to be returned, got %v", err)
+	}
+}
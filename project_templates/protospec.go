@@ -0,0 +1,684 @@
+package project_templates
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoSpec описывает загруженные .proto файлы для gRPC-first генерации:
+// сообщения и enum становятся доменными типами, сервисы - use case
+// интерфейсами, а сервера в internal/delivery/grpc переводят вызовы в них
+type ProtoSpec struct {
+	// Sources - исходники по путям, под которыми их видят import
+	Sources map[string]string
+	Files   []ProtoFile
+	// GoogleAPIs - файлы импортируют google/api/*.proto, Go код которых
+	// живет в genproto
+	GoogleAPIs bool
+	// Wrappers - использованные google.protobuf.*Value
+	Wrappers []ProtoWrapper
+
+	helpers map[string]bool
+	// domainNames - имена верхнего уровня пакета domain и их источник
+	domainNames map[string]string
+}
+
+type ProtoFile struct {
+	Path string
+	// Snake - основа имен сгенерированных файлов: "shop/v1/order.proto" -> "order"
+	Snake    string
+	Enums    []ProtoEnum
+	Messages []ProtoMessage
+	Services []ProtoService
+	UsesTime bool
+}
+
+type ProtoEnum struct {
+	// GoName - доменный тип, PBName - тип protoc-gen-go в пакете grpc
+	GoName   string
+	PBName   string
+	Comment  string
+	Values   []ProtoEnumValue
+	Converts string
+}
+
+type ProtoEnumValue struct {
+	Const  string
+	Number int32
+}
+
+type ProtoMessage struct {
+	GoName   string
+	PBName   string
+	Comment  string
+	Fields   []ProtoMessageField
+	Oneofs   []ProtoOneof
+	Converts string
+}
+
+type ProtoMessageField struct {
+	GoName string
+	PBName string
+	Type   string
+	JSON   string
+	// ToDomain и FromDomain - выражения над сообщением m и доменной
+	// структурой v. У полей oneof FromDomain пустой, их заполняет switch
+	ToDomain   string
+	FromDomain string
+}
+
+type ProtoOneof struct {
+	PBName  string
+	Options []ProtoOneofOption
+}
+
+type ProtoOneofOption struct {
+	// Cond выбирает вариант по доменному значению
+	Cond    string
+	Wrapper string
+	PBName  string
+	Value   string
+}
+
+type ProtoService struct {
+	GoName  string
+	VarName string
+	Snake   string
+	Comment string
+	Methods []ProtoMethod
+	// UsesEmpty - сервер принимает или возвращает google.protobuf.Empty
+	UsesEmpty bool
+}
+
+type ProtoMethod struct {
+	GoName  string
+	Comment string
+	// Input и Output - доменные типы, пусто для google.protobuf.Empty
+	Input    string
+	Output   string
+	PBInput  string
+	PBOutput string
+	// ToDomain и FromDomain - функции преобразования запроса и ответа
+	ToDomain   string
+	FromDomain string
+}
+
+// ProtoWrapper - google.protobuf.*Value, в домене указатель на скаляр
+type ProtoWrapper struct {
+	Name     string
+	Type     string
+	Ctor     string
+	Converts string
+}
+
+// protoPlaceholderPackage - Go пакет для проверки .proto файлов до того,
+// как известно имя проекта. На имена типов он не влияет
+const protoPlaceholderPackage = "example.com/project/internal/delivery/grpc"
+
+var protoScalarTypes = map[protoreflect.Kind]string{
+	protoreflect.StringKind:   "string",
+	protoreflect.BoolKind:     "bool",
+	protoreflect.BytesKind:    "[]byte",
+	protoreflect.Int32Kind:    "int32",
+	protoreflect.Sint32Kind:   "int32",
+	protoreflect.Sfixed32Kind: "int32",
+	protoreflect.Int64Kind:    "int64",
+	protoreflect.Sint64Kind:   "int64",
+	protoreflect.Sfixed64Kind: "int64",
+	protoreflect.Uint32Kind:   "uint32",
+	protoreflect.Fixed32Kind:  "uint32",
+	protoreflect.Uint64Kind:   "uint64",
+	protoreflect.Fixed64Kind:  "uint64",
+	protoreflect.FloatKind:    "float32",
+	protoreflect.DoubleKind:   "float64",
+}
+
+var protoWrappers = map[protoreflect.FullName]ProtoWrapper{
+	"google.protobuf.StringValue": {Name: "StringValue", Type: "string", Ctor: "String"},
+	"google.protobuf.BoolValue":   {Name: "BoolValue", Type: "bool", Ctor: "Bool"},
+	"google.protobuf.BytesValue":  {Name: "BytesValue", Type: "[]byte", Ctor: "Bytes"},
+	"google.protobuf.Int32Value":  {Name: "Int32Value", Type: "int32", Ctor: "Int32"},
+	"google.protobuf.Int64Value":  {Name: "Int64Value", Type: "int64", Ctor: "Int64"},
+	"google.protobuf.UInt32Value": {Name: "UInt32Value", Type: "uint32", Ctor: "UInt32"},
+	"google.protobuf.UInt64Value": {Name: "UInt64Value", Type: "uint64", Ctor: "UInt64"},
+	"google.protobuf.FloatValue":  {Name: "FloatValue", Type: "float32", Ctor: "Float"},
+	"google.protobuf.DoubleValue": {Name: "DoubleValue", Type: "float64", Ctor: "Double"},
+}
+
+const protoEmpty protoreflect.FullName = "google.protobuf.Empty"
+
+// ParseProto разбирает загруженные .proto файлы. Ключи sources - пути,
+// под которыми файлы импортируют друг друга
+func ParseProto(sources map[string]string) (*ProtoSpec, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("no .proto files uploaded")
+	}
+
+	// Все файлы генерируются в один Go пакет internal/delivery/grpc
+	bases := make(map[string]string)
+	for _, name := range sortedKeys(sources) {
+		if path.Ext(name) != ".proto" {
+			return nil, fmt.Errorf("%s: expected a .proto file", name)
+		}
+		base := path.Base(name)
+		if other, ok := bases[base]; ok {
+			return nil, fmt.Errorf("%s and %s have the same file name", other, name)
+		}
+		bases[base] = name
+	}
+
+	plugin, err := newProtoPlugin(sources, protoPackageParameter(sources, protoPlaceholderPackage))
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &ProtoSpec{
+		Sources:     sources,
+		helpers:     make(map[string]bool),
+		domainNames: map[string]string{"ErrNotImplemented": "use case stubs"},
+	}
+	wrappers := make(map[protoreflect.FullName]bool)
+	pbNames := make(map[string]string)
+
+	for _, file := range plugin.Files {
+		if !file.Generate {
+			continue
+		}
+		for _, dep := range file.Proto.GetDependency() {
+			if strings.HasPrefix(dep, "google/api/") {
+				spec.GoogleAPIs = true
+			}
+		}
+
+		b := &protoBuilder{spec: spec, file: &ProtoFile{
+			Path:  file.Desc.Path(),
+			Snake: snakeCase(strings.TrimSuffix(path.Base(file.Desc.Path()), ".proto")),
+		}, wrappers: wrappers}
+
+		for _, enum := range file.Enums {
+			if err := b.enum(enum); err != nil {
+				return nil, err
+			}
+		}
+		for _, message := range file.Messages {
+			if err := b.message(message); err != nil {
+				return nil, err
+			}
+		}
+		for _, service := range file.Services {
+			if err := b.service(service); err != nil {
+				return nil, err
+			}
+		}
+		spec.Files = append(spec.Files, *b.file)
+
+		// Сообщения из разных proto пакетов попадают в один Go пакет
+		for _, name := range b.pbNames {
+			if other, ok := pbNames[name]; ok && other != file.Desc.Path() {
+				return nil, fmt.Errorf("%s and %s both declare %s, they are generated into one Go package", other, file.Desc.Path(), name)
+			}
+			pbNames[name] = file.Desc.Path()
+		}
+	}
+
+	names := make([]string, 0, len(wrappers))
+	for name := range wrappers {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w := protoWrappers[protoreflect.FullName(name)]
+		w.Converts = unexport(w.Name)
+		spec.Wrappers = append(spec.Wrappers, w)
+	}
+
+	// Ошибки protoc-gen-go и gRPC генератора, например streaming RPC,
+	// должны всплыть при загрузке, а не при генерации проекта
+	if _, err := generateProto("", plugin, false); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// StructFields возвращает поля доменной структуры, выровненные как после gofmt
+func (m ProtoMessage) StructFields() []StructField {
+	fields := make([]StructField, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		fields = append(fields, StructField{Name: f.GoName, Type: f.Type, JSON: f.JSON})
+	}
+	return alignStructFields(fields)
+}
+
+// Paths - пути загруженных файлов в порядке сортировки
+func (s *ProtoSpec) Paths() []string {
+	return sortedKeys(s.Sources)
+}
+
+func (s *ProtoSpec) Services() []ProtoService {
+	var services []ProtoService
+	for _, file := range s.Files {
+		services = append(services, file.Services...)
+	}
+	return services
+}
+
+func (s *ProtoSpec) Enums() []ProtoEnum {
+	var enums []ProtoEnum
+	for _, file := range s.Files {
+		enums = append(enums, file.Enums...)
+	}
+	return enums
+}
+
+func (s *ProtoSpec) Messages() []ProtoMessage {
+	var messages []ProtoMessage
+	for _, file := range s.Files {
+		messages = append(messages, file.Messages...)
+	}
+	return messages
+}
+
+// Uses сообщает, нужен ли convert.go вспомогательный код name
+func (s *ProtoSpec) Uses(name string) bool {
+	return s.helpers[name]
+}
+
+// conflicts проверяет, что доменные типы из .proto файлов не совпадают с
+// тем, что генерируется для сущностей в том же пакете domain
+func (s *ProtoSpec) conflicts(entities Entities) error {
+	p := &ProjectConfig{Name: "example.com/project", Entities: entities.link()}
+
+	sources := []string{eventsDomainTemplate, p.render("domainerrors", domainErrorsTemplate), listDomainTemplate, validationDomainTemplate}
+	for _, entity := range p.Entities {
+		sources = append(sources, p.renderEntity("domain", entityDomainTemplate, entity))
+	}
+	for _, src := range sources {
+		names, err := declaredNames(src)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if origin, ok := s.domainNames[name]; ok {
+				return fmt.Errorf("%s maps to domain.%s, which is already generated for the entities: rename or remove the entity", origin, name)
+			}
+		}
+	}
+	return nil
+}
+
+// declaredNames возвращает имена верхнего уровня Go файла
+func declaredNames(src string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names = append(names, decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// protoBuilder собирает ProtoFile из одного файла protogen
+type protoBuilder struct {
+	spec     *ProtoSpec
+	file     *ProtoFile
+	wrappers map[protoreflect.FullName]bool
+	pbNames  []string
+}
+
+// declare регистрирует доменное имя, origin - полное proto имя для ошибок
+func (b *protoBuilder) declare(name string, origin protoreflect.FullName) error {
+	if other, ok := b.spec.domainNames[name]; ok {
+		return fmt.Errorf("%s and %s both map to domain.%s", other, origin, name)
+	}
+	b.spec.domainNames[name] = string(origin)
+	return nil
+}
+
+// protoDomainName: вложенный тип "Order_Item" становится "OrderItem"
+func protoDomainName(ident protogen.GoIdent) string {
+	return strings.ReplaceAll(ident.GoName, "_", "")
+}
+
+func protoComment(comments protogen.Comments) string {
+	return strings.TrimSuffix(comments.String(), "\n")
+}
+
+func (b *protoBuilder) enum(enum *protogen.Enum) error {
+	e := ProtoEnum{
+		GoName:  protoDomainName(enum.GoIdent),
+		PBName:  enum.GoIdent.GoName,
+		Comment: protoComment(enum.Comments.Leading),
+	}
+	e.Converts = unexport(e.GoName)
+	if err := b.declare(e.GoName, enum.Desc.FullName()); err != nil {
+		return err
+	}
+	b.pbNames = append(b.pbNames, enum.GoIdent.GoName)
+
+	// STATUS_ACTIVE в enum Status -> StatusActive
+	prefix := strings.ToUpper(snakeCase(string(enum.Desc.Name()))) + "_"
+	for _, value := range enum.Values {
+		name := string(value.Desc.Name())
+		if trimmed := strings.TrimPrefix(name, prefix); trimmed != "" {
+			name = trimmed
+		}
+		v := ProtoEnumValue{Const: e.GoName + goName(strings.ToLower(name)), Number: int32(value.Desc.Number())}
+		if err := b.declare(v.Const, value.Desc.FullName()); err != nil {
+			return err
+		}
+		b.pbNames = append(b.pbNames, value.GoIdent.GoName)
+		e.Values = append(e.Values, v)
+	}
+
+	b.file.Enums = append(b.file.Enums, e)
+	return nil
+}
+
+func (b *protoBuilder) message(message *protogen.Message) error {
+	if message.Desc.IsMapEntry() {
+		return nil
+	}
+
+	m := ProtoMessage{
+		GoName:  protoDomainName(message.GoIdent),
+		PBName:  message.GoIdent.GoName,
+		Comment: protoComment(message.Comments.Leading),
+	}
+	m.Converts = unexport(m.GoName)
+	if err := b.declare(m.GoName, message.Desc.FullName()); err != nil {
+		return err
+	}
+	b.pbNames = append(b.pbNames, message.GoIdent.GoName)
+
+	fieldNames := make(map[string]bool)
+	for _, field := range message.Fields {
+		f, err := b.field(field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Desc.FullName(), err)
+		}
+		if fieldNames[f.GoName] {
+			return fmt.Errorf("%s: field name %s is used twice", message.Desc.FullName(), f.GoName)
+		}
+		fieldNames[f.GoName] = true
+		m.Fields = append(m.Fields, f)
+	}
+
+	for _, oneof := range message.Oneofs {
+		if oneof.Desc.IsSynthetic() {
+			continue
+		}
+		b.pbNames = append(b.pbNames, oneof.GoIdent.GoName)
+		o := ProtoOneof{PBName: oneof.GoName}
+		for _, field := range oneof.Fields {
+			value, err := b.value(field, field.Desc.Kind())
+			if err != nil {
+				return fmt.Errorf("%s: %w", field.Desc.FullName(), err)
+			}
+			b.pbNames = append(b.pbNames, field.GoIdent.GoName)
+			v := "v." + goName(string(field.Desc.Name()))
+			o.Options = append(o.Options, ProtoOneofOption{
+				Cond:    protoPresent(value.typ, v),
+				Wrapper: field.GoIdent.GoName,
+				PBName:  field.GoName,
+				Value:   value.from(v),
+			})
+		}
+		m.Oneofs = append(m.Oneofs, o)
+	}
+
+	b.file.Messages = append(b.file.Messages, m)
+
+	for _, enum := range message.Enums {
+		if err := b.enum(enum); err != nil {
+			return err
+		}
+	}
+	for _, nested := range message.Messages {
+		if err := b.message(nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protoValue - доменный тип значения и функции его преобразования,
+// пустая функция означает, что значение переносится как есть
+type protoValue struct {
+	typ        string
+	toDomain   string
+	fromDomain string
+}
+
+func (v protoValue) to(expr string) string {
+	if v.toDomain == "" {
+		return expr
+	}
+	return v.toDomain + "(" + expr + ")"
+}
+
+func (v protoValue) from(expr string) string {
+	if v.fromDomain == "" {
+		return expr
+	}
+	return v.fromDomain + "(" + expr + ")"
+}
+
+// protoPresent - условие, что вариант oneof задан в доменной структуре
+func protoPresent(typ, expr string) string {
+	switch {
+	case typ == "string":
+		return expr + ` != ""`
+	case typ == "bool":
+		return expr
+	case typ == "[]byte", strings.HasPrefix(typ, "*"):
+		return expr + " != nil"
+	case typ == "time.Time":
+		return "!" + expr + ".IsZero()"
+	}
+	return expr + " != 0"
+}
+
+// value описывает одиночное значение поля: скаляр, enum или сообщение
+func (b *protoBuilder) value(field *protogen.Field, kind protoreflect.Kind) (protoValue, error) {
+	switch kind {
+	case protoreflect.EnumKind:
+		enum := field.Enum
+		if !b.local(enum.Desc.ParentFile()) {
+			return protoValue{}, fmt.Errorf("enum %s is not declared in the uploaded files", enum.Desc.FullName())
+		}
+		name := protoDomainName(enum.GoIdent)
+		return protoValue{typ: name, toDomain: unexport(name) + "ToDomain", fromDomain: unexport(name) + "FromDomain"}, nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.messageValue(field.Message)
+	}
+
+	typ, ok := protoScalarTypes[kind]
+	if !ok {
+		return protoValue{}, fmt.Errorf("unsupported field kind %s", kind)
+	}
+	return protoValue{typ: typ}, nil
+}
+
+func (b *protoBuilder) messageValue(message *protogen.Message) (protoValue, error) {
+	name := message.Desc.FullName()
+	switch name {
+	case "google.protobuf.Timestamp":
+		b.spec.helpers["time"] = true
+		b.file.UsesTime = true
+		return protoValue{typ: "time.Time", toDomain: "timeToDomain", fromDomain: "timeFromDomain"}, nil
+	case "google.protobuf.Duration":
+		b.spec.helpers["duration"] = true
+		b.file.UsesTime = true
+		return protoValue{typ: "time.Duration", toDomain: "durationToDomain", fromDomain: "durationFromDomain"}, nil
+	}
+	if w, ok := protoWrappers[name]; ok {
+		b.wrappers[name] = true
+		return protoValue{typ: "*" + w.Type, toDomain: unexport(w.Name) + "ToDomain", fromDomain: unexport(w.Name) + "FromDomain"}, nil
+	}
+	if !b.local(message.Desc.ParentFile()) {
+		return protoValue{}, fmt.Errorf("message %s is not supported, only messages from the uploaded files, Timestamp, Duration and wrappers can be used", name)
+	}
+
+	domain := protoDomainName(message.GoIdent)
+	return protoValue{typ: "*" + domain, toDomain: unexport(domain) + "ToDomain", fromDomain: unexport(domain) + "FromDomain"}, nil
+}
+
+// local сообщает, что файл загружен пользователем и генерируется в проект
+func (b *protoBuilder) local(file protoreflect.FileDescriptor) bool {
+	_, ok := b.spec.Sources[file.Path()]
+	return ok
+}
+
+func (b *protoBuilder) field(field *protogen.Field) (ProtoMessageField, error) {
+	f := ProtoMessageField{
+		GoName: goName(string(field.Desc.Name())),
+		PBName: field.GoName,
+		JSON:   string(field.Desc.Name()),
+	}
+	m, v := "m."+field.GoName, "v."+f.GoName
+	getter := "m.Get" + field.GoName + "()"
+
+	switch {
+	case field.Desc.IsMap():
+		key := protoScalarTypes[field.Message.Fields[0].Desc.Kind()]
+		value, err := b.value(field.Message.Fields[1], field.Message.Fields[1].Desc.Kind())
+		if err != nil {
+			return f, err
+		}
+		f.Type = "map[" + key + "]" + value.typ
+		f.ToDomain, f.FromDomain = getter, v
+		if value.toDomain != "" {
+			b.spec.helpers["map"] = true
+			f.ToDomain = "convertMap(" + getter + ", " + value.toDomain + ")"
+			f.FromDomain = "convertMap(" + v + ", " + value.fromDomain + ")"
+		}
+	case field.Desc.IsList():
+		value, err := b.value(field, field.Desc.Kind())
+		if err != nil {
+			return f, err
+		}
+		f.Type = "[]" + value.typ
+		f.ToDomain, f.FromDomain = getter, v
+		if value.toDomain != "" {
+			b.spec.helpers["slice"] = true
+			f.ToDomain = "convertSlice(" + getter + ", " + value.toDomain + ")"
+			f.FromDomain = "convertSlice(" + v + ", " + value.fromDomain + ")"
+		}
+	default:
+		value, err := b.value(field, field.Desc.Kind())
+		if err != nil {
+			return f, err
+		}
+		f.Type = value.typ
+		f.ToDomain, f.FromDomain = value.to(getter), value.from(v)
+
+		switch {
+		case field.Oneof != nil && !field.Oneof.Desc.IsSynthetic():
+			// Вариант oneof выставляет switch в конвертере
+			f.FromDomain = ""
+		case protoPointer(field):
+			// optional скаляры в proto и в домене - указатели
+			f.Type = "*" + value.typ
+			f.ToDomain, f.FromDomain = m, v
+			if value.toDomain != "" {
+				b.spec.helpers["ptr"] = true
+				f.ToDomain = "convertPtr(" + m + ", " + value.toDomain + ")"
+				f.FromDomain = "convertPtr(" + v + ", " + value.fromDomain + ")"
+			}
+		}
+	}
+	return f, nil
+}
+
+// protoPointer повторяет правило protoc-gen-go: скаляры и enum с явным
+// наличием значения, кроме bytes и вариантов oneof, хранятся указателями
+func protoPointer(field *protogen.Field) bool {
+	if !field.Desc.HasPresence() || field.Desc.IsList() || field.Desc.IsMap() {
+		return false
+	}
+	switch field.Desc.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind, protoreflect.BytesKind:
+		return false
+	}
+	return field.Oneof == nil || field.Oneof.Desc.IsSynthetic()
+}
+
+func (b *protoBuilder) service(service *protogen.Service) error {
+	s := ProtoService{
+		GoName:  service.GoName,
+		Snake:   snakeCase(service.GoName),
+		Comment: protoComment(service.Comments.Leading),
+	}
+	s.VarName = lowerFirstWord(s.GoName)
+	if token.IsKeyword(s.VarName) || reservedVars[s.VarName] {
+		s.VarName += "Service"
+	}
+	if err := b.declare(s.GoName+"UseCase", service.Desc.FullName()); err != nil {
+		return err
+	}
+	b.pbNames = append(b.pbNames, s.GoName)
+
+	for _, method := range service.Methods {
+		m := ProtoMethod{GoName: method.GoName}
+		// Комментарий метода стоит внутри интерфейса с отступом
+		if comment := protoComment(method.Comments.Leading); comment != "" {
+			m.Comment = "\t" + strings.ReplaceAll(comment, "\n", "\n\t")
+		}
+
+		var err error
+		if m.Input, m.PBInput, m.ToDomain, err = b.rpcType(method.Input, "ToDomain"); err != nil {
+			return fmt.Errorf("%s: %w", method.Desc.FullName(), err)
+		}
+		if m.Output, m.PBOutput, m.FromDomain, err = b.rpcType(method.Output, "FromDomain"); err != nil {
+			return fmt.Errorf("%s: %w", method.Desc.FullName(), err)
+		}
+		if m.Input == "" || m.Output == "" {
+			s.UsesEmpty = true
+		}
+		s.Methods = append(s.Methods, m)
+	}
+
+	b.file.Services = append(b.file.Services, s)
+	return nil
+}
+
+// rpcType возвращает доменный тип, тип protoc-gen-go и функцию
+// преобразования запроса или ответа. Empty в домене не появляется
+func (b *protoBuilder) rpcType(message *protogen.Message, convert string) (domain, pb, fn string, err error) {
+	if message.Desc.FullName() == protoEmpty {
+		return "", "emptypb.Empty", "", nil
+	}
+	if !b.local(message.Desc.ParentFile()) {
+		return "", "", "", fmt.Errorf("message %s is not declared in the uploaded files", message.Desc.FullName())
+	}
+	domain = protoDomainName(message.GoIdent)
+	return domain, message.GoIdent.GoName, unexport(domain) + convert, nil
+}
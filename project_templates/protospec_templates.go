@@ -0,0 +1,348 @@
+package project_templates
+
+// Шаблоны gRPC-first режима: доменные типы, use case заглушки и сервера
+// генерируются из загруженных .proto файлов вместо сервисов сущностей
+
+// protoDomainTemplate - доменные типы и use case интерфейсы одного .proto файла
+const protoDomainTemplate = `package domain
+{{- with .File}}
+{{- if or .Services .UsesTime}}
+
+import (
+{{- if .Services}}
+	"context"
+{{- end}}
+{{- if .UsesTime}}
+	"time"
+{{- end}}
+)
+{{- end}}
+{{- range .Enums}}
+{{- $enum := .GoName}}
+
+
+{{if .Comment}}{{.Comment}}
+{{end}}type {{.GoName}} int32
+
+const (
+{{- range .Values}}
+	{{.Const}} {{$enum}} = {{.Number}}
+{{- end}}
+)
+{{- end}}
+{{- range .Messages}}
+
+
+{{if .Comment}}{{.Comment}}
+{{end}}type {{.GoName}} struct {
+{{- range .StructFields}}
+	{{.Name}} {{.Type}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+{{- end}}
+{{- range .Services}}
+
+
+//go:generate mockery --name={{.GoName}}UseCase --output=../mocks --outpkg=mocks
+type {{.GoName}}UseCase interface {
+{{- range .Methods}}
+{{- if .Comment}}
+{{.Comment}}
+{{- end}}
+	{{.GoName}}(ctx context.Context{{if .Input}}, req *{{.Input}}{{end}}) {{if .Output}}(*{{.Output}}, error){{else}}error{{end}}
+{{- end}}
+}
+{{- end}}
+{{- end}}
+`
+
+// protoUsecaseTemplate - заглушка use case сервиса, каждый метод
+// возвращает domain.ErrNotImplemented, пока его не реализуют
+const protoUsecaseTemplate = `package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/domain"
+)
+{{- with .Service}}
+{{- $service := .}}
+
+type {{.VarName}}UseCase struct {
+	logger *zap.Logger
+}
+
+func New{{.GoName}}UseCase(logger *zap.Logger) domain.{{.GoName}}UseCase {
+	return &{{.VarName}}UseCase{
+		logger: logger,
+	}
+}
+{{- range .Methods}}
+
+func (u *{{$service.VarName}}UseCase) {{.GoName}}(ctx context.Context{{if .Input}}, req *domain.{{.Input}}{{end}}) {{if .Output}}(*domain.{{.Output}}, error){{else}}error{{end}} {
+	// TODO: implement {{$service.GoName}}.{{.GoName}}
+	u.logger.Warn("{{$service.GoName}}.{{.GoName}} is not implemented")
+	return {{if .Output}}nil, {{end}}fmt.Errorf("{{$service.GoName}}.{{.GoName}}: %w", domain.ErrNotImplemented)
+}
+{{- end}}
+{{- end}}
+`
+
+const protoServerTemplate = `package grpc
+
+import (
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(
+{{- range .Proto.Services}}
+		New{{.GoName}},
+{{- end}}
+	),
+	fx.Invoke(RegisterServices),
+)
+
+
+// RegisterServices registers every gRPC service on the server created in bootstrap
+func RegisterServices(server *grpc.Server{{range .Proto.Services}}, {{.VarName}} *{{.GoName}}{{end}}) {
+{{- range .Proto.Services}}
+	Register{{.GoName}}Server(server, {{.VarName}})
+{{- end}}
+}
+`
+
+// protoServiceTemplate - реализация сервера protoc-gen-go-grpc, которая
+// переводит запрос в доменный тип и делегирует use case
+const protoServiceTemplate = `package grpc
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+{{- if .Service.UsesEmpty}}
+	"google.golang.org/protobuf/types/known/emptypb"
+{{- end}}
+
+	"{{.Name}}/internal/domain"
+)
+{{- with .Service}}
+{{- $service := .}}
+
+
+{{if .Comment}}{{.Comment}}
+{{end}}type {{.GoName}} struct {
+	Unimplemented{{.GoName}}Server
+	useCase domain.{{.GoName}}UseCase
+	logger  *zap.Logger
+}
+
+
+func New{{.GoName}}(useCase domain.{{.GoName}}UseCase, logger *zap.Logger) *{{.GoName}} {
+	return &{{.GoName}}{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+{{- range .Methods}}
+
+
+func (s *{{$service.GoName}}) {{.GoName}}(ctx context.Context, {{if .Input}}req{{else}}_{{end}} *{{.PBInput}}) (*{{.PBOutput}}, error) {
+{{- if .Output}}
+	resp, err := s.useCase.{{.GoName}}(ctx{{if .Input}}, {{.ToDomain}}(req){{end}})
+	if err != nil {
+		return nil, rpcError(s.logger, "{{$service.GoName}}.{{.GoName}}", err)
+	}
+
+	return {{.FromDomain}}(resp), nil
+{{- else}}
+	if err := s.useCase.{{.GoName}}(ctx{{if .Input}}, {{.ToDomain}}(req){{end}}); err != nil {
+		return nil, rpcError(s.logger, "{{$service.GoName}}.{{.GoName}}", err)
+	}
+
+	return &emptypb.Empty{}, nil
+{{- end}}
+}
+{{- end}}
+{{- end}}
+`
+
+// protoConvertTemplate - преобразования между сообщениями protoc-gen-go и
+// доменными типами. Выполняется над ProjectConfig с непустым Proto
+const protoConvertTemplate = `package grpc
+
+import (
+{{- if or (.Proto.Uses "time") (.Proto.Uses "duration")}}
+	"time"
+{{end}}
+{{- if .Proto.Uses "duration"}}
+	"google.golang.org/protobuf/types/known/durationpb"
+{{- end}}
+{{- if .Proto.Uses "time"}}
+	"google.golang.org/protobuf/types/known/timestamppb"
+{{- end}}
+{{- if .Proto.Wrappers}}
+	"google.golang.org/protobuf/types/known/wrapperspb"
+{{- end}}
+
+	"{{.Name}}/internal/domain"
+)
+{{- range .Proto.Enums}}
+
+
+func {{.Converts}}ToDomain(e {{.PBName}}) domain.{{.GoName}} {
+	return domain.{{.GoName}}(e)
+}
+
+
+func {{.Converts}}FromDomain(e domain.{{.GoName}}) {{.PBName}} {
+	return {{.PBName}}(e)
+}
+{{- end}}
+{{- range .Proto.Messages}}
+
+
+func {{.Converts}}ToDomain(m *{{.PBName}}) *domain.{{.GoName}} {
+	if m == nil {
+		return nil
+	}
+
+	return &domain.{{.GoName}}{
+{{- range .Fields}}
+		{{.GoName}}: {{.ToDomain}},
+{{- end}}
+	}
+}
+
+
+func {{.Converts}}FromDomain(v *domain.{{.GoName}}) *{{.PBName}} {
+	if v == nil {
+		return nil
+	}
+{{if .Oneofs}}
+	m := &{{.PBName}}{
+{{- range .Fields}}{{if .FromDomain}}
+		{{.PBName}}: {{.FromDomain}},
+{{- end}}{{end}}
+	}
+{{- range .Oneofs}}
+{{- $oneof := .PBName}}
+
+	switch {
+{{- range .Options}}
+	case {{.Cond}}:
+		m.{{$oneof}} = &{{.Wrapper}}{{"{"}}{{.PBName}}: {{.Value}}{{"}"}}
+{{- end}}
+	}
+{{- end}}
+
+	return m
+{{- else}}
+	return &{{.PBName}}{
+{{- range .Fields}}
+		{{.PBName}}: {{.FromDomain}},
+{{- end}}
+	}
+{{- end}}
+}
+{{- end}}
+{{- range .Proto.Wrappers}}
+
+
+func {{.Converts}}ToDomain(w *wrapperspb.{{.Name}}) *{{.Type}} {
+	if w == nil {
+		return nil
+	}
+
+	v := w.GetValue()
+	return &v
+}
+
+
+func {{.Converts}}FromDomain(v *{{.Type}}) *wrapperspb.{{.Name}} {
+	if v == nil {
+		return nil
+	}
+
+	return wrapperspb.{{.Ctor}}(*v)
+}
+{{- end}}
+{{- if .Proto.Uses "time"}}
+
+
+// timeToDomain переводит незаданный Timestamp в нулевое время и обратно
+func timeToDomain(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+
+func timeFromDomain(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+{{- end}}
+{{- if .Proto.Uses "duration"}}
+
+
+func durationToDomain(d *durationpb.Duration) time.Duration {
+	return d.AsDuration()
+}
+
+
+func durationFromDomain(d time.Duration) *durationpb.Duration {
+	return durationpb.New(d)
+}
+{{- end}}
+{{- if .Proto.Uses "slice"}}
+
+
+func convertSlice[T, U any](values []T, convert func(T) U) []U {
+	if values == nil {
+		return nil
+	}
+
+	result := make([]U, len(values))
+	for i, v := range values {
+		result[i] = convert(v)
+	}
+	return result
+}
+{{- end}}
+{{- if .Proto.Uses "map"}}
+
+
+func convertMap[K comparable, T, U any](values map[K]T, convert func(T) U) map[K]U {
+	if values == nil {
+		return nil
+	}
+
+	result := make(map[K]U, len(values))
+	for k, v := range values {
+		result[k] = convert(v)
+	}
+	return result
+}
+{{- end}}
+{{- if .Proto.Uses "ptr"}}
+
+
+func convertPtr[T, U any](value *T, convert func(T) U) *U {
+	if value == nil {
+		return nil
+	}
+
+	result := convert(*value)
+	return &result
+}
+{{- end}}
+`
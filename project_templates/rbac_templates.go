@@ -0,0 +1,336 @@
+package project_templates
+
+// Шаблоны для RBAC: политика ролей, порт domain.Authorizer и вычисление
+// политики внутри процесса по ролям principal из auth
+
+const configRBACTemplate = `package config
+
+
+type RBACConfig struct {
+	// PolicyFile replaces the policy built into the binary (internal/rbac/policy.yaml)
+	PolicyFile string
+}
+
+
+func NewRBACConfig() RBACConfig {
+	return RBACConfig{
+		PolicyFile: getEnv("RBAC_POLICY_FILE", ""),
+	}
+}`
+
+const authorizerDomainTemplate = `package domain
+
+import "context"
+
+// Actions checked by the Authorizer, one per use case method
+const (
+	ActionCreate = "create"
+	ActionRead   = "read"
+	ActionList   = "list"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Resources checked by the Authorizer, one per entity
+const (
+{{- range .Entities}}
+	{{.GoName}}Resource = "{{.Route}}"
+{{- end}}
+)
+
+// Authorizer decides whether the caller in ctx may perform action on
+// resource. It returns ErrUnauthorized when the call has no principal and
+// ErrForbidden when none of the caller's roles allows the action
+type Authorizer interface {
+	Authorize(ctx context.Context, resource, action string) error
+}
+`
+
+const rbacPolicyTemplate = `# RBAC policy: role -> resource -> actions the role may perform.
+# Roles come from the roles claim of the access token, a caller is allowed
+# an action when any of its roles allows it.
+#
+# Actions: create, read, list, update, delete. "*" matches any resource or action.
+# Resources:{{range .Entities}} {{.Route}}{{end}}
+#
+# The file is built into the binary, RBAC_POLICY_FILE points to a replacement.
+roles:
+  admin:
+    "*": ["*"]
+  editor:
+{{- range .Entities}}
+    {{.Route}}: [create, read, list, update]
+{{- end}}
+  viewer:
+{{- range .Entities}}
+    {{.Route}}: [read, list]
+{{- end}}
+`
+
+const rbacModuleTemplate = `package rbac
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"go.uber.org/fx"
+
+	"{{.Name}}/internal/config"
+)
+
+
+//go:embed policy.yaml
+var defaultPolicy []byte
+
+
+var Module = fx.Options(
+	fx.Provide(
+		LoadPolicy,
+		NewAuthorizer,
+	),
+)
+
+
+// LoadPolicy reads the policy from RBAC_POLICY_FILE, or uses policy.yaml
+// built into the binary. An invalid policy stops the application from starting
+func LoadPolicy(cfg *config.Config) (*Policy, error) {
+	data := defaultPolicy
+	if cfg.RBAC.PolicyFile != "" {
+		var err error
+		data, err = os.ReadFile(cfg.RBAC.PolicyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read rbac policy: %w", err)
+		}
+	}
+	return ParsePolicy(data)
+}
+`
+
+const rbacPolicyGoTemplate = `package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"{{.Name}}/internal/domain"
+)
+
+
+// wildcard in a policy matches any resource or any action
+const wildcard = "*"
+
+
+// resources and actions the use cases check. The policy is validated
+// against them, so a typo fails at startup instead of denying access
+var (
+	resources = []string{
+{{- range .Entities}}
+		domain.{{.GoName}}Resource,
+{{- end}}
+	}
+	actions = []string{
+		domain.ActionCreate,
+		domain.ActionRead,
+		domain.ActionList,
+		domain.ActionUpdate,
+		domain.ActionDelete,
+	}
+)
+
+
+// Policy maps roles to the actions they may perform on each resource
+type Policy struct {
+	Roles map[string]map[string][]string ` + "`yaml:\"roles\"`" + `
+}
+
+
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("parse rbac policy: %w", err)
+	}
+	if len(policy.Roles) == 0 {
+		return nil, errors.New("rbac policy defines no roles")
+	}
+
+	for role, grants := range policy.Roles {
+		for resource, allowed := range grants {
+			if resource != wildcard && !slices.Contains(resources, resource) {
+				return nil, fmt.Errorf("rbac policy: role %q: unknown resource %q", role, resource)
+			}
+			for _, action := range allowed {
+				if action != wildcard && !slices.Contains(actions, action) {
+					return nil, fmt.Errorf("rbac policy: role %q: unknown action %q on %q", role, action, resource)
+				}
+			}
+		}
+	}
+
+	return &policy, nil
+}
+
+
+// Allows reports whether role may perform action on resource
+func (p *Policy) Allows(role, resource, action string) bool {
+	grants := p.Roles[role]
+	for _, granted := range [][]string{grants[resource], grants[wildcard]} {
+		for _, allowed := range granted {
+			if allowed == action || allowed == wildcard {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+
+// authorizer evaluates the policy in process against the roles of the
+// principal that auth put into the request context
+type authorizer struct {
+	policy *Policy
+	logger *zap.Logger
+}
+
+
+func NewAuthorizer(policy *Policy, logger *zap.Logger) domain.Authorizer {
+	return &authorizer{
+		policy: policy,
+		logger: logger,
+	}
+}
+
+
+func (a *authorizer) Authorize(ctx context.Context, resource, action string) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return domain.Errorf(domain.ErrUnauthorized, "authentication required")
+	}
+
+	for _, role := range principal.Roles {
+		if a.policy.Allows(role, resource, action) {
+			return nil
+		}
+	}
+
+	a.logger.Info("Access denied",
+		zap.String("subject", principal.Subject),
+		zap.Strings("roles", principal.Roles),
+		zap.String("resource", resource),
+		zap.String("action", action))
+	return domain.Errorf(domain.ErrForbidden, "not allowed to %s %s", action, resource)
+}
+`
+
+const rbacPolicyTestTemplate = `package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/domain"
+)
+
+
+func newTestAuthorizer(t *testing.T) domain.Authorizer {
+	t.Helper()
+	policy, err := ParsePolicy(defaultPolicy)
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	return NewAuthorizer(policy, zap.NewNop())
+}
+
+
+func withRoles(roles ...string) context.Context {
+	return domain.WithPrincipal(context.Background(), domain.Principal{Subject: "alice", Roles: roles})
+}
+
+
+// TestDefaultPolicy checks every role of policy.yaml against every resource
+// and action: allowed lists what the role may do, the rest must be denied
+func TestDefaultPolicy(t *testing.T) {
+	authorizer := newTestAuthorizer(t)
+
+	all := []string{domain.ActionCreate, domain.ActionRead, domain.ActionList, domain.ActionUpdate, domain.ActionDelete}
+	roles := []struct {
+		name    string
+		roles   []string
+		allowed []string
+	}{
+		{"admin", []string{"admin"}, all},
+		{"editor", []string{"editor"}, []string{domain.ActionCreate, domain.ActionRead, domain.ActionList, domain.ActionUpdate}},
+		{"viewer", []string{"viewer"}, []string{domain.ActionRead, domain.ActionList}},
+		{"viewer and editor", []string{"viewer", "editor"}, []string{domain.ActionCreate, domain.ActionRead, domain.ActionList, domain.ActionUpdate}},
+		{"unknown role", []string{"guest"}, nil},
+		{"no roles", nil, nil},
+	}
+
+	for _, role := range roles {
+		for _, resource := range resources {
+			for _, action := range all {
+				allowed := false
+				for _, a := range role.allowed {
+					allowed = allowed || a == action
+				}
+
+				t.Run(role.name+"/"+action+"/"+resource, func(t *testing.T) {
+					err := authorizer.Authorize(withRoles(role.roles...), resource, action)
+					switch {
+					case allowed && err != nil:
+						t.Errorf("expected %s to be allowed, got %v", action, err)
+					case !allowed && !errors.Is(err, domain.ErrForbidden):
+						t.Errorf("expected ErrForbidden, got %v", err)
+					}
+				})
+			}
+		}
+	}
+}
+
+
+func TestAuthorizeWithoutPrincipal(t *testing.T) {
+	authorizer := newTestAuthorizer(t)
+
+	err := authorizer.Authorize(context.Background(), domain.{{(index .Entities 0).GoName}}Resource, domain.ActionRead)
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		valid  bool
+	}{
+		{"wildcards", "roles:\n  admin:\n    \"*\": [\"*\"]\n", true},
+		{"resource actions", "roles:\n  viewer:\n    {{(index .Entities 0).Route}}: [read, list]\n", true},
+		{"unknown resource", "roles:\n  viewer:\n    unknown: [read]\n", false},
+		{"unknown action", "roles:\n  viewer:\n    {{(index .Entities 0).Route}}: [read, destroy]\n", false},
+		{"no roles", "roles: {}\n", false},
+		{"invalid yaml", "roles: [", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.policy))
+			if tt.valid && err != nil {
+				t.Fatalf("expected a valid policy, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+`
@@ -0,0 +1,742 @@
+package project_templates
+
+// Шаблоны для realtime модуля: WebSocket и SSE поверх общего hub
+
+const configRealtimeTemplate = `package config
+
+import "time"
+
+
+type RealtimeConfig struct {
+	// SendBuffer bounds the per-connection queue; clients that fall behind are dropped
+	SendBuffer   int
+	PingInterval time.Duration
+	// AllowedOrigins for WebSocket upgrades, empty means same origin only
+	AllowedOrigins []string
+}
+
+
+func NewRealtimeConfig() RealtimeConfig {
+	return RealtimeConfig{
+		SendBuffer:     getEnvAsInt("REALTIME_SEND_BUFFER", 64),
+		PingInterval:   time.Duration(getEnvAsInt("REALTIME_PING_INTERVAL_MS", 30000)) * time.Millisecond,
+		AllowedOrigins: getEnvAsSlice("REALTIME_ALLOWED_ORIGINS", nil, ","),
+	}
+}`
+
+const realtimeModuleTemplate = `package realtime
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+
+	"{{.Name}}/internal/domain"
+)
+
+
+var Module = fx.Options(
+	fx.Provide(
+		NewHub,
+		NewHandler,
+		fx.Annotate(
+			NewEventPublisher,
+{{- if .HasDependency "kafka"}}
+			// Events reach the hub through the Kafka consumer, so every replica sees every event
+			fx.ResultTags(` + "`group:\"event_subscribers\"`" + `),
+{{- else}}
+			// Use cases publish straight into the hub
+			fx.ResultTags(` + "`group:\"event_publishers\"`" + `),
+{{- end}}
+		),
+	),
+	fx.Invoke(
+		RegisterHub,
+		RegisterRoutes,
+	),
+)
+
+
+func NewEventPublisher(hub *Hub) domain.EventPublisher {
+	return hub
+}
+
+
+func RegisterHub(lc fx.Lifecycle, hub *Hub) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go hub.Run(ctx)
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-hub.Done():
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+`
+
+const realtimeHubTemplate = `package realtime
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/domain"
+)
+
+
+// Hub fans entity events out to the connected WebSocket and SSE clients
+// subscribed to that entity. All client bookkeeping happens in Run,
+// so no locks are needed
+type Hub struct {
+	register   chan *Client
+	unregister chan *Client
+	broadcast  chan message
+	done       chan struct{}
+	clients    map[*Client]struct{}
+	sendBuffer int
+	logger     *zap.Logger
+}
+
+
+// message is an encoded event together with the entity it belongs to
+type message struct {
+	entity string
+	data   []byte
+}
+
+
+// Client is a single subscriber; Messages is closed when the hub drops it
+type Client struct {
+	entity string
+	send   chan []byte
+}
+
+
+func (c *Client) Messages() <-chan []byte {
+	return c.send
+}
+
+
+func NewHub(cfg *config.Config, logger *zap.Logger) *Hub {
+	return &Hub{
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		broadcast:  make(chan message, 256),
+		done:       make(chan struct{}),
+		clients:    make(map[*Client]struct{}),
+		sendBuffer: cfg.Realtime.SendBuffer,
+		logger:     logger,
+	}
+}
+
+
+func (h *Hub) Run(ctx context.Context) {
+	defer close(h.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			for client := range h.clients {
+				h.drop(client)
+			}
+			return
+		case client := <-h.register:
+			h.clients[client] = struct{}{}
+		case client := <-h.unregister:
+			if _, ok := h.clients[client]; ok {
+				h.drop(client)
+			}
+		case msg := <-h.broadcast:
+			for client := range h.clients {
+				if client.entity != msg.entity {
+					continue
+				}
+				select {
+				case client.send <- msg.data:
+				default:
+					// A slow client must not hold back the others
+					h.logger.Warn("Dropping realtime client with a full send buffer")
+					h.drop(client)
+				}
+			}
+		}
+	}
+}
+
+
+func (h *Hub) drop(client *Client) {
+	delete(h.clients, client)
+	close(client.send)
+}
+
+
+// Done is closed once Run has returned and every client has been dropped
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+
+// Subscribe registers a client for the events of one entity, e.g. "user"
+func (h *Hub) Subscribe(entity string) *Client {
+	client := &Client{entity: entity, send: make(chan []byte, h.sendBuffer)}
+
+	select {
+	case h.register <- client:
+	case <-h.done:
+		close(client.send)
+	}
+
+	return client
+}
+
+
+func (h *Hub) Unsubscribe(client *Client) {
+	select {
+	case h.unregister <- client:
+	case <-h.done:
+	}
+}
+
+
+// Publish implements domain.EventPublisher
+func (h *Hub) Publish(ctx context.Context, event domain.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case h.broadcast <- message{entity: event.Entity, data: data}:
+		return nil
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+`
+
+const realtimeHandlerTemplate = `package realtime
+
+import (
+{{- if .HasDependency "rbac"}}
+	"errors"
+{{- end}}
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+{{- if .HasDependency "auth"}}
+	"{{.Name}}/internal/auth"
+{{- end}}
+	"{{.Name}}/internal/config"
+{{- if .HasDependency "rbac"}}
+	"{{.Name}}/internal/domain"
+{{- end}}
+)
+
+
+const (
+	writeWait      = 10 * time.Second
+	maxMessageSize = 512
+)
+
+
+type Handler struct {
+	hub          *Hub
+	upgrader     websocket.Upgrader
+	pingInterval time.Duration
+{{- if .HasDependency "rbac"}}
+	authz        domain.Authorizer
+{{- end}}
+	logger       *zap.Logger
+}
+
+
+func NewHandler(hub *Hub, cfg *config.Config{{if .HasDependency "rbac"}}, authz domain.Authorizer{{end}}, logger *zap.Logger) *Handler {
+	return &Handler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(cfg.Realtime.AllowedOrigins),
+		},
+		pingInterval: cfg.Realtime.PingInterval,
+{{- if .HasDependency "rbac"}}
+		authz:        authz,
+{{- end}}
+		logger:       logger,
+	}
+}
+
+
+{{if .HasDependency "auth" -}}
+// RegisterRoutes exposes a WebSocket and an SSE stream per entity. Streams
+// carry the same entities as the API, so they require a token too
+func RegisterRoutes(server *echo.Echo, h *Handler, authenticator *auth.Authenticator) {
+	server.Pre(auth.TokenFromQuery("/ws/", "/events/"))
+	streams := server.Group("", authenticator.Middleware())
+{{- else -}}
+// RegisterRoutes exposes a WebSocket and an SSE stream per entity
+func RegisterRoutes(server *echo.Echo, h *Handler) {
+	streams := server.Group("")
+{{- end}}
+{{- range .Entities}}
+	streams.GET("/ws/{{.Route}}", h.ServeWebSocket("{{.Snake}}"){{if $.HasDependency "rbac"}}, h.authorize(domain.{{.GoName}}Resource){{end}})
+	streams.GET("/events/{{.Route}}", h.ServeSSE("{{.Snake}}"){{if $.HasDependency "rbac"}}, h.authorize(domain.{{.GoName}}Resource){{end}})
+{{- end}}
+}
+{{- if .HasDependency "rbac"}}
+
+
+// authorize subscribes only callers that may read resource
+func (h *Handler) authorize(resource string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := h.authz.Authorize(c.Request().Context(), resource, domain.ActionRead)
+			switch {
+			case errors.Is(err, domain.ErrUnauthorized):
+				return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+			case errors.Is(err, domain.ErrForbidden):
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			case err != nil:
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+{{- end}}
+
+
+// ServeWebSocket streams the events of entity as JSON text frames
+func (h *Handler) ServeWebSocket(entity string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		conn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			// The upgrader has already replied with an HTTP error
+			h.logger.Debug("WebSocket upgrade failed", zap.Error(err))
+			return nil
+		}
+
+		client := h.hub.Subscribe(entity)
+		go h.readPump(conn, client)
+		h.writePump(conn, client)
+
+		return nil
+	}
+}
+
+
+// readPump discards client messages and keeps the read deadline alive via pongs;
+// it unsubscribes the client as soon as the connection goes away
+func (h *Handler) readPump(conn *websocket.Conn, client *Client) {
+	defer h.hub.Unsubscribe(client)
+
+	pongWait := 2 * h.pingInterval
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+
+func (h *Handler) writePump(conn *websocket.Conn, client *Client) {
+	ticker := time.NewTicker(h.pingInterval)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+		h.hub.Unsubscribe(client)
+	}()
+
+	for {
+		select {
+		case message, ok := <-client.Messages():
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+
+// ServeSSE streams the events of entity as Server-Sent Events
+func (h *Handler) ServeSSE(entity string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return h.serveSSE(c, entity)
+	}
+}
+
+
+func (h *Handler) serveSSE(c echo.Context, entity string) error {
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	client := h.hub.Subscribe(entity)
+	defer h.hub.Unsubscribe(client)
+
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case message, ok := <-client.Messages():
+			if !ok {
+				return nil
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", message); err != nil {
+				return nil
+			}
+			w.Flush()
+		case <-ticker.C:
+			// Comment lines keep proxies from closing idle streams
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		}
+	}
+}
+
+
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	if len(allowed) == 0 {
+		return nil // gorilla/websocket falls back to a same-origin check
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		for _, a := range allowed {
+			if a == "*" || a == origin || a == u.Host {
+				return true
+			}
+		}
+		return false
+	}
+}
+`
+
+const realtimeHubTestTemplate = `package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/config"
+	"{{.Name}}/internal/domain"
+)
+
+
+func startHub(t *testing.T, sendBuffer int) *Hub {
+	t.Helper()
+
+	cfg := &config.Config{Realtime: config.RealtimeConfig{SendBuffer: sendBuffer}}
+	hub := NewHub(cfg, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go hub.Run(ctx)
+	t.Cleanup(func() {
+		cancel()
+		<-hub.Done()
+	})
+
+	return hub
+}
+
+
+func receive(t *testing.T, client *Client) domain.Event {
+	t.Helper()
+
+	select {
+	case message, ok := <-client.Messages():
+		if !ok {
+			t.Fatal("client was dropped")
+		}
+		var event domain.Event
+		if err := json.Unmarshal(message, &event); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return domain.Event{}
+}
+
+
+func TestHubBroadcastsToAllClients(t *testing.T) {
+	hub := startHub(t, 8)
+
+	first := hub.Subscribe("{{.Entity.Snake}}")
+	second := hub.Subscribe("{{.Entity.Snake}}")
+
+	event := domain.Event{Type: domain.{{.Entity.GoName}}Created, Entity: "{{.Entity.Snake}}", ID: "42"}
+	if err := hub.Publish(context.Background(), event); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	for _, client := range []*Client{first, second} {
+		got := receive(t, client)
+		if got.Type != domain.{{.Entity.GoName}}Created || got.ID != "42" {
+			t.Fatalf("unexpected event: %+v", got)
+		}
+	}
+}
+
+
+func TestHubFiltersByEntity(t *testing.T) {
+	hub := startHub(t, 8)
+
+	other := hub.Subscribe("other")
+	client := hub.Subscribe("{{.Entity.Snake}}")
+
+	event := domain.Event{Type: domain.{{.Entity.GoName}}Deleted, Entity: "{{.Entity.Snake}}", ID: "7"}
+	if err := hub.Publish(context.Background(), event); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	receive(t, client)
+	select {
+	case message := <-other.Messages():
+		t.Fatalf("unexpected message for another entity: %s", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+
+func TestHubDropsSlowClient(t *testing.T) {
+	hub := startHub(t, 8)
+
+	// The slow client gets a single slot, witness tells us when the hub is done broadcasting
+	slow := &Client{entity: "{{.Entity.Snake}}", send: make(chan []byte, 1)}
+	hub.register <- slow
+	witness := hub.Subscribe("{{.Entity.Snake}}")
+	event := domain.Event{Type: domain.{{.Entity.GoName}}Updated, Entity: "{{.Entity.Snake}}", ID: "1"}
+
+	// The first event fills the slow client's buffer, the second one overflows it
+	for i := 0; i < 3; i++ {
+		if err := hub.Publish(context.Background(), event); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(witness.Messages()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the broadcast")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	receive(t, slow)
+	select {
+	case _, ok := <-slow.Messages():
+		if ok {
+			t.Fatal("expected the slow client to be dropped")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow client was not dropped")
+	}
+}
+
+
+func TestHubUnsubscribeClosesClient(t *testing.T) {
+	hub := startHub(t, 8)
+
+	client := hub.Subscribe("{{.Entity.Snake}}")
+	hub.Unsubscribe(client)
+
+	select {
+	case _, ok := <-client.Messages():
+		if ok {
+			t.Fatal("expected closed channel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("client was not closed")
+	}
+}
+`
+
+// realtimeHandlerTestTemplate проверяет, что с auth потоки закрыты для анонимных клиентов
+const realtimeHandlerTestTemplate = `package realtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"{{.Name}}/internal/auth"
+	"{{.Name}}/internal/auth/authtest"
+	"{{.Name}}/internal/config"
+{{- if .HasDependency "rbac"}}
+	"{{.Name}}/internal/rbac"
+{{- end}}
+)
+
+
+const testSecret = "test-secret"
+
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	cfg := &config.Config{
+		Auth:     config.AuthConfig{HS256Secret: testSecret},
+		Realtime: config.RealtimeConfig{SendBuffer: 8, PingInterval: time.Minute},
+	}
+	authenticator, err := auth.NewAuthenticator(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+{{- if .HasDependency "rbac"}}
+	policy, err := rbac.LoadPolicy(cfg)
+	if err != nil {
+		t.Fatalf("LoadPolicy: %v", err)
+	}
+	handler := NewHandler(startHub(t, 8), cfg, rbac.NewAuthorizer(policy, zap.NewNop()), zap.NewNop())
+{{- else}}
+	handler := NewHandler(startHub(t, 8), cfg, zap.NewNop())
+{{- end}}
+
+	e := echo.New()
+	RegisterRoutes(e, handler, authenticator)
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return server
+}
+
+
+// connect opens a stream and returns the status of the response. Both streams
+// stay open on success, so the request is cancelled once the headers arrive
+func connect(t *testing.T, server *httptest.Server, path string, websocket bool) int {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if websocket {
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	}
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+
+func TestStreamsRequireToken(t *testing.T) {
+	server := newTestServer(t)
+{{- if .HasDependency "rbac"}}
+	token, err := authtest.HS256Token(testSecret, "", "", "alice", []string{"viewer"}, time.Hour)
+{{- else}}
+	token, err := authtest.HS256Token(testSecret, "", "", "alice", nil, time.Hour)
+{{- end}}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		websocket bool
+		status    int
+	}{
+		{"anonymous websocket", "/ws/{{.Entity.Route}}", true, http.StatusUnauthorized},
+		{"anonymous sse", "/events/{{.Entity.Route}}", false, http.StatusUnauthorized},
+		{"invalid token", "/events/{{.Entity.Route}}?access_token=not-a-token", false, http.StatusUnauthorized},
+		{"websocket with token", "/ws/{{.Entity.Route}}?access_token=" + token, true, http.StatusSwitchingProtocols},
+		{"sse with token", "/events/{{.Entity.Route}}?access_token=" + token, false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := connect(t, server, tt.path, tt.websocket); got != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, got)
+			}
+		})
+	}
+}
+{{- if .HasDependency "rbac"}}
+
+
+func TestStreamsRequireReadPermission(t *testing.T) {
+	server := newTestServer(t)
+	// Токен без ролей проходит аутентификацию, но не дает права read
+	token, err := authtest.HS256Token(testSecret, "", "", "mallory", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := connect(t, server, "/ws/{{.Entity.Route}}?access_token="+token, true); got != http.StatusForbidden {
+		t.Errorf("websocket: expected status 403, got %d", got)
+	}
+	if got := connect(t, server, "/events/{{.Entity.Route}}?access_token="+token, false); got != http.StatusForbidden {
+		t.Errorf("sse: expected status 403, got %d", got)
+	}
+}
+{{- end}}
+`
@@ -0,0 +1,986 @@
+package project_templates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Импорт сущностей из DDL существующей базы. Понимает CREATE TABLE,
+// CREATE TYPE ... AS ENUM, CREATE INDEX и ALTER TABLE ... ADD в диалектах
+// PostgreSQL и MySQL, остальные выражения пропускаются
+
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlQuoted
+	sqlString
+	sqlNumber
+	sqlPunct
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// is сравнивает ключевое слово без учета регистра
+func (t sqlToken) is(word string) bool {
+	return t.kind == sqlWord && strings.EqualFold(t.text, word)
+}
+
+func (t sqlToken) punct(p string) bool {
+	return t.kind == sqlPunct && t.text == p
+}
+
+// ident - имя таблицы, колонки или типа
+func (t sqlToken) ident() bool {
+	return t.kind == sqlWord || t.kind == sqlQuoted
+}
+
+type sqlColumn struct {
+	name    string
+	typ     sqlType
+	notNull bool
+	// generated - значение выдает сама база данных: serial, IDENTITY,
+	// AUTO_INCREMENT или GENERATED колонка
+	generated bool
+	primary   bool
+	unique    bool
+	indexed   bool
+	ref       *sqlRef
+}
+
+type sqlType struct {
+	// name - базовое имя в нижнем регистре: "character varying", "int"
+	name   string
+	args   []string
+	values []string
+	array  bool
+}
+
+type sqlRef struct {
+	table  string
+	column string
+}
+
+type sqlTable struct {
+	name    string
+	columns []*sqlColumn
+	primary []string
+}
+
+func (t *sqlTable) column(name string) *sqlColumn {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+type sqlSchema struct {
+	tables []*sqlTable
+	enums  map[string][]string
+}
+
+func (s *sqlSchema) table(name string) *sqlTable {
+	for _, t := range s.tables {
+		if strings.EqualFold(t.name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// ParseSQLSchema строит сущности по таблицам с первичным ключом из одной
+// колонки. Таблицы с составным ключом (связи многие-ко-многим) пропускаются
+func ParseSQLSchema(data []byte) (Entities, error) {
+	tokens, err := tokenizeSQL(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &sqlSchema{enums: make(map[string][]string)}
+	for _, stmt := range splitSQLStatements(tokens) {
+		if err := schema.statement(stmt); err != nil {
+			return nil, err
+		}
+	}
+	if len(schema.tables) == 0 {
+		return nil, errors.New("no CREATE TABLE statements found in the SQL schema")
+	}
+
+	entities, err := schema.entities()
+	if err != nil {
+		return nil, err
+	}
+	if len(entities) == 0 {
+		return nil, errors.New("no tables with a single-column primary key found in the SQL schema")
+	}
+	if err := entities.Validate(); err != nil {
+		return nil, fmt.Errorf("imported schema: %w", err)
+	}
+	return entities, nil
+}
+
+func tokenizeSQL(src string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--") || c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated comment in the SQL schema")
+			}
+			i += end + 4
+		case c == '\'':
+			text, n, err := readSQLQuoted(src[i:], '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlToken{kind: sqlString, text: text})
+			i += n
+		case c == '"' || c == '`':
+			text, n, err := readSQLQuoted(src[i:], c)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlToken{kind: sqlQuoted, text: text})
+			i += n
+		case c == '$':
+			// Тела функций PostgreSQL в $$ ... $$ или $tag$ ... $tag$
+			end := strings.IndexByte(src[i+1:], '$')
+			if end < 0 {
+				tokens = append(tokens, sqlToken{kind: sqlPunct, text: "$"})
+				i++
+				continue
+			}
+			tag := src[i : i+end+2]
+			body := strings.Index(src[i+len(tag):], tag)
+			if body < 0 {
+				return nil, errors.New("unterminated dollar-quoted string in the SQL schema")
+			}
+			tokens = append(tokens, sqlToken{kind: sqlString, text: src[i+len(tag) : i+len(tag)+body]})
+			i += len(tag)*2 + body
+		case isSQLWordByte(c):
+			start := i
+			for i < len(src) && (isSQLWordByte(src[i]) || isASCIIDigit(src[i]) || src[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlWord, text: src[start:i]})
+		case isASCIIDigit(c):
+			start := i
+			for i < len(src) && (isASCIIDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlNumber, text: src[start:i]})
+		default:
+			tokens = append(tokens, sqlToken{kind: sqlPunct, text: string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isSQLWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// readSQLQuoted читает строку или имя в кавычках, удвоенная кавычка - экранирование
+func readSQLQuoted(src string, quote byte) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch {
+		case src[i] == '\\' && quote == '\'' && i+1 < len(src):
+			i++
+			b.WriteByte(src[i])
+		case src[i] != quote:
+			b.WriteByte(src[i])
+		case i+1 < len(src) && src[i+1] == quote:
+			b.WriteByte(quote)
+			i++
+		default:
+			return b.String(), i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated %c quote in the SQL schema", quote)
+}
+
+func splitSQLStatements(tokens []sqlToken) [][]sqlToken {
+	var (
+		statements [][]sqlToken
+		start      int
+	)
+	for i, t := range tokens {
+		if t.punct(";") {
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+	return statements
+}
+
+// sqlParser разбирает одно выражение
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+func (p *sqlParser) peek() sqlToken {
+	if p.pos >= len(p.tokens) {
+		return sqlToken{kind: sqlPunct}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *sqlParser) next() sqlToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// accept пропускает последовательность ключевых слов, если она идет следующей
+func (p *sqlParser) accept(words ...string) bool {
+	for i, w := range words {
+		if p.pos+i >= len(p.tokens) || !p.tokens[p.pos+i].is(w) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+// name читает имя, возможно со схемой: public.users -> users
+func (p *sqlParser) name() (string, error) {
+	t := p.next()
+	if !t.ident() {
+		return "", fmt.Errorf("expected a name, got %q", t.text)
+	}
+	name := t.text
+	for p.peek().punct(".") {
+		p.next()
+		t = p.next()
+		if !t.ident() {
+			return "", fmt.Errorf("expected a name after %q, got %q", name, t.text)
+		}
+		name = t.text
+	}
+	return name, nil
+}
+
+// group возвращает токены внутри следующих скобок
+func (p *sqlParser) group() ([]sqlToken, error) {
+	if !p.peek().punct("(") {
+		return nil, fmt.Errorf("expected \"(\", got %q", p.peek().text)
+	}
+	start := p.pos + 1
+	depth := 0
+	for !p.done() {
+		t := p.next()
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+			if depth == 0 {
+				return p.tokens[start : p.pos-1], nil
+			}
+		}
+	}
+	return nil, errors.New("unbalanced parentheses")
+}
+
+// nameList читает (a, b, c). Выражения вместо имен дают ошибку
+func (p *sqlParser) nameList() ([]string, error) {
+	group, err := p.group()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, item := range splitSQLList(group) {
+		// Длина префикса индекса MySQL и порядок сортировки не важны
+		if len(item) == 0 || !item[0].ident() {
+			return nil, errors.New("expected a column list")
+		}
+		names = append(names, item[0].text)
+		if len(item) > 1 && !item[1].punct("(") && !item[1].is("asc") && !item[1].is("desc") {
+			return nil, errors.New("expressions are not supported in column lists")
+		}
+	}
+	return names, nil
+}
+
+// splitSQLList делит токены по запятым верхнего уровня
+func splitSQLList(tokens []sqlToken) [][]sqlToken {
+	var (
+		items [][]sqlToken
+		start int
+		depth int
+	)
+	for i, t := range tokens {
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+		case t.punct(",") && depth == 0:
+			items = append(items, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, tokens[start:])
+}
+
+func (s *sqlSchema) statement(tokens []sqlToken) error {
+	p := &sqlParser{tokens: tokens}
+	switch {
+	case p.accept("create"):
+		p.accept("or", "replace")
+		for p.accept("global") || p.accept("local") || p.accept("temporary") || p.accept("temp") || p.accept("unlogged") {
+		}
+		switch {
+		case p.accept("table"):
+			return s.createTable(p)
+		case p.accept("type"):
+			return s.createType(p)
+		case p.accept("unique", "index"):
+			return s.createIndex(p, true)
+		case p.accept("index"):
+			return s.createIndex(p, false)
+		}
+	case p.accept("alter", "table"):
+		return s.alterTable(p)
+	}
+	return nil
+}
+
+func (s *sqlSchema) createTable(p *sqlParser) error {
+	p.accept("if", "not", "exists")
+	name, err := p.name()
+	if err != nil {
+		return fmt.Errorf("CREATE TABLE: %w", err)
+	}
+	// CREATE TABLE ... AS SELECT и PARTITION OF не описывают колонки
+	if !p.peek().punct("(") {
+		return nil
+	}
+	body, err := p.group()
+	if err != nil {
+		return fmt.Errorf("CREATE TABLE %s: %w", name, err)
+	}
+
+	table := &sqlTable{name: name}
+	for _, item := range splitSQLList(body) {
+		if err := table.element(&sqlParser{tokens: item}); err != nil {
+			return fmt.Errorf("CREATE TABLE %s: %w", name, err)
+		}
+	}
+	if s.table(name) == nil {
+		s.tables = append(s.tables, table)
+	}
+	return nil
+}
+
+// element разбирает колонку или ограничение таблицы
+func (t *sqlTable) element(p *sqlParser) error {
+	if p.done() {
+		return nil
+	}
+	if p.accept("constraint") {
+		p.next()
+	}
+	switch {
+	case p.accept("primary", "key"):
+		columns, err := p.nameList()
+		if err != nil {
+			return err
+		}
+		t.primary = columns
+		return nil
+	case p.accept("unique"):
+		if !p.accept("key") {
+			p.accept("index")
+		}
+		if !p.peek().punct("(") {
+			p.next()
+		}
+		columns, err := p.nameList()
+		if err != nil {
+			return nil
+		}
+		if len(columns) == 1 {
+			if c := t.column(columns[0]); c != nil {
+				c.unique = true
+			}
+		}
+		return nil
+	case p.accept("foreign", "key"):
+		if !p.peek().punct("(") {
+			p.next()
+		}
+		return t.foreignKey(p)
+	case p.accept("key"), p.accept("index"):
+		if !p.peek().punct("(") {
+			p.next()
+		}
+		columns, err := p.nameList()
+		if err == nil && len(columns) == 1 {
+			if c := t.column(columns[0]); c != nil {
+				c.indexed = true
+			}
+		}
+		return nil
+	case p.peek().is("check"), p.peek().is("exclude"), p.peek().is("like"),
+		p.peek().is("fulltext"), p.peek().is("spatial"):
+		return nil
+	}
+
+	column, err := parseSQLColumn(p)
+	if err != nil {
+		return err
+	}
+	t.columns = append(t.columns, column)
+	if column.primary {
+		t.primary = []string{column.name}
+	}
+	return nil
+}
+
+func (t *sqlTable) foreignKey(p *sqlParser) error {
+	columns, err := p.nameList()
+	if err != nil {
+		return err
+	}
+	if !p.accept("references") {
+		return errors.New("FOREIGN KEY without REFERENCES")
+	}
+	ref, err := parseSQLRef(p)
+	if err != nil {
+		return err
+	}
+	// Составные внешние ключи не превращаются в relation поля
+	if len(columns) == 1 {
+		if c := t.column(columns[0]); c != nil {
+			c.ref = ref
+		}
+	}
+	return nil
+}
+
+func parseSQLRef(p *sqlParser) (*sqlRef, error) {
+	table, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	ref := &sqlRef{table: table}
+	if p.peek().punct("(") {
+		columns, err := p.nameList()
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 1 {
+			ref.column = columns[0]
+		}
+	}
+	return ref, nil
+}
+
+func parseSQLColumn(p *sqlParser) (*sqlColumn, error) {
+	name := p.next()
+	if !name.ident() {
+		return nil, fmt.Errorf("unexpected %q", name.text)
+	}
+	column := &sqlColumn{name: name.text}
+
+	typ, err := parseSQLType(p)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", column.name, err)
+	}
+	column.typ = typ
+	switch typ.name {
+	case "serial", "serial2", "serial4", "serial8", "smallserial", "bigserial":
+		column.generated = true
+	}
+
+	for !p.done() {
+		switch {
+		case p.accept("not", "null"):
+			column.notNull = true
+		case p.accept("primary", "key"):
+			column.primary = true
+			column.notNull = true
+		case p.accept("unique"):
+			p.accept("key")
+			column.unique = true
+		case p.accept("references"):
+			ref, err := parseSQLRef(p)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", column.name, err)
+			}
+			column.ref = ref
+		case p.accept("auto_increment"), p.accept("autoincrement"), p.accept("generated"), p.accept("identity"):
+			column.generated = true
+		case p.peek().punct("("):
+			// CHECK (...), DEFAULT (...) и аргументы GENERATED не разбираются
+			if _, err := p.group(); err != nil {
+				return nil, fmt.Errorf("column %s: %w", column.name, err)
+			}
+		default:
+			p.next()
+		}
+	}
+	return column, nil
+}
+
+// sqlTypeWords продолжают имя типа: "character varying", "double precision",
+// "timestamp with time zone", "int unsigned"
+var sqlTypeWords = map[string]bool{
+	"varying": true, "precision": true, "with": true, "without": true, "time": true,
+	"zone": true, "unsigned": true, "signed": true, "zerofill": true,
+}
+
+func parseSQLType(p *sqlParser) (sqlType, error) {
+	t := p.next()
+	if !t.ident() {
+		return sqlType{}, fmt.Errorf("expected a type, got %q", t.text)
+	}
+	name := t.text
+	for p.peek().punct(".") {
+		p.next()
+		name = p.next().text
+	}
+	typ := sqlType{name: strings.ToLower(name)}
+
+	for {
+		switch next := p.peek(); {
+		case next.punct("("):
+			group, err := p.group()
+			if err != nil {
+				return typ, err
+			}
+			for _, arg := range splitSQLList(group) {
+				if len(arg) != 1 {
+					continue
+				}
+				switch arg[0].kind {
+				case sqlString:
+					typ.values = append(typ.values, arg[0].text)
+				case sqlNumber:
+					typ.args = append(typ.args, arg[0].text)
+				}
+			}
+		case next.punct("["):
+			for !p.done() && !p.next().punct("]") {
+			}
+			typ.array = true
+		case next.is("array"):
+			p.next()
+			typ.array = true
+		case next.kind == sqlWord && sqlTypeWords[strings.ToLower(next.text)]:
+			p.next()
+			// signed и zerofill не меняют тип для Go
+			if word := strings.ToLower(next.text); word != "signed" && word != "unsigned" && word != "zerofill" {
+				typ.name += " " + word
+			}
+		default:
+			return typ, nil
+		}
+	}
+}
+
+func (s *sqlSchema) createType(p *sqlParser) error {
+	name, err := p.name()
+	if err != nil {
+		return fmt.Errorf("CREATE TYPE: %w", err)
+	}
+	if !p.accept("as", "enum") {
+		return nil
+	}
+	group, err := p.group()
+	if err != nil {
+		return fmt.Errorf("CREATE TYPE %s: %w", name, err)
+	}
+	var values []string
+	for _, item := range splitSQLList(group) {
+		if len(item) == 1 && item[0].kind == sqlString {
+			values = append(values, item[0].text)
+		}
+	}
+	s.enums[strings.ToLower(name)] = values
+	return nil
+}
+
+func (s *sqlSchema) createIndex(p *sqlParser, unique bool) error {
+	p.accept("concurrently")
+	p.accept("if", "not", "exists")
+	if !p.peek().is("on") {
+		if _, err := p.name(); err != nil {
+			return fmt.Errorf("CREATE INDEX: %w", err)
+		}
+	}
+	if !p.accept("on") {
+		return nil
+	}
+	p.accept("only")
+	name, err := p.name()
+	if err != nil {
+		return fmt.Errorf("CREATE INDEX: %w", err)
+	}
+	if p.accept("using") {
+		p.next()
+	}
+	columns, err := p.nameList()
+	// Индексы по выражениям и по нескольким колонкам не переносятся на поля
+	if err != nil || len(columns) != 1 {
+		return nil
+	}
+	// Частичный индекс не гарантирует уникальность всей колонки
+	if p.accept("where") {
+		unique = false
+	}
+
+	table := s.table(name)
+	if table == nil {
+		return nil
+	}
+	if c := table.column(columns[0]); c != nil {
+		if unique {
+			c.unique = true
+		} else {
+			c.indexed = true
+		}
+	}
+	return nil
+}
+
+func (s *sqlSchema) alterTable(p *sqlParser) error {
+	p.accept("only")
+	p.accept("if", "exists")
+	name, err := p.name()
+	if err != nil {
+		return fmt.Errorf("ALTER TABLE: %w", err)
+	}
+	table := s.table(name)
+	if table == nil {
+		return nil
+	}
+
+	for _, action := range splitSQLList(p.tokens[p.pos:]) {
+		ap := &sqlParser{tokens: action}
+		if !ap.accept("add") {
+			continue
+		}
+		// ADD [COLUMN] добавляет колонку, ADD CONSTRAINT, PRIMARY KEY и т.д. - ограничение
+		if ap.accept("column") {
+			ap.accept("if", "not", "exists")
+		}
+		if err := table.element(ap); err != nil {
+			return fmt.Errorf("ALTER TABLE %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// primaryKey возвращает колонку первичного ключа. Без явного ключа им
+// считается колонка id, составной ключ не поддерживается
+func (t *sqlTable) primaryKey() *sqlColumn {
+	switch len(t.primary) {
+	case 0:
+		return t.column("id")
+	case 1:
+		return t.column(t.primary[0])
+	}
+	return nil
+}
+
+// hasTimestamps сообщает, есть ли в таблице обе колонки created_at и updated_at
+func (t *sqlTable) hasTimestamps() bool {
+	for _, name := range []string{"created_at", "updated_at"} {
+		c := t.column(name)
+		if c == nil || !strings.HasPrefix(c.typ.name, "timestamp") && c.typ.name != "datetime" {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *sqlSchema) entities() (Entities, error) {
+	// Сначала имена сущностей: relation поля ссылаются на них
+	names := make(map[string]string, len(s.tables))
+	var tables []*sqlTable
+	for _, table := range s.tables {
+		key := table.primaryKey()
+		if key == nil {
+			continue
+		}
+		if _, ok := keyType(key); !ok {
+			continue
+		}
+		if !sqlNamePattern.MatchString(table.name) {
+			return nil, fmt.Errorf("table %q: only letters, digits and underscores are supported in table names", table.name)
+		}
+		names[strings.ToLower(table.name)] = goName(singularize(snakeCase(table.name)))
+		tables = append(tables, table)
+	}
+
+	// relation - внешний ключ, который станет relation полем, если не замкнет цикл
+	type relation struct {
+		entity, field int
+		value         Field
+	}
+	var (
+		entities  Entities
+		relations []relation
+	)
+	for _, table := range tables {
+		entity := Entity{Name: names[strings.ToLower(table.name)]}
+		if entity.Table() != table.name {
+			entity.TableName = table.name
+		}
+		key := table.primaryKey()
+		if key.name != "id" {
+			entity.IDColumnName = key.name
+		}
+		entity.IDColumnType, _ = keyType(key)
+		entity.NoTimestamps = !table.hasTimestamps()
+
+		for _, c := range table.columns {
+			// Одиночная created_at или updated_at остается обычным полем
+			if c == key || entity.implicitField(strings.ToLower(c.name)) {
+				continue
+			}
+			field, ok := s.field(c)
+			if !ok {
+				continue
+			}
+			if ref := c.ref; ref != nil {
+				target := s.table(ref.table)
+				name, known := names[strings.ToLower(ref.table)]
+				if known && (ref.column == "" || strings.EqualFold(ref.column, target.primaryKey().name)) {
+					value := Field{
+						Name:     field.Name,
+						Type:     FieldRelation,
+						Required: c.notNull,
+						Unique:   field.Unique,
+						Indexed:  field.Indexed,
+						Null:     field.Null,
+						Ref:      name,
+					}
+					// Колонка ссылки сохраняет свой тип, иначе BIGINT ключ
+					// превратился бы в TEXT
+					if pgType := postgresType(c.typ); pgType != "TEXT" {
+						value.ColumnType = pgType
+					}
+					relations = append(relations, relation{entity: len(entities), field: len(entity.Fields), value: withColumn(value, c.name)})
+				}
+			}
+			entity.Fields = append(entity.Fields, withColumn(field, c.name))
+		}
+		if len(entity.Fields) == 0 {
+			return nil, fmt.Errorf("table %q has no columns besides the primary key and timestamps", table.name)
+		}
+		entities = append(entities, entity)
+	}
+
+	// Генератор не поддерживает циклические связи: ключ, замыкающий цикл,
+	// остается обычной колонкой с исходным типом
+	for _, r := range relations {
+		scalar := entities[r.entity].Fields[r.field]
+		entities[r.entity].Fields[r.field] = r.value
+		if _, err := entities.sorted(); err != nil {
+			entities[r.entity].Fields[r.field] = scalar
+		}
+	}
+	return entities, nil
+}
+
+// keyType - тип ключа сущности для колонки первичного ключа. Целый ключ
+// всегда выдает база данных: pg_dump и MySQL часто задают последовательность
+// отдельной командой, поэтому DEFAULT у колонки не проверяется. Ключ других
+// типов выдает use case, и колонка должна вмещать UUID. Пустой тип - TEXT
+func keyType(c *sqlColumn) (string, bool) {
+	if c.typ.array {
+		return "", false
+	}
+	switch c.typ.name {
+	case "smallint", "int2", "smallserial", "serial2", "tinyint":
+		return "SMALLSERIAL", true
+	case "int", "integer", "int4", "serial", "serial4", "mediumint":
+		return "SERIAL", true
+	case "bigint", "int8", "bigserial", "serial8":
+		return "BIGSERIAL", true
+	case "uuid", "text", "tinytext", "mediumtext", "longtext", "clob",
+		"varchar", "character varying", "nvarchar", "char", "character", "nchar", "bpchar":
+		typ := postgresType(c.typ)
+		if typ == "TEXT" {
+			return "", true
+		}
+		return typ, Entity{IDColumnType: typ}.validIDType()
+	}
+	return "", false
+}
+
+// withColumn сохраняет имя колонки, если оно не выводится из имени поля
+func withColumn(f Field, column string) Field {
+	if f.Column() != column {
+		f.ColumnName = column
+	}
+	return f
+}
+
+// field сопоставляет колонке поле. Типы, которые нельзя прочитать в
+// строку или число (bytea, geometry и т.д.), пропускаются
+func (s *sqlSchema) field(c *sqlColumn) (Field, bool) {
+	if !sqlNamePattern.MatchString(c.name) {
+		return Field{}, false
+	}
+	// DEFAULT не переносится в миграцию, а незаданное поле записывается
+	// нулевым значением Go, поэтому NOT NULL колонка с DEFAULT обязательна
+	field := Field{
+		Name:     c.name,
+		Required: c.notNull && !c.generated,
+		Unique:   c.unique,
+		Indexed:  c.indexed,
+		Null:     !c.notNull,
+	}
+
+	typ := c.typ
+	if typ.array {
+		field.Type = FieldString
+		field.ColumnType = postgresType(typ) + "[]"
+		return field, true
+	}
+	if values, ok := s.enums[typ.name]; ok {
+		typ = sqlType{name: "enum", values: values}
+	}
+
+	switch typ.name {
+	case "smallint", "int2", "smallserial", "serial2", "tinyint", "mediumint",
+		"int", "integer", "int4", "serial", "serial4", "bigint", "int8", "bigserial", "serial8":
+		if typ.name == "tinyint" && len(typ.args) == 1 && typ.args[0] == "1" {
+			field.Type = FieldBool
+			break
+		}
+		field.Type = FieldInt
+	case "bool", "boolean":
+		field.Type = FieldBool
+	case "timestamptz", "timestamp with time zone", "timestamp", "timestamp without time zone",
+		"datetime", "date":
+		field.Type = FieldTime
+	case "uuid":
+		field.Type = FieldUUID
+	case "enum":
+		field.Type = FieldEnum
+		field.Values = typ.values
+		for _, v := range typ.values {
+			// Значения, которые не годятся для Go констант, остаются строкой
+			if !enumValuePattern.MatchString(v) {
+				field.Type = FieldString
+				field.Values = nil
+				break
+			}
+		}
+		if len(field.Values) == 0 {
+			field.Type = FieldString
+		}
+	case "text", "tinytext", "mediumtext", "longtext", "clob", "citext", "name",
+		"varchar", "character varying", "nvarchar", "char", "character", "nchar", "bpchar",
+		"json", "jsonb", "xml", "numeric", "decimal", "dec", "real", "float", "float4",
+		"float8", "double", "double precision", "money", "time", "time with time zone",
+		"time without time zone", "timetz", "interval", "inet", "cidr", "macaddr", "year":
+		field.Type = FieldString
+	default:
+		return Field{}, false
+	}
+
+	if pgType := postgresType(typ); pgType != field.SQLType() && field.Type != FieldEnum {
+		field.ColumnType = pgType
+	}
+	// Пустое enum или UUID значение пишется как NULL, поэтому NOT NULL
+	// колонка такого типа обязательна даже со значением по умолчанию
+	if c.notNull && field.Nullable() {
+		field.Required = true
+	}
+	return field, true
+}
+
+// postgresType переводит тип колонки в PostgreSQL, MySQL типы заменяются
+// ближайшими аналогами
+func postgresType(typ sqlType) string {
+	args := ""
+	if len(typ.args) > 0 {
+		args = "(" + strings.Join(typ.args, ",") + ")"
+	}
+	switch typ.name {
+	case "tinyint":
+		if args == "(1)" {
+			return "BOOLEAN"
+		}
+		return "SMALLINT"
+	case "smallint", "int2", "smallserial", "serial2", "year":
+		return "SMALLINT"
+	case "int", "integer", "int4", "mediumint", "serial", "serial4":
+		return "INTEGER"
+	case "bigint", "int8", "bigserial", "serial8":
+		return "BIGINT"
+	case "bool", "boolean":
+		return "BOOLEAN"
+	case "timestamptz", "timestamp with time zone":
+		return "TIMESTAMPTZ"
+	case "timestamp", "timestamp without time zone", "datetime":
+		return "TIMESTAMP"
+	case "date":
+		return "DATE"
+	case "time", "time without time zone":
+		return "TIME"
+	case "timetz", "time with time zone":
+		return "TIMETZ"
+	case "varchar", "character varying", "nvarchar":
+		return "VARCHAR" + args
+	case "char", "character", "nchar", "bpchar":
+		return "CHAR" + args
+	case "numeric", "decimal", "dec":
+		return "NUMERIC" + args
+	case "real", "float", "float4":
+		return "REAL"
+	case "double", "double precision", "float8":
+		return "DOUBLE PRECISION"
+	case "tinytext", "mediumtext", "longtext", "clob":
+		return "TEXT"
+	}
+	return strings.ToUpper(typ.name)
+}
+
+// singularize обращает pluralize для имен таблиц: "order_items" -> "order_item"
+func singularize(snake string) string {
+	switch {
+	case strings.HasSuffix(snake, "ies") && len(snake) > 3:
+		return snake[:len(snake)-3] + "y"
+	case strings.HasSuffix(snake, "sses"), strings.HasSuffix(snake, "xes"), strings.HasSuffix(snake, "zes"),
+		strings.HasSuffix(snake, "ches"), strings.HasSuffix(snake, "shes"):
+		return snake[:len(snake)-2]
+	case strings.HasSuffix(snake, "ss"), strings.HasSuffix(snake, "us"), strings.HasSuffix(snake, "is"):
+		return snake
+	case strings.HasSuffix(snake, "s") && len(snake) > 1:
+		return snake[:len(snake)-1]
+	}
+	return snake
+}
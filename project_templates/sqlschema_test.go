@@ -0,0 +1,150 @@
+package project_templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSQLSchemaKeyTypes(t *testing.T) {
+	entities, err := ParseSQLSchema([]byte(`
+CREATE TABLE customers (
+    id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE orders (
+    id uuid PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customers(id),
+    note text
+);
+
+CREATE TABLE codes (
+    code VARCHAR(8) PRIMARY KEY,
+    title text
+);
+`))
+	if err != nil {
+		t.Fatalf("ParseSQLSchema() error = %v", err)
+	}
+	// codes пропускается: в его ключ не поместится UUID
+	if len(entities) != 2 {
+		t.Fatalf("expected customers and orders, got %d entities", len(entities))
+	}
+
+	customer, order := entities[0], entities[1]
+	if customer.IDColumnType != "BIGSERIAL" || !customer.IDGenerated() {
+		t.Errorf("customer key = %q, expected a BIGSERIAL key generated by the database", customer.IDColumnType)
+	}
+	if order.IDColumnType != "UUID" || order.IDGenerated() {
+		t.Errorf("order key = %q, expected a UUID key generated by the service", order.IDColumnType)
+	}
+	ref := order.Fields[0]
+	if ref.Type != FieldRelation || ref.ColumnType != "BIGINT" {
+		t.Errorf("customer_id = %s %q, expected a BIGINT relation", ref.Type, ref.ColumnType)
+	}
+
+	p := &ProjectConfig{Name: "example.com/shop", Dependencies: []string{"http", "postgres"}, Entities: entities}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	files, err := p.GenerateProject()
+	if err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+
+	migrations := map[string]string{
+		migrationName(1, "create_customers_table", "up"): `"id" BIGSERIAL PRIMARY KEY`,
+		migrationName(2, "create_orders_table", "up"):    `"customer_id" BIGINT NOT NULL REFERENCES "customers" ("id")`,
+	}
+	for path, want := range migrations {
+		if !strings.Contains(files[path], want) {
+			t.Errorf("%s does not contain %s:\n%s", path, want, files[path])
+		}
+	}
+
+	repository := files["internal/repository/postgres/customer_repository.go"]
+	if strings.Contains(repository, `"id": customer.ID`) || !strings.Contains(repository, `Returning(goqu.L(`+"`"+`"id"::text`+"`"+`))`) {
+		t.Errorf("expected Create to leave the key to the database and read it back:\n%s", repository)
+	}
+	if strings.Contains(files["internal/usecase/customer_usecase.go"], "customer.ID = newID()") {
+		t.Error("expected the customer use case not to generate the key")
+	}
+	if !strings.Contains(files["internal/usecase/order_usecase.go"], "order.ID = newID()") {
+		t.Error("expected the order use case to generate the key")
+	}
+}
+
+func TestValidateIDColumnType(t *testing.T) {
+	for _, typ := range []string{"BIGINT", "VARCHAR(8)", "DATE"} {
+		entities := Entities{{Name: "code", IDColumnType: typ, Fields: []Field{{Name: "title", Type: FieldString}}}}
+		if err := entities.Validate(); err == nil {
+			t.Errorf("expected id column type %s to be rejected", typ)
+		}
+	}
+}
+
+func TestParseSQLSchemaDefaults(t *testing.T) {
+	entities, err := ParseSQLSchema([]byte(`
+CREATE TABLE accounts (
+    id BIGSERIAL PRIMARY KEY,
+    balance NUMERIC(12,2) NOT NULL DEFAULT 0,
+    opened_at timestamptz NOT NULL DEFAULT now(),
+    closed_at timestamptz DEFAULT now()
+);
+`))
+	if err != nil {
+		t.Fatalf("ParseSQLSchema() error = %v", err)
+	}
+
+	// DEFAULT не попадает в миграцию, поэтому NOT NULL колонки с ним
+	// обязательны, иначе запишется пустая строка или нулевое время
+	fields := map[string]Field{}
+	for _, f := range entities[0].Fields {
+		fields[f.Column()] = f
+	}
+	for _, column := range []string{"balance", "opened_at"} {
+		f := fields[column]
+		if !f.Required || !strings.HasPrefix(f.ValidateTag(), "required") {
+			t.Errorf("%s: expected a required field, got required=%v validate=%q", column, f.Required, f.ValidateTag())
+		}
+	}
+	if fields["closed_at"].Required {
+		t.Error("closed_at: a nullable column should stay optional")
+	}
+}
+
+func TestParseSQLSchemaLoneTimestamp(t *testing.T) {
+	entities, err := ParseSQLSchema([]byte(`
+CREATE TABLE audit_logs (
+    id uuid PRIMARY KEY,
+    message text NOT NULL,
+    created_at timestamptz
+);
+`))
+	if err != nil {
+		t.Fatalf("ParseSQLSchema() error = %v", err)
+	}
+
+	// Без updated_at created_at остается обычным полем таблицы
+	entity := entities[0]
+	if entity.HasTimestamps() || len(entity.Fields) != 2 || entity.Fields[1].Column() != "created_at" {
+		t.Fatalf("expected created_at to be imported as a field, got %+v", entity)
+	}
+	for _, f := range entity.StructFields() {
+		if strings.TrimSpace(f.Name) == "UpdatedAt" {
+			t.Error("expected no UpdatedAt in a table without updated_at")
+		}
+	}
+
+	p := &ProjectConfig{Name: "example.com/audit", Dependencies: []string{"http", "postgres"}, Entities: entities}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	files, err := p.GenerateProject()
+	if err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+	if migration := files[migrationName(1, "create_audit_logs_table", "up")]; !strings.Contains(migration, `"created_at" TIMESTAMPTZ`) {
+		t.Errorf("expected the migration to keep created_at:\n%s", migration)
+	}
+}
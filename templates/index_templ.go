@@ -9,7 +9,11 @@ import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
+
+	"github.com/malinatrash/golang-initializr/project_templates"
 )
 
 type Dependency struct {
@@ -19,12 +23,51 @@ type Dependency struct {
 	Category    string
 }
 
+// ProjectForm - состояние формы генерации. Его восстанавливают из ссылки
+// и присылают заново при каждом изменении в дизайнере сущностей
 type ProjectForm struct {
 	Name         string
-	Dependencies []Dependency
+	Dependencies []string
+	Entities     project_templates.Entities
+
+	// Previews и Error заполняются из project_templates.PreviewEntities
+	Previews []project_templates.EntityPreview
+	Error    string
 }
 
-func Index() templ.Component {
+// DefaultProjectForm - форма нового проекта: Echo, Docker и сущность User
+func DefaultProjectForm() ProjectForm {
+	return ProjectForm{
+		Dependencies: []string{"http", "docker"},
+		Entities:     project_templates.DefaultEntities(),
+	}
+}
+
+func (f ProjectForm) Has(dependency string) bool {
+	for _, d := range f.Dependencies {
+		if d == dependency {
+			return true
+		}
+	}
+	return false
+}
+
+// ShareURL - ссылка на главную страницу, открывающая форму в том же состоянии
+func (f ProjectForm) ShareURL() string {
+	query := url.Values{}
+	query.Set("name", f.Name)
+	for _, d := range f.Dependencies {
+		query.Add("dependencies", d)
+	}
+	if len(f.Entities) > 0 {
+		if data, err := json.Marshal(f.Entities); err == nil {
+			query.Set("entities", string(data))
+		}
+	}
+	return "/?" + query.Encode()
+}
+
+func Index(form ProjectForm) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -57,7 +100,238 @@ func Index() templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"hero\"><h1>Golang Initializr</h1><p>Quickly generate Go project skeleton with the dependencies you need</p></div><div class=\"project-form\"><form id=\"project-form\" action=\"/generate\" method=\"post\"><div class=\"form-group\"><label for=\"project-name\">Project Name</label> <input type=\"text\" id=\"project-name\" name=\"name\" placeholder=\"github.com/username/project\" required></div><div class=\"dependencies-section\"><h2>Dependencies</h2><p class=\"note\">All projects include: Uber FX, Zap Logger, Clean Architecture</p><div class=\"dependency-categories\"><div class=\"category\"><h3>Databases</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"checkbox\" id=\"postgres\" name=\"dependencies\" value=\"postgres\"> <label for=\"postgres\">PostgreSQL</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"redis\" name=\"dependencies\" value=\"redis\"> <label for=\"redis\">Redis</label></div></div></div><div class=\"category\"><h3>Messaging</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"checkbox\" id=\"kafka\" name=\"dependencies\" value=\"kafka\"> <label for=\"kafka\">Kafka</label></div></div></div><div class=\"category\"><h3>API</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"checkbox\" id=\"http\" name=\"dependencies\" value=\"http\" checked> <label for=\"http\">HTTP (Echo)</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"grpc\" name=\"dependencies\" value=\"grpc\"> <label for=\"grpc\">gRPC</label></div></div></div><div class=\"category\"><h3>Tools</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"checkbox\" id=\"docker\" name=\"dependencies\" value=\"docker\" checked> <label for=\"docker\">Docker</label></div></div></div></div></div><div class=\"form-actions\"><button type=\"submit\" class=\"btn-primary\">Generate Project</button></div></form><!-- Form submits directly to generate endpoint for immediate download --></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"hero\"><h1>Golang Initializr</h1><p>Quickly generate Go project skeleton with the dependencies you need</p></div><div class=\"project-form\"><form id=\"project-form\" action=\"/generate\" method=\"post\" enctype=\"multipart/form-data\"><!-- Любое изменение формы обновляет превью сущностей и ссылку в адресной строке --><div hx-post=\"/entities/preview\" hx-trigger=\"input changed delay:400ms, change\" hx-target=\"#entity-preview\" hx-swap=\"outerHTML\" hx-params=\"not openapi,schema\"><div class=\"form-group\"><label for=\"project-name\">Project Name</label> <input type=\"text\" id=\"project-name\" name=\"name\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(form.Name)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 84, Col: 23}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\" placeholder=\"github.com/username/project\" required></div><div class=\"dependencies-section\"><h2>Dependencies</h2><p class=\"note\">All projects include: Uber FX, Zap Logger, Clean Architecture</p><div class=\"dependency-categories\"><div class=\"category\"><h3>Databases</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"checkbox\" id=\"postgres\" name=\"dependencies\" value=\"postgres\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("postgres") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "> <label for=\"postgres\">PostgreSQL</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"redis\" name=\"dependencies\" value=\"redis\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("redis") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "> <label for=\"redis\">Redis</label></div></div></div><div class=\"category\"><h3>Messaging</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"checkbox\" id=\"kafka\" name=\"dependencies\" value=\"kafka\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("kafka") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "> <label for=\"kafka\">Kafka</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"nats\" name=\"dependencies\" value=\"nats\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("nats") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "> <label for=\"nats\">NATS JetStream</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"rabbitmq\" name=\"dependencies\" value=\"rabbitmq\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("rabbitmq") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "> <label for=\"rabbitmq\">RabbitMQ</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"outbox\" name=\"dependencies\" value=\"outbox\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("outbox") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "> <label for=\"outbox\">Transactional Outbox (PostgreSQL + Kafka)</label></div></div></div><div class=\"category\"><h3>API</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"radio\" id=\"http\" name=\"dependencies\" value=\"http\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("http") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "> <label for=\"http\">HTTP (Echo)</label></div><div class=\"dependency-item\"><input type=\"radio\" id=\"chi\" name=\"dependencies\" value=\"chi\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("chi") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "> <label for=\"chi\">HTTP (chi)</label></div><div class=\"dependency-item\"><input type=\"radio\" id=\"gin\" name=\"dependencies\" value=\"gin\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("gin") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "> <label for=\"gin\">HTTP (Gin)</label></div><div class=\"dependency-item\"><input type=\"radio\" id=\"fiber\" name=\"dependencies\" value=\"fiber\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("fiber") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "> <label for=\"fiber\">HTTP (Fiber)</label></div><div class=\"dependency-item\"><input type=\"radio\" id=\"servemux\" name=\"dependencies\" value=\"servemux\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("servemux") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "> <label for=\"servemux\">HTTP (net/http ServeMux)</label></div><div class=\"dependency-item\"><input type=\"radio\" id=\"no-http\" name=\"dependencies\" value=\"\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "> <label for=\"no-http\">No HTTP API (gRPC only)</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"grpc\" name=\"dependencies\" value=\"grpc\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("grpc") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "> <label for=\"grpc\">gRPC</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"grpc-gateway\" name=\"dependencies\" value=\"grpc-gateway\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("grpc-gateway") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "> <label for=\"grpc-gateway\">gRPC-Gateway (REST from gRPC, needs Echo + gRPC)</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"graphql\" name=\"dependencies\" value=\"graphql\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("graphql") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, "> <label for=\"graphql\">GraphQL (gqlgen)</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"realtime\" name=\"dependencies\" value=\"realtime\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("realtime") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "> <label for=\"realtime\">Realtime (WebSocket + SSE, needs Echo)</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"auth\" name=\"dependencies\" value=\"auth\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("auth") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "> <label for=\"auth\">JWT auth (HS256 / RS256 + JWKS, needs Echo)</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"rbac\" name=\"dependencies\" value=\"rbac\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("rbac") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "> <label for=\"rbac\">RBAC policy (needs JWT auth)</label></div></div></div><div class=\"category\"><h3>Observability</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"checkbox\" id=\"otel\" name=\"dependencies\" value=\"otel\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("otel") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "> <label for=\"otel\">OpenTelemetry (traces + metrics)</label></div><div class=\"dependency-item\"><input type=\"checkbox\" id=\"prometheus\" name=\"dependencies\" value=\"prometheus\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("prometheus") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "> <label for=\"prometheus\">Prometheus (/metrics + Grafana dashboard)</label></div></div></div><div class=\"category\"><h3>Tools</h3><div class=\"dependency-list\"><div class=\"dependency-item\"><input type=\"checkbox\" id=\"docker\" name=\"dependencies\" value=\"docker\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if form.Has("docker") {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "> <label for=\"docker\">Docker</label></div></div></div></div></div><div class=\"entities-section\"><h2>Entities</h2><p class=\"note\">Every entity gets a domain struct, repository, use case, API handlers and a migration. ID, CreatedAt and UpdatedAt are added automatically</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = EntityDesigner(form).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, "</div></div><div class=\"spec-section\"><h2>API Specification</h2><p class=\"note\">Optional: upload an OpenAPI 3 document to generate the Echo server interfaces, types and handler stubs from it</p><div class=\"form-group\"><label for=\"openapi\">OpenAPI document</label> <input type=\"file\" id=\"openapi\" name=\"openapi\" accept=\".yaml,.yml,.json\"></div><p class=\"note\">Optional: upload .proto files to generate the gRPC servers, domain types and use case interfaces from your services instead of the entity services. Requires gRPC</p><div class=\"form-group\"><label for=\"proto\">Protobuf files</label> <input type=\"file\" id=\"proto\" name=\"proto\" accept=\".proto\" multiple></div></div><div class=\"form-actions\"><button type=\"submit\" class=\"btn-primary\">Generate Project</button></div></form><!-- Form submits directly to generate endpoint for immediate download --></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -87,21 +361,21 @@ func downloadLink(projectName string, dependencies []string) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var3 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var3 == nil {
-			templ_7745c5c3_Var3 = templ.NopComponent
+		templ_7745c5c3_Var4 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var4 == nil {
+			templ_7745c5c3_Var4 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<a href=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "<a href=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var4 templ.SafeURL = templ.SafeURL("/download?session=" + projectName + "-" + fmt.Sprint(len(dependencies)))
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var4)))
+		var templ_7745c5c3_Var5 templ.SafeURL = templ.SafeURL("/download?session=" + projectName + "-" + fmt.Sprint(len(dependencies)))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var5)))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\" class=\"btn-download\">Download Project</a>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "\" class=\"btn-download\">Download Project</a>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}